@@ -0,0 +1,85 @@
+package api
+
+import (
+	"server/model/request"
+	"server/model/response"
+	"server/service"
+	"server/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessTokenApi 个人访问令牌API
+type AccessTokenApi struct{}
+
+var accessTokenService = service.ServiceGroups.AccessTokenService
+
+// ListTokens 获取当前用户的访问令牌
+func (a *AccessTokenApi) ListTokens(c *gin.Context) {
+	userId, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return
+	}
+
+	tokens, err := accessTokenService.List(userId)
+	if err != nil {
+		response.FailWithMessage("获取访问令牌失败: "+err.Error(), c)
+		return
+	}
+	list := make([]response.AccessTokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		list = append(list, response.ToAccessTokenResponse(token))
+	}
+	response.OkWithData(list, c)
+}
+
+// CreateToken 创建访问令牌，明文令牌只在本次响应中返回
+func (a *AccessTokenApi) CreateToken(c *gin.Context) {
+	var req request.AccessTokenCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+	if errMsg := utils.ValidateStruct(req); errMsg != "" {
+		response.FailWithMessage(errMsg, c)
+		return
+	}
+
+	userId, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return
+	}
+
+	token, plain, err := accessTokenService.Create(userId, req)
+	if err != nil {
+		response.FailWithMessage("创建访问令牌失败: "+err.Error(), c)
+		return
+	}
+	resp := response.ToAccessTokenResponse(token)
+	resp.Token = plain
+	response.OkWithDetailed(resp, "创建成功，请立即保存令牌，之后将无法再次查看", c)
+}
+
+// RevokeToken 撤销访问令牌
+func (a *AccessTokenApi) RevokeToken(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.FailWithMessage("无效的令牌ID", c)
+		return
+	}
+
+	userId, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return
+	}
+
+	if err := accessTokenService.Revoke(userId, uint(id)); err != nil {
+		response.FailWithMessage("撤销访问令牌失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("令牌已撤销", c)
+}
@@ -1,14 +1,17 @@
 package api
 
 import (
+	"archive/zip"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"go.uber.org/zap"
 
 	"server/global"
+	"server/model/appType"
 	"server/model/request"
 	"server/model/response"
 	"server/service"
@@ -18,6 +21,7 @@ import (
 type ArticleApi struct{}
 
 var articleService = service.ServiceGroups.ArticleService
+var articleCounterService = service.ServiceGroups.ArticleCounterService
 
 // @Summary 创建文章
 // @Description 创建新文章，需要认证
@@ -60,6 +64,8 @@ func (a *ArticleApi) CreateArticle(c *gin.Context) {
 		return
 	}
 
+	recordAudit(c, appType.AuditActionCreate, appType.AuditTargetArticle, article.ID, nil, article)
+
 	// 直接使用article对象中的关联数据，无需额外查询
 	// 注意：这需要确保ArticleService.CreateArticle方法已经预加载了相关关联数据
 	response.OkWithData(response.ToArticleResponse(article, article.Category, article.Tags, article.Author.Username, userID), c)
@@ -153,6 +159,15 @@ func (a *ArticleApi) GetArticle(c *gin.Context) {
 		return
 	}
 
+	// 记录阅读量，爬虫不计入；登录用户按用户去重，未登录按IP去重
+	if !utils.IsBot(c.Request.UserAgent()) {
+		visitor := "ip:" + c.ClientIP()
+		if currentUserID != 0 {
+			visitor = "user:" + strconv.FormatUint(uint64(currentUserID), 10)
+		}
+		go articleCounterService.RecordView(article.ID, visitor)
+	}
+
 	// 获取关联数据
 	articleResponse := response.ToArticleResponse(article, article.Category, article.Tags, article.Author.Username, currentUserID)
 
@@ -217,6 +232,7 @@ func (a *ArticleApi) UpdateArticle(c *gin.Context) {
 		response.FailWithMessage("更新文章失败: "+err.Error(), c)
 		return
 	}
+	recordAudit(c, appType.AuditActionUpdate, appType.AuditTargetArticle, id, article, updatedArticle)
 
 	response.OkWithData(response.ToArticleResponse(updatedArticle, updatedArticle.Category, updatedArticle.Tags, updatedArticle.Author.Username, currentUserID), c)
 }
@@ -265,6 +281,7 @@ func (a *ArticleApi) DeleteArticle(c *gin.Context) {
 		response.FailWithMessage("删除文章失败: "+err.Error(), c)
 		return
 	}
+	recordAudit(c, appType.AuditActionDelete, appType.AuditTargetArticle, id, article, nil)
 
 	response.OkWithMessage("文章删除成功", c)
 }
@@ -297,7 +314,7 @@ func (a *ArticleApi) SearchArticles(c *gin.Context) {
 		zap.Int("size", req.Size))
 
 	// 调用服务层搜索文章
-	result, err := articleService.SearchArticles(req)
+	result, err := articleService.SearchArticles(req, c.ClientIP())
 	if err != nil {
 		response.FailWithMessage("搜索失败: "+err.Error(), c)
 		return
@@ -306,6 +323,31 @@ func (a *ArticleApi) SearchArticles(c *gin.Context) {
 	response.OkWithData(result, c)
 }
 
+// @Summary 搜索自动补全
+// @Description 根据输入的前缀返回文章标题、标签和热门搜索词建议，前缀为空时返回热门搜索词
+// @Tags article
+// @Accept json
+// @Produce json
+// @Param prefix query string false "输入的前缀"
+// @Param size query int false "建议条数"
+// @Success 200 {object} response.Response{data=[]es.ArticleSuggestion}
+// @Router /api/articles/suggest [get]
+func (a *ArticleApi) SuggestArticles(c *gin.Context) {
+	var req request.SuggestArticleRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage("参数错误: "+err.Error(), c)
+		return
+	}
+
+	suggestions, err := articleService.SuggestArticles(req)
+	if err != nil {
+		response.FailWithMessage("获取搜索建议失败: "+err.Error(), c)
+		return
+	}
+
+	response.OkWithData(suggestions, c)
+}
+
 // @Summary 切换文章点赞状态
 // @Description 点赞或取消点赞文章，需要认证
 // @Tags article
@@ -698,3 +740,85 @@ func (a *ArticleApi) SyncAllArticlesToES(c *gin.Context) {
 
 	response.OkWithMessage("文章同步任务已启动，请查看日志了解进度", c)
 }
+
+var articleMarkdownService = service.ServiceGroups.ArticleMarkdownService
+
+// maxMarkdownImportSize 导入压缩包的大小上限
+const maxMarkdownImportSize = 200 << 20
+
+// ExportArticlesMarkdown 导出全部文章为 Markdown 压缩包（管理员）
+func (a *ArticleApi) ExportArticlesMarkdown(c *gin.Context) {
+	currentUserID, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return
+	}
+	if !utils.IsAdmin(currentUserID) {
+		response.Forbidden("需要管理员权限", c)
+		return
+	}
+
+	fileName := fmt.Sprintf("articles_markdown_%s.zip", time.Now().Format("20060102150405"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", "attachment; filename="+fileName)
+	if _, err := articleMarkdownService.ExportZip(c.Writer); err != nil {
+		// 响应头可能已发出，只能记录错误
+		global.ZapLog.Error("导出文章Markdown失败", zap.Error(err))
+		if !c.Writer.Written() {
+			c.Header("Content-Disposition", "")
+			response.FailWithMessage("导出失败: "+err.Error(), c)
+		}
+	}
+}
+
+// ImportArticlesMarkdown 从 Markdown 压缩包导入文章（管理员），dry_run=true 时只返回预演报告
+func (a *ArticleApi) ImportArticlesMarkdown(c *gin.Context) {
+	currentUserID, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return
+	}
+	if !utils.IsAdmin(currentUserID) {
+		response.Forbidden("需要管理员权限", c)
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		response.FailWithMessage("请上传zip压缩包", c)
+		return
+	}
+	defer file.Close()
+	if header.Size > maxMarkdownImportSize {
+		response.FailWithMessage("压缩包不能超过200MB", c)
+		return
+	}
+	archive, err := zip.NewReader(file, header.Size)
+	if err != nil {
+		response.FailWithMessage("无法读取压缩包: "+err.Error(), c)
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(c.DefaultPostForm("dry_run", c.Query("dry_run")))
+	report, err := articleMarkdownService.Import(archive, service.MarkdownImportOptions{
+		AuthorID: currentUserID,
+		DryRun:   dryRun,
+	})
+	if err != nil {
+		global.ZapLog.Error("导入文章Markdown失败", zap.Error(err))
+		response.FailWithMessage("导入失败: "+err.Error(), c)
+		return
+	}
+
+	if !dryRun {
+		for _, item := range report.Items {
+			switch item.Action {
+			case service.MarkdownImportCreate:
+				recordAudit(c, appType.AuditActionCreate, appType.AuditTargetArticle, item.ArticleID, nil, item)
+			case service.MarkdownImportUpdate:
+				recordAudit(c, appType.AuditActionUpdate, appType.AuditTargetArticle, item.ArticleID, nil, item)
+			}
+		}
+	}
+	response.OkWithDetailed(report, "导入完成", c)
+}
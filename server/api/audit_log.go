@@ -0,0 +1,103 @@
+package api
+
+import (
+	"fmt"
+	"server/global"
+	"server/model/appType"
+	"server/model/request"
+	"server/model/response"
+	"server/service"
+	"server/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AuditLogApi 审计日志API
+type AuditLogApi struct{}
+
+var auditService = service.ServiceGroups.AuditService
+
+// recordAudit 记录当前请求中的修改操作，操作人、IP和User-Agent取自请求上下文
+func recordAudit(c *gin.Context, action appType.AuditAction, targetType appType.AuditTargetType, targetID interface{}, before, after interface{}) {
+	actorID, _ := utils.GetUserID(c)
+	auditService.Record(service.AuditEntry{
+		ActorID:    actorID,
+		ActorName:  c.GetString("username"),
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   fmt.Sprint(targetID),
+		Before:     before,
+		After:      after,
+		IP:         c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	})
+}
+
+// bindAuditLogQuery 解析查询条件并检查管理员权限
+func bindAuditLogQuery(c *gin.Context) (req request.AuditLogQueryRequest, ok bool) {
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return req, false
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Size <= 0 || req.Size > 100 {
+		req.Size = 20
+	}
+	if errMsg := utils.ValidateStruct(req); errMsg != "" {
+		response.FailWithMessage(errMsg, c)
+		return req, false
+	}
+
+	userId, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return req, false
+	}
+	if !utils.IsAdmin(userId) {
+		response.Forbidden("需要管理员权限", c)
+		return req, false
+	}
+	return req, true
+}
+
+// GetAuditLogList 分页查询审计日志，支持按操作人、操作、对象和日期筛选
+func (a *AuditLogApi) GetAuditLogList(c *gin.Context) {
+	req, ok := bindAuditLogQuery(c)
+	if !ok {
+		return
+	}
+
+	list, total, err := auditService.GetAuditLogList(req)
+	if err != nil {
+		response.FailWithMessage("获取审计日志失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.Size,
+	}, "获取成功", c)
+}
+
+// ExportAuditLogs 按筛选条件导出审计日志为CSV
+func (a *AuditLogApi) ExportAuditLogs(c *gin.Context) {
+	req, ok := bindAuditLogQuery(c)
+	if !ok {
+		return
+	}
+
+	fileName := fmt.Sprintf("audit_logs_%s.csv", time.Now().Format("20060102150405"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", "attachment; filename="+fileName)
+	// 写入UTF-8 BOM，便于Excel正确识别中文
+	c.Writer.WriteString("\xEF\xBB\xBF")
+	if err := auditService.ExportAuditLogs(req, c.Writer); err != nil {
+		// 响应头已发出，只能记录错误
+		global.ZapLog.Error("导出审计日志失败", zap.Error(err))
+	}
+}
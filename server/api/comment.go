@@ -1,6 +1,7 @@
 package api
 
 import (
+	"server/model/appType"
 	"server/model/request"
 	"server/model/response"
 	"server/service"
@@ -47,6 +48,7 @@ func (a *CommentApi) CreateComment(c *gin.Context) {
 		response.FailWithMessage("创建评论失败: "+err.Error(), c)
 		return
 	}
+	recordAudit(c, appType.AuditActionCreate, appType.AuditTargetComment, comment.ID, nil, comment)
 
 	response.OkWithData(response.ToCommentResponse(comment), c)
 }
@@ -172,11 +174,13 @@ func (a *CommentApi) UpdateComment(c *gin.Context) {
 		response.NoAuth(err.Error(), c)
 		return
 	}
+	before, _ := commentService.GetCommentByID(id)
 	comment, err := commentService.UpdateComment(id, userID, req)
 	if err != nil {
 		response.FailWithMessage("更新评论失败: "+err.Error(), c)
 		return
 	}
+	recordAudit(c, appType.AuditActionUpdate, appType.AuditTargetComment, id, before, comment)
 
 	response.OkWithData(response.ToCommentResponse(comment), c)
 }
@@ -205,10 +209,12 @@ func (a *CommentApi) DeleteComment(c *gin.Context) {
 		response.NoAuth(err.Error(), c)
 		return
 	}
+	before, _ := commentService.GetCommentByID(id)
 	if err := commentService.DeleteComment(id, userID); err != nil {
 		response.FailWithMessage("删除评论失败: "+err.Error(), c)
 		return
 	}
+	recordAudit(c, appType.AuditActionDelete, appType.AuditTargetComment, id, before, nil)
 
 	response.OkWithMessage("评论删除成功", c)
 }
@@ -253,6 +259,7 @@ func (a *CommentApi) ReplyToComment(c *gin.Context) {
 		response.FailWithMessage("回复评论失败: "+err.Error(), c)
 		return
 	}
+	recordAudit(c, appType.AuditActionCreate, appType.AuditTargetComment, comment.ID, nil, comment)
 
 	response.OkWithData(response.ToCommentResponse(comment), c)
 }
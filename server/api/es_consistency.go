@@ -0,0 +1,70 @@
+package api
+
+import (
+	"server/model/appType"
+	"server/model/response"
+	"server/service"
+	"server/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ESConsistencyApi ES索引与数据库的一致性检查API，仅管理员可用
+type ESConsistencyApi struct{}
+
+// checkConsistencyAdmin 检查管理员权限
+func checkConsistencyAdmin(c *gin.Context) bool {
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return false
+	}
+	if !utils.IsAdmin(userID) {
+		response.Forbidden("需要管理员权限", c)
+		return false
+	}
+	return true
+}
+
+// CheckConsistency 比较索引与数据库中已发布的文章，返回缺失、多余和过期的文档
+func (e *ESConsistencyApi) CheckConsistency(c *gin.Context) {
+	if !checkConsistencyAdmin(c) {
+		return
+	}
+	report, err := service.NewArticleESService().CheckConsistency()
+	if err != nil {
+		response.FailWithMessage("一致性检查失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(report, c)
+}
+
+// RepairConsistency 重新检查并修复不一致：缺失和过期的文档重新写入，多余的文档删除
+func (e *ESConsistencyApi) RepairConsistency(c *gin.Context) {
+	if !checkConsistencyAdmin(c) {
+		return
+	}
+	esService := service.NewArticleESService()
+	report, err := esService.CheckConsistency()
+	if err != nil {
+		response.FailWithMessage("一致性检查失败: "+err.Error(), c)
+		return
+	}
+	if report.Consistent() {
+		response.OkWithDetailed(report, "索引与数据库一致，无需修复", c)
+		return
+	}
+	result, err := esService.RepairConsistency(report)
+	if err != nil {
+		response.FailWithMessage("修复失败: "+err.Error(), c)
+		return
+	}
+	recordAudit(c, appType.AuditActionUpdate, appType.AuditTargetESIndex, "consistency", nil, gin.H{
+		"missing": report.Missing,
+		"orphan":  report.Orphan,
+		"stale":   report.Stale,
+		"indexed": result.Indexed,
+		"deleted": result.Deleted,
+	})
+	response.OkWithDetailed(report, "修复完成", c)
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"server/model/appType"
+	"server/model/request"
+	"server/model/response"
+	"server/service"
+	"server/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ESOutboxApi 文章同步到ES的事件队列管理API，仅管理员可用
+type ESOutboxApi struct{}
+
+var esOutboxService = service.ServiceGroups.ESOutboxService
+
+// checkOutboxAdmin 检查管理员权限
+func checkOutboxAdmin(c *gin.Context) bool {
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return false
+	}
+	if !utils.IsAdmin(userID) {
+		response.Forbidden("需要管理员权限", c)
+		return false
+	}
+	return true
+}
+
+// GetOutboxStatus 查看同步延迟、待投递和失败的事件
+func (e *ESOutboxApi) GetOutboxStatus(c *gin.Context) {
+	if !checkOutboxAdmin(c) {
+		return
+	}
+	status, err := esOutboxService.Status()
+	if err != nil {
+		response.FailWithMessage("获取同步状态失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(status, c)
+}
+
+// RetryOutboxEvents 把失败的事件重新放回队列，由定时任务重新投递
+func (e *ESOutboxApi) RetryOutboxEvents(c *gin.Context) {
+	if !checkOutboxAdmin(c) {
+		return
+	}
+	var req request.ESOutboxRetryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误: "+err.Error(), c)
+		return
+	}
+	count, err := esOutboxService.Retry(req.IDs)
+	if err != nil {
+		response.FailWithMessage("重试失败: "+err.Error(), c)
+		return
+	}
+	recordAudit(c, appType.AuditActionUpdate, appType.AuditTargetESOutbox, "retry", nil, gin.H{"ids": req.IDs, "count": count})
+	response.OkWithDetailed(gin.H{"count": count}, "已重新加入队列", c)
+}
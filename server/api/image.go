@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"server/global"
+	"server/model/appType"
 	"server/model/database"
 	"server/model/request"
 	"server/model/response"
@@ -85,6 +86,7 @@ func UploadImage(c *gin.Context) {
 		response.FailWithMessage("图片上传失败: "+err.Error(), c)
 		return
 	}
+	recordAudit(c, appType.AuditActionCreate, appType.AuditTargetMedia, media.ID, nil, media)
 
 	// 在使用 imageID 前添加转换逻辑
 	imageIDUint, err := utils.StringToUint(imageID)
@@ -172,10 +174,12 @@ func DeleteImage(c *gin.Context) {
 		response.FailWithMessage("参数错误", c)
 		return
 	}
+	before, _ := service.GetImageByID(id)
 	if err := service.DeleteImage(id, userID); err != nil {
 		response.FailWithMessage("图片删除失败: "+err.Error(), c)
 		return
 	}
+	recordAudit(c, appType.AuditActionDelete, appType.AuditTargetMedia, id, before, nil)
 
 	response.OkWithMessage("图片删除成功", c)
 }
@@ -199,10 +203,13 @@ func UpdateImage(c *gin.Context) {
 	}
 	imageInfo.ID = id
 
+	before, _ := service.GetImageByID(id)
 	if err := service.UpdateImage(imageInfo, userID); err != nil {
 		response.FailWithMessage("图片信息更新失败: "+err.Error(), c)
 		return
 	}
+	after, _ := service.GetImageByID(id)
+	recordAudit(c, appType.AuditActionUpdate, appType.AuditTargetMedia, id, before, after)
 
 	response.OkWithMessage("图片信息更新成功", c)
 }
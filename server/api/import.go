@@ -0,0 +1,125 @@
+package api
+
+import (
+	"archive/zip"
+	"server/global"
+	"server/model/appType"
+	"server/model/response"
+	"server/service"
+	"server/utils"
+	"server/utils/importer"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ImportApi 从其他博客系统导入内容的API
+type ImportApi struct{}
+
+var blogImportService = service.ServiceGroups.BlogImportService
+
+// maxBlogImportSize 导入文件的大小上限
+const maxBlogImportSize = 200 << 20
+
+// importContext 检查管理员权限，解析旧站时区（timezone 参数，默认服务器时区）
+func importContext(c *gin.Context) (userID uint, loc *time.Location, ok bool) {
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return 0, nil, false
+	}
+	if !utils.IsAdmin(userID) {
+		response.Forbidden("需要管理员权限", c)
+		return 0, nil, false
+	}
+
+	loc = time.Local
+	if name := c.PostForm("timezone"); name != "" {
+		if loc, err = time.LoadLocation(name); err != nil {
+			response.FailWithMessage("无效的时区: "+name, c)
+			return 0, nil, false
+		}
+	}
+	return userID, loc, true
+}
+
+// runBlogImport 写入解析结果并记录审计日志；match_comment_email=true 时按评论者邮箱把评论归属到本站用户
+func runBlogImport(c *gin.Context, site *importer.Site, userID uint) {
+	report, err := blogImportService.Import(site, service.BlogImportOptions{
+		AuthorID:          userID,
+		MatchCommentEmail: c.PostForm("match_comment_email") == "true",
+	})
+	if err != nil {
+		global.ZapLog.Error("导入博客内容失败", zap.String("source", site.Source), zap.Error(err))
+		response.FailWithMessage("导入失败: "+err.Error(), c)
+		return
+	}
+
+	for _, item := range report.Imported {
+		target := appType.AuditTargetArticle
+		if item.Type == importer.TypePage {
+			target = appType.AuditTargetPage
+		}
+		recordAudit(c, appType.AuditActionCreate, target, item.ID, nil, item)
+	}
+	response.OkWithDetailed(report, "导入完成", c)
+}
+
+// ImportWordPress 导入 WordPress 导出的 WXR 文件
+func (i *ImportApi) ImportWordPress(c *gin.Context) {
+	userID, loc, ok := importContext(c)
+	if !ok {
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		response.FailWithMessage("请上传WXR文件", c)
+		return
+	}
+	defer file.Close()
+	if header.Size > maxBlogImportSize {
+		response.FailWithMessage("文件不能超过200MB", c)
+		return
+	}
+
+	site, err := importer.ParseWXR(file, loc)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	runBlogImport(c, site, userID)
+}
+
+// ImportHexo 导入 Hexo 的 source/_posts 或 Hugo 的 content 目录（zip 压缩包）
+// permalink 参数为旧站的固定链接格式，用于生成重定向
+func (i *ImportApi) ImportHexo(c *gin.Context) {
+	userID, loc, ok := importContext(c)
+	if !ok {
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		response.FailWithMessage("请上传zip压缩包", c)
+		return
+	}
+	defer file.Close()
+	if header.Size > maxBlogImportSize {
+		response.FailWithMessage("压缩包不能超过200MB", c)
+		return
+	}
+	archive, err := zip.NewReader(file, header.Size)
+	if err != nil {
+		response.FailWithMessage("无法读取压缩包: "+err.Error(), c)
+		return
+	}
+
+	site, err := importer.ParseHexoDir(archive, c.PostForm("permalink"), loc)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	runBlogImport(c, site, userID)
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"errors"
+	"server/global"
+	"server/model/response"
+	"server/service"
+	"server/utils"
+	"server/utils/oauth"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// OAuthApi 第三方登录API
+type OAuthApi struct{}
+
+var oauthService = service.ServiceGroups.OAuthService
+
+// GetProviders 获取已启用的第三方登录方式
+func (o *OAuthApi) GetProviders(c *gin.Context) {
+	providers := oauthService.EnabledProviders()
+	if providers == nil {
+		providers = []string{}
+	}
+	response.OkWithData(providers, c)
+}
+
+// Authorize 发起第三方登录，返回授权跳转地址
+func (o *OAuthApi) Authorize(c *gin.Context) {
+	url, err := oauthService.Begin(c.Param("provider"), service.OAuthModeLogin, 0)
+	if err != nil {
+		response.FailWithMessage("发起第三方登录失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(gin.H{"url": url}, c)
+}
+
+// Callback 第三方登录回调，根据发起时的模式完成登录或绑定
+func (o *OAuthApi) Callback(c *gin.Context) {
+	code := c.Query("code")
+	state := c.Query("state")
+	if code == "" {
+		// 用户在提供方页面取消授权时不会带回授权码
+		response.FailWithMessage("授权已取消", c)
+		return
+	}
+
+	result, err := oauthService.Callback(c.Request.Context(), c.Param("provider"), code, state)
+	if err != nil {
+		if !errors.Is(err, oauth.ErrProviderDisabled) {
+			global.ZapLog.Warn("第三方登录回调失败", zap.String("provider", c.Param("provider")), zap.Error(err))
+		}
+		response.FailWithMessage("第三方登录失败: "+err.Error(), c)
+		return
+	}
+
+	if result.Mode == service.OAuthModeLink {
+		response.OkWithDetailed(response.ToUserResponse(result.User), "绑定成功", c)
+		return
+	}
+	if result.Mode == service.OAuthModeReauth {
+		response.OkWithDetailed(gin.H{"reauth_token": result.ReauthToken}, "身份验证成功", c)
+		return
+	}
+	if respondTwoFactorChallenge(c, result.User) {
+		return
+	}
+	loginSuccess(c, result.User)
+}
+
+// GetLinks 获取当前用户已绑定的第三方账户
+func (o *OAuthApi) GetLinks(c *gin.Context) {
+	userId, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return
+	}
+
+	links, err := oauthService.Links(userId)
+	if err != nil {
+		response.FailWithMessage("获取绑定信息失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(links, c)
+}
+
+// Link 已登录用户发起绑定第三方账户，返回授权跳转地址
+func (o *OAuthApi) Link(c *gin.Context) {
+	userId, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return
+	}
+
+	url, err := oauthService.Begin(c.Param("provider"), service.OAuthModeLink, userId)
+	if err != nil {
+		response.FailWithMessage("发起绑定失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(gin.H{"url": url}, c)
+}
+
+// Reauth 已登录用户发起通过第三方账户重新验证身份，返回授权跳转地址
+// 回调成功后返回一次性令牌，用于注销账户、修改邮箱等敏感操作
+func (o *OAuthApi) Reauth(c *gin.Context) {
+	userId, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return
+	}
+
+	url, err := oauthService.Begin(c.Param("provider"), service.OAuthModeReauth, userId)
+	if err != nil {
+		response.FailWithMessage("发起身份验证失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(gin.H{"url": url}, c)
+}
+
+// Unlink 解绑第三方账户
+func (o *OAuthApi) Unlink(c *gin.Context) {
+	userId, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return
+	}
+
+	if err := oauthService.Unlink(userId, c.Param("provider")); err != nil {
+		response.FailWithMessage("解绑失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("解绑成功", c)
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"server/model/appType"
 	"server/model/database"
 	"server/model/request"
 	"server/model/response"
@@ -37,10 +38,11 @@ func (p *PageApi) CreatePage(c *gin.Context) {
 		Sort:      req.Sort,
 	}
 	page.Status = req.Status
-	if err := pageService.CreatePage(page); err != nil {
+	if err := pageService.CreatePage(&page); err != nil {
 		response.FailWithMessage("创建页面失败: "+err.Error(), c)
 		return
 	}
+	recordAudit(c, appType.AuditActionCreate, appType.AuditTargetPage, page.ID, nil, page)
 
 	response.OkWithMessage("创建页面成功", c)
 }
@@ -104,6 +106,7 @@ func (p *PageApi) UpdatePage(c *gin.Context) {
 	}
 
 	// 更新字段
+	before := page
 	page.Title = req.Title
 	page.Slug = req.Slug
 	page.Content = req.Content
@@ -116,6 +119,7 @@ func (p *PageApi) UpdatePage(c *gin.Context) {
 		response.FailWithMessage("更新页面失败: "+err.Error(), c)
 		return
 	}
+	recordAudit(c, appType.AuditActionUpdate, appType.AuditTargetPage, page.ID, before, page)
 
 	response.OkWithMessage("更新页面成功", c)
 }
@@ -129,7 +133,7 @@ func (p *PageApi) DeletePage(c *gin.Context) {
 	}
 
 	// 先检查页面是否存在
-	_, err = pageService.GetPageByID(id)
+	page, err := pageService.GetPageByID(id)
 	if err != nil {
 		response.FailWithMessage("页面不存在或已被删除", c)
 		return
@@ -139,6 +143,7 @@ func (p *PageApi) DeletePage(c *gin.Context) {
 		response.FailWithMessage("删除页面失败: "+err.Error(), c)
 		return
 	}
+	recordAudit(c, appType.AuditActionDelete, appType.AuditTargetPage, id, page, nil)
 
 	response.OkWithMessage("删除页面成功", c)
 }
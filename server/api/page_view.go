@@ -0,0 +1,92 @@
+package api
+
+import (
+	"net/url"
+	"server/model/appType"
+	"server/model/request"
+	"server/model/response"
+	"server/service"
+	"server/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PageViewApi 文章浏览统计API
+type PageViewApi struct{}
+
+var pageViewService = service.ServiceGroups.PageViewService
+
+// siteHost 前端页面所在的域名：优先取信标请求的 Origin 或 Referer，前后端同域部署时二者都与 Host 相同
+func siteHost(c *gin.Context) string {
+	for _, header := range []string{"Origin", "Referer"} {
+		if u, err := url.Parse(c.GetHeader(header)); err == nil && u.Host != "" {
+			return u.Host
+		}
+	}
+	return c.Request.Host
+}
+
+// RecordBeacon 接收文章页面发送的浏览信标，爬虫和未发布的文章不计入
+func (p *PageViewApi) RecordBeacon(c *gin.Context) {
+	var req request.PageViewBeaconRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误: "+err.Error(), c)
+		return
+	}
+
+	counted, err := pageViewService.Record(service.PageView{
+		ArticleID:   req.ArticleID,
+		Referrer:    req.Referrer,
+		UTMSource:   req.UTMSource,
+		UTMMedium:   req.UTMMedium,
+		UTMCampaign: req.UTMCampaign,
+		IP:          c.ClientIP(),
+		UserAgent:   c.Request.UserAgent(),
+		Host:        siteHost(c),
+	})
+	if err != nil {
+		response.FailWithMessage("记录浏览失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(gin.H{"counted": counted}, c)
+}
+
+// GetPageViewReport 浏览统计报表：全站、文章、分类或作者每天的浏览量和独立访客数，以及文章和来源排行，仅管理员可用
+func (p *PageViewApi) GetPageViewReport(c *gin.Context) {
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return
+	}
+	if !utils.IsAdmin(userID) {
+		response.Forbidden("需要管理员权限", c)
+		return
+	}
+
+	var req request.PageViewReportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage("参数错误: "+err.Error(), c)
+		return
+	}
+	scope := appType.PageViewScope(req.Scope)
+	if scope == "" {
+		scope = appType.PageViewSite
+	}
+	if scope != appType.PageViewSite && req.ID == 0 {
+		response.FailWithMessage("参数错误: 缺少ID", c)
+		return
+	}
+	if req.Days <= 0 {
+		req.Days = 30
+	}
+	if req.Size <= 0 {
+		req.Size = 10
+	}
+
+	report, err := pageViewService.Report(scope, req.ID, req.Days, req.Size)
+	if err != nil {
+		response.FailWithMessage("获取浏览统计失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(report, c)
+}
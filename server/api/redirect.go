@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+	"server/model/response"
+	"server/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RedirectApi 旧地址重定向API
+type RedirectApi struct{}
+
+var redirectService = service.ServiceGroups.RedirectService
+
+// ResolveRedirect 查询旧地址对应的新地址，供前端路由在页面不存在时调用
+func (r *RedirectApi) ResolveRedirect(c *gin.Context) {
+	path := c.Query("path")
+	if path == "" {
+		response.FailWithMessage("缺少 path 参数", c)
+		return
+	}
+	redirect, ok := redirectService.Resolve(path)
+	if !ok {
+		response.FailWithMessage("没有对应的重定向", c)
+		return
+	}
+	response.OkWithData(gin.H{"to_path": redirect.ToPath, "status_code": redirect.StatusCode}, c)
+}
+
+// HandleNoRoute 未匹配任何路由的 GET 请求按旧地址重定向，没有重定向时返回404
+func (r *RedirectApi) HandleNoRoute(c *gin.Context) {
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		if redirect, ok := redirectService.Resolve(c.Request.RequestURI); ok {
+			code := redirect.StatusCode
+			if code < http.StatusMultipleChoices || code > http.StatusPermanentRedirect {
+				code = http.StatusMovedPermanently
+			}
+			c.Redirect(code, redirect.ToPath)
+			return
+		}
+	}
+	c.AbortWithStatus(http.StatusNotFound)
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"server/model/request"
+	"server/model/response"
+	"server/service"
+	"server/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchAnalyticsApi 搜索统计API
+type SearchAnalyticsApi struct{}
+
+var searchAnalyticsService = service.ServiceGroups.SearchAnalyticsService
+
+// GetSearchAnalytics 搜索统计报表：热门搜索词、没有结果的搜索词和点击率，仅管理员可用
+func (s *SearchAnalyticsApi) GetSearchAnalytics(c *gin.Context) {
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return
+	}
+	if !utils.IsAdmin(userID) {
+		response.Forbidden("需要管理员权限", c)
+		return
+	}
+
+	var req request.SearchAnalyticsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.FailWithMessage("参数错误: "+err.Error(), c)
+		return
+	}
+	if req.Days <= 0 {
+		req.Days = 7
+	}
+	if req.Size <= 0 {
+		req.Size = 20
+	}
+
+	report, err := searchAnalyticsService.Report(req.Days, req.Size)
+	if err != nil {
+		response.FailWithMessage("获取搜索统计失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(report, c)
+}
+
+// RecordSearchClick 记录从搜索结果点击进入文章，用于计算点击率；需回传搜索接口返回的 search_id，同一访客的重复点击只计一次
+func (s *SearchAnalyticsApi) RecordSearchClick(c *gin.Context) {
+	var req request.SearchClickRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误: "+err.Error(), c)
+		return
+	}
+
+	counted, err := searchAnalyticsService.RecordClick(req.SearchID, req.ArticleID, c.ClientIP())
+	if err != nil {
+		response.FailWithMessage("记录点击失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(gin.H{"counted": counted}, c)
+}
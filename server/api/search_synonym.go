@@ -0,0 +1,105 @@
+package api
+
+import (
+	"server/model/appType"
+	"server/model/request"
+	"server/model/response"
+	"server/service"
+	"server/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchSynonymApi 搜索同义词管理API，仅管理员可用
+type SearchSynonymApi struct{}
+
+var searchSynonymService = service.ServiceGroups.SearchSynonymService
+
+// checkSynonymAdmin 检查管理员权限
+func checkSynonymAdmin(c *gin.Context) bool {
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return false
+	}
+	if !utils.IsAdmin(userID) {
+		response.Forbidden("需要管理员权限", c)
+		return false
+	}
+	return true
+}
+
+// ListSearchSynonyms 获取全部同义词组
+func (s *SearchSynonymApi) ListSearchSynonyms(c *gin.Context) {
+	if !checkSynonymAdmin(c) {
+		return
+	}
+	synonyms, err := searchSynonymService.List()
+	if err != nil {
+		response.FailWithMessage("获取同义词失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(synonyms, c)
+}
+
+// CreateSearchSynonym 新建同义词组，搜索时立即生效，无需重建索引
+func (s *SearchSynonymApi) CreateSearchSynonym(c *gin.Context) {
+	if !checkSynonymAdmin(c) {
+		return
+	}
+	var req request.SearchSynonymRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误: "+err.Error(), c)
+		return
+	}
+	synonym, err := searchSynonymService.Create(req.Terms)
+	if err != nil {
+		response.FailWithMessage("创建同义词失败: "+err.Error(), c)
+		return
+	}
+	recordAudit(c, appType.AuditActionCreate, appType.AuditTargetSynonym, synonym.ID, nil, synonym)
+	response.OkWithData(synonym, c)
+}
+
+// UpdateSearchSynonym 修改同义词组
+func (s *SearchSynonymApi) UpdateSearchSynonym(c *gin.Context) {
+	if !checkSynonymAdmin(c) {
+		return
+	}
+	id, err := utils.StringToUint(c.Param("id"))
+	if err != nil {
+		response.FailWithMessage("参数错误: "+err.Error(), c)
+		return
+	}
+	var req request.SearchSynonymRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误: "+err.Error(), c)
+		return
+	}
+	before, synonym, err := searchSynonymService.Update(id, req.Terms)
+	if err != nil {
+		response.FailWithMessage("修改同义词失败: "+err.Error(), c)
+		return
+	}
+	recordAudit(c, appType.AuditActionUpdate, appType.AuditTargetSynonym, synonym.ID, before, synonym)
+	response.OkWithData(synonym, c)
+}
+
+// DeleteSearchSynonym 删除同义词组
+func (s *SearchSynonymApi) DeleteSearchSynonym(c *gin.Context) {
+	if !checkSynonymAdmin(c) {
+		return
+	}
+	id, err := utils.StringToUint(c.Param("id"))
+	if err != nil {
+		response.FailWithMessage("参数错误: "+err.Error(), c)
+		return
+	}
+	synonym, err := searchSynonymService.Delete(id)
+	if err != nil {
+		response.FailWithMessage("删除同义词失败: "+err.Error(), c)
+		return
+	}
+	recordAudit(c, appType.AuditActionDelete, appType.AuditTargetSynonym, id, synonym, nil)
+	response.OkWithMessage("删除同义词成功", c)
+}
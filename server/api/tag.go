@@ -1,6 +1,7 @@
 package api
 
 import (
+	"server/model/appType"
 	"server/model/response"
 	"server/service"
 	"server/utils"
@@ -85,6 +86,7 @@ func (t *TagApi) CleanupOrphanTags(ctx *gin.Context) {
 		response.FailWithMessage("清理孤儿标签失败: "+err.Error(), ctx)
 		return
 	}
+	recordAudit(ctx, appType.AuditActionCleanup, appType.AuditTargetTag, "orphans", nil, map[string]int64{"deleted_count": deletedCount})
 
 	response.OkWithData(map[string]int64{"deleted_count": deletedCount}, ctx)
 } 
\ No newline at end of file
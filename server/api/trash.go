@@ -0,0 +1,114 @@
+package api
+
+import (
+	"errors"
+	"server/model/appType"
+	"server/model/request"
+	"server/model/response"
+	"server/service"
+	"server/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TrashApi 回收站API
+type TrashApi struct{}
+
+var trashService = service.ServiceGroups.TrashService
+
+// trashContext 解析资源类型并确定操作范围：管理员可操作全部内容，其他用户只能操作自己的内容
+func trashContext(c *gin.Context) (resource appType.TrashResource, ownerID uint, ok bool) {
+	resource = appType.TrashResource(c.Param("resource"))
+	if !resource.IsValid() {
+		response.FailWithMessage("不支持的资源类型", c)
+		return resource, 0, false
+	}
+
+	userId, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return resource, 0, false
+	}
+	if !utils.IsAdmin(userId) {
+		ownerID = userId
+	}
+	return resource, ownerID, true
+}
+
+// failTrash 输出回收站操作错误
+func failTrash(c *gin.Context, prefix string, err error) {
+	if errors.Is(err, service.ErrTrashForbidden) {
+		response.Forbidden(err.Error(), c)
+		return
+	}
+	response.FailWithMessage(prefix+err.Error(), c)
+}
+
+// ListTrash 分页查询回收站中的内容
+func (t *TrashApi) ListTrash(c *gin.Context) {
+	resource, ownerID, ok := trashContext(c)
+	if !ok {
+		return
+	}
+
+	var pageInfo request.PageInfo
+	_ = c.ShouldBindQuery(&pageInfo)
+	if pageInfo.Page <= 0 {
+		pageInfo.Page = 1
+	}
+	if pageInfo.Size <= 0 || pageInfo.Size > 100 {
+		pageInfo.Size = 10
+	}
+
+	list, total, err := trashService.List(resource, ownerID, pageInfo.Page, pageInfo.Size)
+	if err != nil {
+		failTrash(c, "获取回收站失败: ", err)
+		return
+	}
+	response.OkWithDetailed(response.PageResult{
+		List:     list,
+		Total:    total,
+		Page:     pageInfo.Page,
+		PageSize: pageInfo.Size,
+	}, "获取成功", c)
+}
+
+// RestoreTrash 从回收站恢复内容
+func (t *TrashApi) RestoreTrash(c *gin.Context) {
+	resource, ownerID, ok := trashContext(c)
+	if !ok {
+		return
+	}
+	id, err := utils.StringToUint(c.Param("id"))
+	if err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+
+	if err := trashService.Restore(resource, id, ownerID); err != nil {
+		failTrash(c, "恢复失败: ", err)
+		return
+	}
+	recordAudit(c, appType.AuditActionRestore, resource.AuditTarget(), id, nil, nil)
+	response.OkWithMessage("恢复成功", c)
+}
+
+// PurgeTrash 从回收站彻底删除内容，删除后无法恢复
+func (t *TrashApi) PurgeTrash(c *gin.Context) {
+	resource, ownerID, ok := trashContext(c)
+	if !ok {
+		return
+	}
+	id, err := utils.StringToUint(c.Param("id"))
+	if err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+
+	if err := trashService.Purge(resource, id, ownerID); err != nil {
+		failTrash(c, "彻底删除失败: ", err)
+		return
+	}
+	recordAudit(c, appType.AuditActionPurge, resource.AuditTarget(), id, nil, nil)
+	response.OkWithMessage("已彻底删除", c)
+}
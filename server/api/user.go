@@ -1,12 +1,16 @@
 package api
 
 import (
+	"fmt"
 	"server/global"
+	"server/model/appType"
+	"server/model/database"
 	"server/model/request"
 	"server/model/response"
 	"server/service"
 	"server/utils"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -17,6 +21,37 @@ type UserApi struct{}
 
 // 定义用户服务变量，通过ServiceGroups调用
 var userService = service.ServiceGroups.UserService
+var challengeService = service.ServiceGroups.ChallengeService
+var accountService = service.ServiceGroups.AccountService
+var twoFactorService = service.ServiceGroups.TwoFactorService
+
+// challengeSubject 从请求中提取风险评估所需的信息
+func challengeSubject(c *gin.Context, endpoint, identifier string) service.ChallengeSubject {
+	return service.ChallengeSubject{
+		Endpoint:   endpoint,
+		IP:         c.ClientIP(),
+		UserAgent:  c.GetHeader("User-Agent"),
+		DeviceID:   c.GetHeader("X-Device-ID"),
+		Identifier: identifier,
+	}
+}
+
+// verifyChallenge 按风险评估结果决定是否校验验证码，校验失败时直接写入响应并返回 false
+func verifyChallenge(c *gin.Context, subject service.ChallengeSubject, captchaID, captchaCode string) bool {
+	assessment, ok := challengeService.Verify(subject, captchaID, captchaCode)
+	if ok {
+		return true
+	}
+	message := "验证码错误或已过期"
+	if captchaID == "" {
+		message = "请完成人机验证"
+	}
+	response.FailWithDetailed(gin.H{
+		"challenge_required": true,
+		"types":              assessment.Types,
+	}, message, c)
+	return false
+}
 
 // Register 用户注册
 func (u *UserApi) Register(c *gin.Context) {
@@ -32,14 +67,15 @@ func (u *UserApi) Register(c *gin.Context) {
 		return
 	}
 
-	// 验证图片验证码
-	if !utils.VerifyCaptcha(registerReq.CaptchaID, registerReq.CaptchaCode) {
-		response.FailWithMessage("图片验证码错误或已过期", c)
+	// 根据风险评估决定是否需要验证码
+	subject := challengeSubject(c, service.ChallengeRegister, registerReq.Email)
+	if !verifyChallenge(c, subject, registerReq.CaptchaID, registerReq.CaptchaCode) {
 		return
 	}
 
 	// 验证邮箱验证码
 	if !utils.VerifyEmailCode(registerReq.Email, registerReq.EmailCode) {
+		challengeService.RecordFailure(subject)
 		response.FailWithMessage("邮箱验证码错误或已过期", c)
 		return
 	}
@@ -67,27 +103,269 @@ func (u *UserApi) Login(c *gin.Context) {
 		return
 	}
 
-	// 验证图片验证码
-	if !utils.VerifyCaptcha(loginReq.CaptchaID, loginReq.CaptchaCode) {
-		response.FailWithMessage("图片验证码错误或已过期", c)
+	// 该IP对账户多次登录失败被锁定时直接拒绝
+	if remaining, locked := utils.GetLoginLockout(loginReq.Username, c.ClientIP()); locked {
+		c.Header("Retry-After", utils.RetryAfterSeconds(remaining))
+		response.TooManyRequests(fmt.Sprintf("登录失败次数过多，请%s秒后再试", utils.RetryAfterSeconds(remaining)), c)
+		return
+	}
+
+	// 根据风险评估决定是否需要验证码
+	subject := challengeSubject(c, service.ChallengeLogin, loginReq.Username)
+	if !verifyChallenge(c, subject, loginReq.CaptchaID, loginReq.CaptchaCode) {
 		return
 	}
 
 	// 调用服务层(内部实现登录类型自动判断)
 	if err, user := userService.Login(loginReq); err != nil {
+		// 记录该IP的失败次数，达到阈值后锁定；账户维度的失败次数计入风险评分，由验证码限制
+		if lockDuration := utils.RecordLoginFailure(loginReq.Username, c.ClientIP()); lockDuration > 0 {
+			global.ZapLog.Warn("账户登录失败次数过多，已锁定",
+				zap.String("username", loginReq.Username),
+				zap.String("ip", c.ClientIP()),
+				zap.Duration("duration", lockDuration))
+		}
+		challengeService.RecordFailure(subject)
 		response.FailWithMessage("登录失败: "+err.Error(), c)
 	} else {
-		// 生成JWT令牌
-		token, err := utils.GenerateToken(user.ID, user.Username)
-		if err != nil {
+		challengeService.RecordSuccess(subject)
+
+		if respondTwoFactorChallenge(c, user) {
+			return
+		}
+		utils.ResetLoginFailures(loginReq.Username, c.ClientIP())
+		loginSuccess(c, user)
+	}
+}
+
+// respondTwoFactorChallenge 已开启两步验证，或角色要求开启但尚未开启时，签发挑战令牌代替访问令牌
+// 返回 true 表示已写入响应
+func respondTwoFactorChallenge(c *gin.Context, user database.User) bool {
+	purpose := ""
+	if user.IsTwoFactorEnabled() {
+		purpose = utils.TokenPurposeTwoFactorLogin
+	} else if global.Config.Account.TwoFactor.RequiredFor(user.Role) {
+		purpose = utils.TokenPurposeTwoFactorSetup
+	}
+	if purpose == "" {
+		return false
+	}
+
+	challengeToken, ttl, err := twoFactorService.IssueChallenge(user, purpose)
+	if err != nil {
+		global.ZapLog.Error("签发两步验证挑战令牌失败", zap.Error(err))
+		response.FailWithMessage("登录失败，请重试", c)
+		return true
+	}
+	message := "请输入两步验证码"
+	if purpose == utils.TokenPurposeTwoFactorSetup {
+		message = "当前账户必须开启两步验证"
+	}
+	response.OkWithDetailed(response.TwoFactorChallengeResponse{
+		TwoFactorRequired: purpose == utils.TokenPurposeTwoFactorLogin,
+		SetupRequired:     purpose == utils.TokenPurposeTwoFactorSetup,
+		ChallengeToken:    challengeToken,
+		ExpiresIn:         int(ttl.Seconds()),
+	}, message, c)
+	return true
+}
+
+// loginSuccess 签发访问令牌并返回登录结果
+func loginSuccess(c *gin.Context, user database.User) {
+	token, err := utils.GenerateToken(user.ID, user.Username)
+	if err != nil {
+		response.FailWithMessage("生成令牌失败", c)
+		return
+	}
+	response.OkWithDetailed(response.LoginResponse{
+		User:  response.ToUserResponse(user),
+		Token: token,
+	}, "登录成功", c)
+}
+
+// LoginTwoFactor 登录第二步：提交两步验证码或恢复码换取访问令牌
+func (u *UserApi) LoginTwoFactor(c *gin.Context) {
+	var req request.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+	if errMsg := utils.ValidateStruct(req); errMsg != "" {
+		response.FailWithMessage(errMsg, c)
+		return
+	}
+
+	user, err := twoFactorService.CompleteLogin(req.ChallengeToken, req.Code)
+	if err != nil {
+		// 两步验证码错误同样计入登录失败次数
+		if user.ID != 0 {
+			utils.RecordLoginFailure(user.Username, c.ClientIP())
+		}
+		response.FailWithMessage("登录失败: "+err.Error(), c)
+		return
+	}
+
+	utils.ResetLoginFailures(user.Username, c.ClientIP())
+	loginSuccess(c, user)
+}
+
+// GetTwoFactorStatus 查询两步验证状态
+func (u *UserApi) GetTwoFactorStatus(c *gin.Context) {
+	userId, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return
+	}
+
+	status, err := twoFactorService.Status(userId)
+	if err != nil {
+		response.FailWithMessage("获取两步验证状态失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(status, "获取成功", c)
+}
+
+// SetupTwoFactor 生成两步验证密钥和二维码地址
+func (u *UserApi) SetupTwoFactor(c *gin.Context) {
+	userId, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return
+	}
+	if !twoFactorSetupTokenActive(c) {
+		response.NoAuth("登录会话无效或已过期，请重新登录", c)
+		return
+	}
+
+	secret, uri, err := twoFactorService.Setup(userId)
+	if err != nil {
+		response.FailWithMessage("获取两步验证密钥失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(response.TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: uri,
+	}, "获取成功", c)
+}
+
+// EnableTwoFactor 校验验证码并开启两步验证，返回一次性恢复码
+func (u *UserApi) EnableTwoFactor(c *gin.Context) {
+	var req request.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+	if errMsg := utils.ValidateStruct(req); errMsg != "" {
+		response.FailWithMessage(errMsg, c)
+		return
+	}
+
+	userId, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return
+	}
+	if !twoFactorSetupTokenActive(c) {
+		response.NoAuth("登录会话无效或已过期，请重新登录", c)
+		return
+	}
+
+	codes, err := twoFactorService.Enable(userId, req.Code)
+	if err != nil {
+		response.FailWithMessage("开启两步验证失败: "+err.Error(), c)
+		return
+	}
+
+	resp := response.TwoFactorEnableResponse{RecoveryCodes: codes}
+	// 使用受限令牌开启时，作废该令牌并签发正式访问令牌
+	if c.GetString("tokenPurpose") == utils.TokenPurposeTwoFactorSetup {
+		twoFactorService.RevokeChallenge(c.GetString("tokenID"))
+		utils.ResetLoginFailures(c.GetString("username"), c.ClientIP())
+		if resp.Token, err = utils.GenerateToken(userId, c.GetString("username")); err != nil {
 			response.FailWithMessage("生成令牌失败", c)
 			return
 		}
-		response.OkWithDetailed(response.LoginResponse{
-			User:  response.ToUserResponse(user),
-			Token: token,
-		}, "登录成功", c)
 	}
+	response.OkWithDetailed(resp, "两步验证已开启，请妥善保存恢复码", c)
+}
+
+// twoFactorSetupTokenActive 使用受限令牌访问时检查令牌是否仍然有效
+func twoFactorSetupTokenActive(c *gin.Context) bool {
+	if c.GetString("tokenPurpose") != utils.TokenPurposeTwoFactorSetup {
+		return true
+	}
+	return twoFactorService.ChallengeActive(c.GetString("tokenID"))
+}
+
+// DisableTwoFactor 关闭两步验证
+func (u *UserApi) DisableTwoFactor(c *gin.Context) {
+	var req request.TwoFactorDisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+	if errMsg := utils.ValidateStruct(req); errMsg != "" {
+		response.FailWithMessage(errMsg, c)
+		return
+	}
+
+	userId, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return
+	}
+
+	if err := twoFactorService.Disable(userId, req.Password, req.Code); err != nil {
+		response.FailWithMessage("关闭两步验证失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("两步验证已关闭", c)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+func (u *UserApi) RegenerateRecoveryCodes(c *gin.Context) {
+	var req request.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+	if errMsg := utils.ValidateStruct(req); errMsg != "" {
+		response.FailWithMessage(errMsg, c)
+		return
+	}
+
+	userId, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return
+	}
+
+	codes, err := twoFactorService.RegenerateRecoveryCodes(userId, req.Code)
+	if err != nil {
+		response.FailWithMessage("生成恢复码失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(response.TwoFactorEnableResponse{RecoveryCodes: codes}, "恢复码已重新生成，旧恢复码已失效", c)
+}
+
+// ResetUserTwoFactor 管理员重置用户的两步验证
+func (u *UserApi) ResetUserTwoFactor(c *gin.Context) {
+	userId, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return
+	}
+	if !utils.IsAdmin(userId) {
+		response.Forbidden("没有权限进行此操作", c)
+		return
+	}
+
+	if err := twoFactorService.AdminReset(c.Param("uuid")); err != nil {
+		response.FailWithMessage("重置两步验证失败: "+err.Error(), c)
+		return
+	}
+	recordAudit(c, appType.AuditActionResetTwoFactor, appType.AuditTargetUser, c.Param("uuid"), nil, nil)
+	response.OkWithMessage("两步验证已重置", c)
 }
 
 // GetUserInfo 获取用户信息
@@ -145,21 +423,28 @@ func (u *UserApi) UpdateUserInfo(c *gin.Context) {
 		return
 	}
 
+	// 获取原始用户信息
+	err, originalUser := userService.GetUserInfo(updateReq.ID)
+	if err != nil {
+		response.FailWithMessage("获取用户信息失败", c)
+		return
+	}
+
 	// 新增：非管理员用户不能修改Role字段
 	if !utils.IsAdmin(userId) {
-		// 获取原始用户信息
-		if err, originalUser := userService.GetUserInfo(updateReq.ID); err != nil {
-			response.FailWithMessage("获取用户信息失败", c)
+		// 保留原始角色
+		updateReq.Role = originalUser.Role
+		// 修改邮箱需要通过新邮箱验证
+		if updateReq.Email != "" && updateReq.Email != originalUser.Email {
+			response.FailWithMessage("修改邮箱需要验证新邮箱，请使用邮箱修改功能", c)
 			return
-		} else {
-			// 保留原始角色
-			updateReq.Role = originalUser.Role
 		}
 	}
 
 	if err, user := userService.UpdateUserInfo(updateReq); err != nil {
 		response.FailWithMessage("更新用户信息失败: "+err.Error(), c)
 	} else {
+		recordAudit(c, appType.AuditActionUpdate, appType.AuditTargetUser, user.UUID, originalUser, user)
 		response.OkWithDetailed(response.ToUserResponse(user), "更新成功", c)
 	}
 }
@@ -192,17 +477,26 @@ func (u *UserApi) ChangePassword(c *gin.Context) {
 	if err := userService.ChangePassword(userId, passwordReq); err != nil {
 		response.FailWithMessage("修改密码失败: "+err.Error(), c)
 	} else {
+		recordAudit(c, appType.AuditActionChangePassword, appType.AuditTargetUser, userId, nil, nil)
 		response.OkWithMessage("修改密码成功", c)
 	}
 }
 
-// DeleteUser 删除用户
+// DeleteUser 注销账户：立即停用账户，文章、评论等内容按所选方式在后台匿名化或删除
 func (u *UserApi) DeleteUser(c *gin.Context) {
-	var idReq request.IdRequest
-	if err := c.ShouldBindJSON(&idReq); err != nil {
+	var deleteReq request.DeleteUserRequest
+	if err := c.ShouldBindJSON(&deleteReq); err != nil {
 		response.FailWithMessage("参数错误", c)
 		return
 	}
+	if errMsg := utils.ValidateStruct(deleteReq); errMsg != "" {
+		response.FailWithMessage(errMsg, c)
+		return
+	}
+	mode := appType.AccountDeletionMode(deleteReq.Mode)
+	if mode == "" {
+		mode = appType.AccountDeletionAnonymize
+	}
 
 	userId, err := utils.GetUserID(c)
 	if err != nil {
@@ -210,15 +504,54 @@ func (u *UserApi) DeleteUser(c *gin.Context) {
 		return
 	}
 	// 只有管理员或用户本人可以删除账户
-	if !utils.IsAdmin(userId) && userId != idReq.ID {
+	if !utils.IsAdmin(userId) && userId != deleteReq.ID {
 		response.FailWithMessage("没有权限进行此操作", c)
 		return
 	}
 
-	if err := userService.DeleteUser(idReq.ID); err != nil {
+	err, before := userService.GetUserInfo(deleteReq.ID)
+	if err != nil {
+		response.FailWithMessage("用户不存在", c)
+		return
+	}
+	// 本人注销需要再次验证身份
+	if userId == deleteReq.ID {
+		proof := service.ReauthProof{Password: deleteReq.Password, Code: deleteReq.Code, ReauthToken: deleteReq.ReauthToken}
+		if err := accountService.Reauthenticate(before, proof); err != nil {
+			response.FailWithMessage(err.Error(), c)
+			return
+		}
+	}
+
+	job, err := accountService.RequestDeletion(userId, deleteReq.ID, mode)
+	if err != nil {
 		response.FailWithMessage("删除用户失败: "+err.Error(), c)
-	} else {
-		response.OkWithMessage("删除用户成功", c)
+		return
+	}
+	go accountService.ProcessDeletion(job.ID)
+
+	recordAudit(c, appType.AuditActionDelete, appType.AuditTargetUser, before.UUID, before, gin.H{"mode": mode})
+	response.OkWithDetailed(job, "账户已停用，数据将在后台处理", c)
+}
+
+// ExportUserData 导出当前用户的个人数据（zip）
+func (u *UserApi) ExportUserData(c *gin.Context) {
+	userId, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return
+	}
+
+	fileName := fmt.Sprintf("user_data_%d_%s.zip", userId, time.Now().Format("20060102150405"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", "attachment; filename="+fileName)
+	if err := accountService.Export(userId, c.Writer); err != nil {
+		// 响应头可能已发出，只能记录错误
+		global.ZapLog.Error("导出用户数据失败", zap.Uint("user_id", userId), zap.Error(err))
+		if !c.Writer.Written() {
+			c.Header("Content-Disposition", "")
+			response.FailWithMessage("导出失败: "+err.Error(), c)
+		}
 	}
 }
 
@@ -265,21 +598,52 @@ func (u *UserApi) GetUserList(c *gin.Context) {
 	}
 }
 
-// GetCaptcha 获取图片验证码
+// GetCaptcha 获取验证码，type 可选 image（默认）、math、audio
 func (u *UserApi) GetCaptcha(c *gin.Context) {
+	captchaType := c.DefaultQuery("type", utils.CaptchaTypeImage)
+	if !utils.IsValidCaptchaType(captchaType) {
+		response.FailWithMessage("不支持的验证码类型", c)
+		return
+	}
+
 	// 生成验证码
-	captchaID, b64s, err := utils.GenerateCaptcha()
+	captchaID, b64s, err := utils.GenerateCaptchaOfType(captchaType)
 	if err != nil {
 		response.FailWithMessage("验证码生成失败: "+err.Error(), c)
 		return
 	}
 
+	// 语音验证码返回音频数据，其余返回图片
+	key := "image"
+	if captchaType == utils.CaptchaTypeAudio {
+		key = "audio"
+	}
 	response.OkWithDetailed(map[string]string{
 		"captcha_id": captchaID,
-		"image":      b64s,
+		"type":       captchaType,
+		key:          b64s,
 	}, "获取验证码成功", c)
 }
 
+// GetChallenge 查询某个接口当前是否需要人机验证，前端据此决定是否展示验证码
+func (u *UserApi) GetChallenge(c *gin.Context) {
+	endpoint := c.Query("endpoint")
+	switch endpoint {
+	case service.ChallengeLogin, service.ChallengeRegister, service.ChallengeForgotPassword, service.ChallengeSendEmailCode:
+	default:
+		response.FailWithMessage("不支持的接口类型", c)
+		return
+	}
+
+	// 只按IP和设备评估，不接受客户端传入的账户标识，避免被用来探测任意账户的失败次数和已知设备
+	// 账户维度的风险由受保护的接口按请求中的用户名或邮箱评估，需要验证时返回 challenge_required
+	assessment := challengeService.Assess(challengeSubject(c, endpoint, ""))
+	response.OkWithDetailed(gin.H{
+		"challenge_required": assessment.Required,
+		"types":              assessment.Types,
+	}, "获取成功", c)
+}
+
 // SendEmailCode 发送邮箱验证码
 func (u *UserApi) SendEmailCode(c *gin.Context) {
 	email := c.Query("email")
@@ -288,6 +652,19 @@ func (u *UserApi) SendEmailCode(c *gin.Context) {
 		return
 	}
 
+	// 根据风险评估决定是否需要验证码
+	subject := challengeSubject(c, service.ChallengeSendEmailCode, email)
+	if !verifyChallenge(c, subject, c.Query("captcha_id"), c.Query("captcha_code")) {
+		return
+	}
+
+	// 同一邮箱在冷却期内不重复发送
+	if remaining, ok := utils.AcquireEmailCooldown(email); !ok {
+		c.Header("Retry-After", utils.RetryAfterSeconds(remaining))
+		response.TooManyRequests(fmt.Sprintf("验证码发送过于频繁，请%s秒后再试", utils.RetryAfterSeconds(remaining)), c)
+		return
+	}
+
 	// 生成6位数字验证码
 	code := utils.GenerateEmailCode()
 
@@ -302,10 +679,110 @@ func (u *UserApi) SendEmailCode(c *gin.Context) {
 		response.FailWithMessage("邮件发送失败: "+err.Error(), c)
 		return
 	}
+	challengeService.RecordEmailSent(subject)
+
+	response.OkWithMessage("验证码已发送至邮箱，请注意查收", c)
+}
+
+// SendEmailVerification 向当前邮箱发送验证码
+func (u *UserApi) SendEmailVerification(c *gin.Context) {
+	userId, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return
+	}
 
+	if err := userService.SendEmailVerification(userId); err != nil {
+		response.FailWithMessage("发送验证码失败: "+err.Error(), c)
+		return
+	}
 	response.OkWithMessage("验证码已发送至邮箱，请注意查收", c)
 }
 
+// VerifyEmail 提交验证码完成邮箱验证
+func (u *UserApi) VerifyEmail(c *gin.Context) {
+	var req request.EmailCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+	if errMsg := utils.ValidateStruct(req); errMsg != "" {
+		response.FailWithMessage(errMsg, c)
+		return
+	}
+
+	userId, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return
+	}
+
+	user, err := userService.VerifyEmail(userId, req.Code)
+	if err != nil {
+		response.FailWithMessage("邮箱验证失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(response.ToUserResponse(user), "邮箱验证成功", c)
+}
+
+// RequestEmailChange 申请修改邮箱，验证码发送至新邮箱
+func (u *UserApi) RequestEmailChange(c *gin.Context) {
+	var req request.EmailChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+	if errMsg := utils.ValidateStruct(req); errMsg != "" {
+		response.FailWithMessage(errMsg, c)
+		return
+	}
+
+	userId, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return
+	}
+
+	// 同一邮箱在冷却期内不重复发送
+	if remaining, ok := utils.AcquireEmailCooldown(req.NewEmail); !ok {
+		c.Header("Retry-After", utils.RetryAfterSeconds(remaining))
+		response.TooManyRequests(fmt.Sprintf("验证码发送过于频繁，请%s秒后再试", utils.RetryAfterSeconds(remaining)), c)
+		return
+	}
+
+	if err := userService.RequestEmailChange(userId, req); err != nil {
+		response.FailWithMessage("申请修改邮箱失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("验证码已发送至新邮箱，请注意查收", c)
+}
+
+// ConfirmEmailChange 提交新邮箱收到的验证码完成修改
+func (u *UserApi) ConfirmEmailChange(c *gin.Context) {
+	var req request.EmailCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("参数错误", c)
+		return
+	}
+	if errMsg := utils.ValidateStruct(req); errMsg != "" {
+		response.FailWithMessage(errMsg, c)
+		return
+	}
+
+	userId, err := utils.GetUserID(c)
+	if err != nil {
+		response.NoAuth(err.Error(), c)
+		return
+	}
+
+	user, err := userService.ConfirmEmailChange(userId, req.Code)
+	if err != nil {
+		response.FailWithMessage("修改邮箱失败: "+err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(response.ToUserResponse(user), "邮箱修改成功", c)
+}
+
 // ForgotPassword 处理忘记密码请求
 func (u *UserApi) ForgotPassword(c *gin.Context) {
 	var req request.ForgotPasswordRequest
@@ -315,19 +792,27 @@ func (u *UserApi) ForgotPassword(c *gin.Context) {
 		return
 	}
 
-	// 验证图片验证码
-	if !utils.VerifyCaptcha(req.CaptchaID, req.Captcha) {
-		response.FailWithMessage("验证码错误或已过期", c)
+	// 根据风险评估决定是否需要验证码
+	subject := challengeSubject(c, service.ChallengeForgotPassword, req.Email)
+	if !verifyChallenge(c, subject, req.CaptchaID, req.Captcha) {
 		return
 	}
 
 	// 检查用户是否存在
 	user, err := userService.FindUserByEmail(req.Email)
 	if err != nil || user.ID == 0 {
+		challengeService.RecordFailure(subject)
 		response.FailWithMessage("该邮箱未注册", c)
 		return
 	}
 
+	// 同一邮箱在冷却期内不重复发送
+	if remaining, ok := utils.AcquireEmailCooldown(req.Email); !ok {
+		c.Header("Retry-After", utils.RetryAfterSeconds(remaining))
+		response.TooManyRequests(fmt.Sprintf("验证码发送过于频繁，请%s秒后再试", utils.RetryAfterSeconds(remaining)), c)
+		return
+	}
+
 	// 生成邮箱验证码
 	code := utils.GenerateEmailCode()
 
@@ -344,6 +829,7 @@ func (u *UserApi) ForgotPassword(c *gin.Context) {
 		response.FailWithMessage("发送验证码失败，请重试", c)
 		return
 	}
+	challengeService.RecordEmailSent(subject)
 
 	response.OkWithMessage("验证码已发送至您的邮箱，请注意查收", c)
 }
@@ -429,10 +915,12 @@ func (u *UserApi) ApproveUser(c *gin.Context) {
 	}
 
 	// 调用Service层启用用户
+	before, _ := userService.FindUserByUUID(userUUID)
 	if err := userService.ApproveUser(userUUID); err != nil {
 		response.FailWithMessage("启用用户失败: "+err.Error(), c)
 		return
 	}
+	recordAudit(c, appType.AuditActionEnable, appType.AuditTargetUser, userUUID, gin.H{"status": before.Status}, gin.H{"status": 1})
 
 	response.OkWithMessage("启用用户成功", c)
 }
@@ -460,10 +948,12 @@ func (u *UserApi) RejectUser(c *gin.Context) {
 	}
 
 	// 调用Service层禁用用户
+	before, _ := userService.FindUserByUUID(userUUID)
 	if err := userService.RejectUser(userUUID); err != nil {
 		response.FailWithMessage("禁用用户失败: "+err.Error(), c)
 		return
 	}
+	recordAudit(c, appType.AuditActionDisable, appType.AuditTargetUser, userUUID, gin.H{"status": before.Status}, gin.H{"status": 0})
 
 	response.OkWithMessage("禁用用户成功", c)
 }
@@ -505,6 +995,7 @@ func (u *UserApi) CreateUser(c *gin.Context) {
 		global.ZapLog.Error("创建用户失败", zap.Error(err))
 		response.FailWithMessage("创建用户失败: "+err.Error(), c)
 	} else {
+		recordAudit(c, appType.AuditActionCreate, appType.AuditTargetUser, user.UUID, nil, user)
 		response.OkWithDetailed(response.ToUserResponse(user), "创建用户成功", c)
 	}
 }
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"server/model/appType"
+	"time"
+)
+
+// Account 账户安全相关配置
+type Account struct {
+	RequireVerifiedEmailToComment bool          `mapstructure:"require_verified_email_to_comment" json:"require_verified_email_to_comment" yaml:"require_verified_email_to_comment"` // 邮箱未验证的账户禁止评论
+	RequireVerifiedEmailToPost    bool          `mapstructure:"require_verified_email_to_post" json:"require_verified_email_to_post" yaml:"require_verified_email_to_post"`          // 邮箱未验证的账户禁止发布文章
+	EmailCodeExpiration           time.Duration `mapstructure:"email_code_expiration" json:"email_code_expiration" yaml:"email_code_expiration"`                                     // 邮箱验证和邮箱变更验证码的有效期
+	TwoFactor                     TwoFactor     `mapstructure:"two_factor" json:"two_factor" yaml:"two_factor"`                                                                      // 两步验证配置
+}
+
+// EmailCodeTTL 邮箱验证码有效期，未配置时默认30分钟
+func (a Account) EmailCodeTTL() time.Duration {
+	if a.EmailCodeExpiration <= 0 {
+		return 30 * time.Minute
+	}
+	return a.EmailCodeExpiration
+}
+
+// TwoFactor 两步验证（TOTP）配置
+type TwoFactor struct {
+	Issuer              string        `mapstructure:"issuer" json:"issuer" yaml:"issuer"`                                           // 验证器应用中显示的发行方名称
+	RequiredRoles       []string      `mapstructure:"required_roles" json:"required_roles" yaml:"required_roles"`                   // 必须开启两步验证的角色，如 admin
+	ChallengeExpiration time.Duration `mapstructure:"challenge_expiration" json:"challenge_expiration" yaml:"challenge_expiration"` // 登录挑战令牌的有效期
+	RecoveryCodeCount   int           `mapstructure:"recovery_code_count" json:"recovery_code_count" yaml:"recovery_code_count"`    // 每次生成的恢复码数量
+}
+
+// RequiredFor 指定角色是否必须开启两步验证
+func (t TwoFactor) RequiredFor(role appType.RoleType) bool {
+	for _, r := range t.RequiredRoles {
+		if appType.RoleType(r) == role {
+			return true
+		}
+	}
+	return false
+}
+
+// ChallengeTTL 挑战令牌有效期，未配置时默认5分钟
+func (t TwoFactor) ChallengeTTL() time.Duration {
+	if t.ChallengeExpiration <= 0 {
+		return 5 * time.Minute
+	}
+	return t.ChallengeExpiration
+}
+
+// RecoveryCodes 恢复码数量，未配置时默认10个
+func (t TwoFactor) RecoveryCodes() int {
+	if t.RecoveryCodeCount <= 0 {
+		return 10
+	}
+	return t.RecoveryCodeCount
+}
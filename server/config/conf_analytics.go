@@ -0,0 +1,15 @@
+package config
+
+// Analytics 文章浏览统计配置
+type Analytics struct {
+	FlushCron     string `mapstructure:"flush_cron" json:"flush_cron" yaml:"flush_cron"`             // 把 Redis 中的浏览统计写入数据库的执行时间（含秒的 cron 表达式）
+	RetentionDays int    `mapstructure:"retention_days" json:"retention_days" yaml:"retention_days"` // 浏览统计保留天数，0 表示永久保留
+}
+
+// FlushSpec 写入任务的 cron 表达式，未配置时每5分钟执行一次
+func (a Analytics) FlushSpec() string {
+	if a.FlushCron == "" {
+		return "0 */5 * * * *"
+	}
+	return a.FlushCron
+}
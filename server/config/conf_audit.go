@@ -0,0 +1,15 @@
+package config
+
+// Audit 审计日志配置
+type Audit struct {
+	RetentionDays int    `mapstructure:"retention_days" json:"retention_days" yaml:"retention_days"` // 审计日志保留天数，0 表示永久保留
+	CleanupCron   string `mapstructure:"cleanup_cron" json:"cleanup_cron" yaml:"cleanup_cron"`       // 过期日志清理任务的执行时间（含秒的 cron 表达式）
+}
+
+// CleanupSpec 清理任务的 cron 表达式，未配置时每天凌晨3点执行
+func (a Audit) CleanupSpec() string {
+	if a.CleanupCron == "" {
+		return "0 0 3 * * *"
+	}
+	return a.CleanupCron
+}
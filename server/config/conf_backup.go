@@ -0,0 +1,25 @@
+package config
+
+// Backup 数据库自动备份配置
+type Backup struct {
+	Enable bool   `mapstructure:"enable" json:"enable" yaml:"enable"` // 是否开启自动备份
+	Dir    string `mapstructure:"dir" json:"dir" yaml:"dir"`          // 备份文件目录
+	Cron   string `mapstructure:"cron" json:"cron" yaml:"cron"`       // 自动备份的执行时间（含秒的 cron 表达式）
+	Keep   int    `mapstructure:"keep" json:"keep" yaml:"keep"`       // 保留最近的备份数量，0 表示不清理旧备份
+}
+
+// BackupDir 备份目录，未配置时使用 backups
+func (b Backup) BackupDir() string {
+	if b.Dir == "" {
+		return "backups"
+	}
+	return b.Dir
+}
+
+// Spec 自动备份任务的 cron 表达式，未配置时每天凌晨2点30分执行
+func (b Backup) Spec() string {
+	if b.Cron == "" {
+		return "0 30 2 * * *"
+	}
+	return b.Cron
+}
@@ -0,0 +1,25 @@
+package config
+
+import "time"
+
+// Counter 文章阅读量、点赞数等计数配置
+type Counter struct {
+	FlushCron  string        `mapstructure:"flush_cron" json:"flush_cron" yaml:"flush_cron"`    // 把 Redis 中累计的计数写入数据库并更新索引的执行时间（含秒的 cron 表达式）
+	ViewWindow time.Duration `mapstructure:"view_window" json:"view_window" yaml:"view_window"` // 同一访客在该时间内重复阅读同一篇文章只计一次
+}
+
+// FlushSpec 写入任务的 cron 表达式，未配置时每分钟执行一次
+func (c Counter) FlushSpec() string {
+	if c.FlushCron == "" {
+		return "0 * * * * *"
+	}
+	return c.FlushCron
+}
+
+// ViewDedupeWindow 阅读量去重的时间窗口，未配置时为30分钟
+func (c Counter) ViewDedupeWindow() time.Duration {
+	if c.ViewWindow <= 0 {
+		return 30 * time.Minute
+	}
+	return c.ViewWindow
+}
@@ -1,8 +1,56 @@
 package config
 
 type ES struct {
-	Url            string `json:"url" yaml:"url"`
-	Username       string `json:"username" yaml:"username"`
-	Password       string `json:"password" yaml:"password"`
-	IsConsolePrint bool   `json:"is_console_print" yaml:"is_console_print"`
+	Url                   string `json:"url" yaml:"url"`
+	Username              string `json:"username" yaml:"username"`
+	Password              string `json:"password" yaml:"password"`
+	IsConsolePrint        bool   `json:"is_console_print" yaml:"is_console_print"`
+	Analyzer              string `mapstructure:"analyzer" json:"analyzer" yaml:"analyzer"`                                              // 中文分词方式：auto（按已安装的插件选择 ik、smartcn，都没有时使用 ngram）、ik、smartcn、ngram、standard
+	Pinyin                bool   `mapstructure:"pinyin" json:"pinyin" yaml:"pinyin"`                                                    // 安装了 analysis-pinyin 插件时，是否添加拼音子字段
+	HighlightPreTag       string `mapstructure:"highlight_pre_tag" json:"highlight_pre_tag" yaml:"highlight_pre_tag"`                   // 搜索结果高亮的开始标签
+	HighlightPostTag      string `mapstructure:"highlight_post_tag" json:"highlight_post_tag" yaml:"highlight_post_tag"`                // 搜索结果高亮的结束标签
+	HighlightFragmentSize int    `mapstructure:"highlight_fragment_size" json:"highlight_fragment_size" yaml:"highlight_fragment_size"` // 正文片段的长度（字符数）
+	HighlightFragments    int    `mapstructure:"highlight_fragments" json:"highlight_fragments" yaml:"highlight_fragments"`             // 正文片段的最大数量
+	OutboxCron            string `mapstructure:"outbox_cron" json:"outbox_cron" yaml:"outbox_cron"`                                     // 投递文章变更事件的执行时间（含秒的 cron 表达式）
+	OutboxMaxAttempts     int    `mapstructure:"outbox_max_attempts" json:"outbox_max_attempts" yaml:"outbox_max_attempts"`             // 文章变更事件的最大投递次数，超过后标记为失败
+}
+
+// HighlightTags 高亮标签，未配置时使用 <em></em>
+func (e ES) HighlightTags() (string, string) {
+	if e.HighlightPreTag == "" || e.HighlightPostTag == "" {
+		return "<em>", "</em>"
+	}
+	return e.HighlightPreTag, e.HighlightPostTag
+}
+
+// FragmentSize 正文片段的长度，未配置时为 120 个字符
+func (e ES) FragmentSize() int {
+	if e.HighlightFragmentSize <= 0 {
+		return 120
+	}
+	return e.HighlightFragmentSize
+}
+
+// FragmentCount 正文片段的最大数量，未配置时为 3 段
+func (e ES) FragmentCount() int {
+	if e.HighlightFragments <= 0 {
+		return 3
+	}
+	return e.HighlightFragments
+}
+
+// OutboxSpec 投递任务的 cron 表达式，未配置时每5秒执行一次
+func (e ES) OutboxSpec() string {
+	if e.OutboxCron == "" {
+		return "*/5 * * * * *"
+	}
+	return e.OutboxCron
+}
+
+// OutboxAttempts 文章变更事件的最大投递次数，未配置时为 10 次
+func (e ES) OutboxAttempts() int {
+	if e.OutboxMaxAttempts <= 0 {
+		return 10
+	}
+	return e.OutboxMaxAttempts
 }
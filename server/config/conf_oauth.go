@@ -0,0 +1,23 @@
+package config
+
+// OAuth 第三方登录配置（QQ 登录见 QQ 配置）
+type OAuth struct {
+	GitHub OAuthClient    `mapstructure:"github" json:"github" yaml:"github"` // GitHub 登录，详情请见 https://docs.github.com/apps/oauth-apps
+	OIDC   []OIDCProvider `mapstructure:"oidc" json:"oidc" yaml:"oidc"`       // 通用 OpenID Connect 登录，可配置多个
+}
+
+// OAuthClient OAuth2 客户端配置
+type OAuthClient struct {
+	Enable       bool     `mapstructure:"enable" json:"enable" yaml:"enable"`                   // 是否启用
+	ClientID     string   `mapstructure:"client_id" json:"client_id" yaml:"client_id"`          // 客户端 ID
+	ClientSecret string   `mapstructure:"client_secret" json:"-" yaml:"client_secret"`          // 客户端密钥
+	RedirectURI  string   `mapstructure:"redirect_uri" json:"redirect_uri" yaml:"redirect_uri"` // 回调地址
+	Scopes       []string `mapstructure:"scopes" json:"scopes" yaml:"scopes"`                   // 申请的权限范围，为空时使用默认值
+}
+
+// OIDCProvider 通用 OpenID Connect 提供方配置，端点通过 {issuer}/.well-known/openid-configuration 自动发现
+type OIDCProvider struct {
+	OAuthClient `mapstructure:",squash" yaml:",inline"`
+	Name        string `mapstructure:"name" json:"name" yaml:"name"`       // 提供方名称，用于接口路径和 LoginMethod，如 gitlab、keycloak
+	Issuer      string `mapstructure:"issuer" json:"issuer" yaml:"issuer"` // 签发方地址
+}
@@ -0,0 +1,35 @@
+package config
+
+import "time"
+
+// RateLimit 接口限流与登录防爆破配置
+type RateLimit struct {
+	Enable        bool                       `mapstructure:"enable" json:"enable" yaml:"enable"`                         // 是否启用限流
+	Policies      map[string]RateLimitPolicy `mapstructure:"policies" json:"policies" yaml:"policies"`                   // 按策略名配置的限流规则，键为策略名（如 login、register）
+	EmailCooldown time.Duration              `mapstructure:"email_cooldown" json:"email_cooldown" yaml:"email_cooldown"` // 同一邮箱两次发送验证码的最小间隔
+	Lockout       LoginLockout               `mapstructure:"lockout" json:"lockout" yaml:"lockout"`                      // 登录失败锁定配置
+}
+
+// RateLimitPolicy 单条限流策略
+type RateLimitPolicy struct {
+	Limit  int           `mapstructure:"limit" json:"limit" yaml:"limit"`    // 窗口内允许的最大请求数
+	Window time.Duration `mapstructure:"window" json:"window" yaml:"window"` // 统计窗口长度
+	By     string        `mapstructure:"by" json:"by" yaml:"by"`             // 限流维度：ip 按客户端IP，user 按登录用户（未登录时退化为IP）
+}
+
+// LoginLockout 登录失败锁定配置，同一IP对同一账户的失败达到阈值后按指数退避锁定该IP的登录
+type LoginLockout struct {
+	MaxFailures   int           `mapstructure:"max_failures" json:"max_failures" yaml:"max_failures"`       // 触发锁定的连续失败次数
+	FailureWindow time.Duration `mapstructure:"failure_window" json:"failure_window" yaml:"failure_window"` // 失败次数的统计周期
+	BaseDuration  time.Duration `mapstructure:"base_duration" json:"base_duration" yaml:"base_duration"`    // 首次锁定时长，此后每多失败一次翻倍
+	MaxDuration   time.Duration `mapstructure:"max_duration" json:"max_duration" yaml:"max_duration"`       // 单次锁定的最长时长
+}
+
+// Policy 获取指定名称的限流策略，未配置或配置无效时返回 false
+func (r RateLimit) Policy(name string) (RateLimitPolicy, bool) {
+	policy, ok := r.Policies[name]
+	if !ok || policy.Limit <= 0 || policy.Window <= 0 {
+		return RateLimitPolicy{}, false
+	}
+	return policy, true
+}
@@ -0,0 +1,35 @@
+package config
+
+import "time"
+
+// Search 搜索统计配置
+type Search struct {
+	AnalyticsFlushCron      string        `mapstructure:"analytics_flush_cron" json:"analytics_flush_cron" yaml:"analytics_flush_cron"`                   // 把 Redis 中的搜索统计写入数据库的执行时间（含秒的 cron 表达式）
+	AnalyticsRetentionDays  int           `mapstructure:"analytics_retention_days" json:"analytics_retention_days" yaml:"analytics_retention_days"`       // 搜索统计保留天数，0 表示永久保留
+	PopularQueryWindow      time.Duration `mapstructure:"popular_query_window" json:"popular_query_window" yaml:"popular_query_window"`                   // 同一访客在该时间内重复搜索同一个词，热门搜索词只计一次
+	PopularQueryMinVisitors int           `mapstructure:"popular_query_min_visitors" json:"popular_query_min_visitors" yaml:"popular_query_min_visitors"` // 搜索人次达到该值的搜索词才作为热门搜索词展示
+}
+
+// FlushSpec 写入任务的 cron 表达式，未配置时每5分钟执行一次
+func (s Search) FlushSpec() string {
+	if s.AnalyticsFlushCron == "" {
+		return "0 */5 * * * *"
+	}
+	return s.AnalyticsFlushCron
+}
+
+// PopularDedupeWindow 热门搜索词的访客去重时间，未配置时为24小时
+func (s Search) PopularDedupeWindow() time.Duration {
+	if s.PopularQueryWindow <= 0 {
+		return 24 * time.Hour
+	}
+	return s.PopularQueryWindow
+}
+
+// PopularMinVisitors 热门搜索词展示所需的最少搜索人次，未配置时为3
+func (s Search) PopularMinVisitors() int {
+	if s.PopularQueryMinVisitors <= 0 {
+		return 3
+	}
+	return s.PopularQueryMinVisitors
+}
@@ -0,0 +1,15 @@
+package config
+
+// Trash 回收站配置
+type Trash struct {
+	RetentionDays int    `mapstructure:"retention_days" json:"retention_days" yaml:"retention_days"` // 回收站内容保留天数，超过后彻底删除，0 表示不自动清理
+	PurgeCron     string `mapstructure:"purge_cron" json:"purge_cron" yaml:"purge_cron"`             // 自动清理任务的执行时间（含秒的 cron 表达式）
+}
+
+// PurgeSpec 清理任务的 cron 表达式，未配置时每天凌晨4点执行
+func (t Trash) PurgeSpec() string {
+	if t.PurgeCron == "" {
+		return "0 0 4 * * *"
+	}
+	return t.PurgeCron
+}
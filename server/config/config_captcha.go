@@ -5,10 +5,47 @@ import (
 )
 
 type Captcha struct {
-	Height     int           `mapstructure:"height" json:"height" yaml:"height"`
-	Width      int           `mapstructure:"width" json:"width" yaml:"width"`
-	Length     int           `mapstructure:"length" json:"length" yaml:"length"`
-	MaxSkew    float64       `mapstructure:"max_skew" json:"max_skew" yaml:"max_skew"`
-	DotCount   int           `mapstructure:"dot_count" json:"dot_count" yaml:"dot_count"`
-	Expiration time.Duration `mapstructure:"expiration" json:"expiration" yaml:"expiration"`
+	Height        int                      `mapstructure:"height" json:"height" yaml:"height"`
+	Width         int                      `mapstructure:"width" json:"width" yaml:"width"`
+	Length        int                      `mapstructure:"length" json:"length" yaml:"length"`
+	MaxSkew       float64                  `mapstructure:"max_skew" json:"max_skew" yaml:"max_skew"`
+	DotCount      int                      `mapstructure:"dot_count" json:"dot_count" yaml:"dot_count"`
+	Expiration    time.Duration            `mapstructure:"expiration" json:"expiration" yaml:"expiration"`
+	AudioLanguage string                   `mapstructure:"audio_language" json:"audio_language" yaml:"audio_language"` // 语音验证码语言：zh、en、ja、ru
+	Policies      map[string]CaptchaPolicy `mapstructure:"policies" json:"policies" yaml:"policies"`                   // 按接口配置的验证策略，键为接口名（login、register、forgot_password、send_email_code）
+	Risk          CaptchaRisk              `mapstructure:"risk" json:"risk" yaml:"risk"`                               // 风险评分规则
+}
+
+// 验证策略模式
+const (
+	CaptchaModeAlways   = "always"   // 始终需要验证
+	CaptchaModeAdaptive = "adaptive" // 风险分达到阈值时才需要验证
+	CaptchaModeNever    = "never"    // 从不需要验证
+)
+
+// CaptchaPolicy 单个接口的人机验证策略
+type CaptchaPolicy struct {
+	Mode      string `mapstructure:"mode" json:"mode" yaml:"mode"`                // always / adaptive / never
+	Threshold int    `mapstructure:"threshold" json:"threshold" yaml:"threshold"` // adaptive 模式下触发验证的风险分
+}
+
+// CaptchaRisk 风险评分规则，每项命中后累加对应分值
+type CaptchaRisk struct {
+	FailedAttemptScore int           `mapstructure:"failed_attempt_score" json:"failed_attempt_score" yaml:"failed_attempt_score"` // 账户每次近期失败累加的分值
+	IPFailureScore     int           `mapstructure:"ip_failure_score" json:"ip_failure_score" yaml:"ip_failure_score"`             // 来源IP每次近期失败累加的分值
+	IPFailureWindow    time.Duration `mapstructure:"ip_failure_window" json:"ip_failure_window" yaml:"ip_failure_window"`          // IP和账户失败次数、邮件发送次数的统计周期
+	NewDeviceScore     int           `mapstructure:"new_device_score" json:"new_device_score" yaml:"new_device_score"`             // 账户在未见过的设备上登录时的分值，只对登录接口生效
+	MissingUAScore     int           `mapstructure:"missing_ua_score" json:"missing_ua_score" yaml:"missing_ua_score"`             // 请求缺少 User-Agent 时的分值
+	EmailSendScore     int           `mapstructure:"email_send_score" json:"email_send_score" yaml:"email_send_score"`             // 来源IP或目标邮箱每次近期成功发送邮件累加的分值，只对发送邮件的接口生效
+	BlockedIPs         []string      `mapstructure:"blocked_ips" json:"blocked_ips" yaml:"blocked_ips"`                            // 信誉差的IP或网段（CIDR），命中后始终需要验证
+	TrustedIPs         []string      `mapstructure:"trusted_ips" json:"trusted_ips" yaml:"trusted_ips"`                            // 可信IP或网段（CIDR），命中后不计IP相关风险
+}
+
+// Policy 获取指定接口的验证策略，未配置时默认始终验证
+func (c Captcha) Policy(endpoint string) CaptchaPolicy {
+	policy, ok := c.Policies[endpoint]
+	if !ok || policy.Mode == "" {
+		return CaptchaPolicy{Mode: CaptchaModeAlways}
+	}
+	return policy
 }
@@ -1,17 +1,26 @@
 package config
 
 type Config struct {
-	Captcha Captcha `json:"captcha" yaml:"captcha"`
-	Email   Email   `json:"email" yaml:"email"`
-	ES      ES      `json:"es" yaml:"es"`
-	Gaode   Gaode   `json:"gaode" yaml:"gaode"`
-	Jwt     Jwt     `json:"jwt" yaml:"jwt"`
-	Mysql   Mysql   `json:"mysql" yaml:"mysql"`
-	Qiniu   Qiniu   `json:"qiniu" yaml:"qiniu"`
-	QQ      QQ      `json:"qq" yaml:"qq"`
-	Redis   Redis   `json:"redis" yaml:"redis"`
-	System  System  `json:"system" yaml:"system"`
-	Upload  Upload  `json:"upload" yaml:"upload"`
-	Website Website `json:"website" yaml:"website"`
-	Zap     Zap     `json:"zap" yaml:"zap"`
+	Account   Account   `json:"account" yaml:"account"`
+	Analytics Analytics `json:"analytics" yaml:"analytics"`
+	Audit     Audit     `json:"audit" yaml:"audit"`
+	Backup    Backup    `json:"backup" yaml:"backup"`
+	Captcha   Captcha   `json:"captcha" yaml:"captcha"`
+	Counter   Counter   `json:"counter" yaml:"counter"`
+	Email     Email     `json:"email" yaml:"email"`
+	ES        ES        `json:"es" yaml:"es"`
+	Gaode     Gaode     `json:"gaode" yaml:"gaode"`
+	Jwt       Jwt       `json:"jwt" yaml:"jwt"`
+	Mysql     Mysql     `json:"mysql" yaml:"mysql"`
+	OAuth     OAuth     `json:"oauth" yaml:"oauth"`
+	Qiniu     Qiniu     `json:"qiniu" yaml:"qiniu"`
+	QQ        QQ        `json:"qq" yaml:"qq"`
+	RateLimit RateLimit `json:"rate_limit" yaml:"rate_limit" mapstructure:"rate_limit"`
+	Redis     Redis     `json:"redis" yaml:"redis"`
+	Search    Search    `json:"search" yaml:"search"`
+	System    System    `json:"system" yaml:"system"`
+	Trash     Trash     `json:"trash" yaml:"trash"`
+	Upload    Upload    `json:"upload" yaml:"upload"`
+	Website   Website   `json:"website" yaml:"website"`
+	Zap       Zap       `json:"zap" yaml:"zap"`
 }
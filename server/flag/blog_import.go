@@ -0,0 +1,63 @@
+package flag
+
+import (
+	"fmt"
+	"os"
+
+	"server/service"
+	"server/utils/importer"
+)
+
+// importWordPress 导入 WordPress 导出的 WXR 文件
+func importWordPress(path, author string, matchEmail bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	site, err := importer.ParseWXR(file, nil)
+	if err != nil {
+		return err
+	}
+	return importBlogSite(site, author, matchEmail)
+}
+
+// importHexo 导入 Hexo 的 source/_posts 或 Hugo 的 content 目录
+func importHexo(dir, permalink, author string, matchEmail bool) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s 不是目录", dir)
+	}
+
+	site, err := importer.ParseHexoDir(os.DirFS(dir), permalink, nil)
+	if err != nil {
+		return err
+	}
+	return importBlogSite(site, author, matchEmail)
+}
+
+// importBlogSite 写入解析结果并打印报告，matchEmail 为 true 时按评论者邮箱把评论归属到本站用户
+func importBlogSite(site *importer.Site, author string, matchEmail bool) error {
+	authorID, err := findImportAuthor(author)
+	if err != nil {
+		return err
+	}
+	report, err := service.ServiceGroups.BlogImportService.Import(site, service.BlogImportOptions{AuthorID: authorID, MatchCommentEmail: matchEmail})
+	if err != nil {
+		return err
+	}
+
+	for _, item := range report.Imported {
+		fmt.Printf("[%s] %s -> %s (ID %d，评论 %d 条)\n", item.Type, item.Source, item.Slug, item.ID, item.Comments)
+	}
+	for _, skipped := range report.Skipped {
+		fmt.Printf("[跳过] %s: %s\n", skipped.Source, skipped.Reason)
+	}
+	fmt.Printf("导入文章 %d 篇，页面 %d 个，新建分类 %d 个，评论 %d 条，重定向 %d 条，跳过 %d 项\n",
+		report.Articles, report.Pages, report.Categories, report.Comments, report.Redirects, len(report.Skipped))
+	return nil
+}
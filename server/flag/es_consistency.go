@@ -0,0 +1,45 @@
+package flag
+
+import (
+	"fmt"
+	"strings"
+
+	"server/service"
+)
+
+// checkEsConsistency 比较索引与数据库中已发布的文章并打印差异，fix 为 true 时批量修复
+func checkEsConsistency(fix bool) error {
+	esService := service.NewArticleESService()
+	report, err := esService.CheckConsistency()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("数据库已发布文章 %d 篇，索引文档 %d 篇，同步中 %d 篇\n", report.DatabaseCount, report.IndexCount, report.InFlight)
+	fmt.Printf("缺失 %d 篇，多余 %d 篇，过期 %d 篇\n", report.Missing, report.Orphan, report.Stale)
+	for _, drift := range report.Drift {
+		if len(drift.Fields) > 0 {
+			fmt.Printf("  %-8s %d (%s)\n", drift.Reason, drift.ArticleID, strings.Join(drift.Fields, ", "))
+		} else {
+			fmt.Printf("  %-8s %d\n", drift.Reason, drift.ArticleID)
+		}
+	}
+	if report.Truncated {
+		fmt.Printf("  仅列出前 %d 篇\n", len(report.Drift))
+	}
+
+	if report.Consistent() {
+		fmt.Println("索引与数据库一致")
+		return nil
+	}
+	if !fix {
+		fmt.Println("使用 --check-es-fix 修复")
+		return nil
+	}
+	result, err := esService.RepairConsistency(report)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("已修复：重新写入 %d 篇，删除 %d 篇\n", result.Indexed, result.Deleted)
+	return nil
+}
@@ -1,91 +1,129 @@
 package flag
 
 import (
-	"context"
+	"bufio"
+	"compress/gzip"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"strconv"
+	"io"
+	"os"
+	"time"
 
-	"github.com/elastic/go-elasticsearch/v8/typedapi/core/bulk"
-	"github.com/elastic/go-elasticsearch/v8/typedapi/types"
-	"github.com/elastic/go-elasticsearch/v8/typedapi/types/enums/refresh"
 	"go.uber.org/zap"
 
 	"server/global"
-	"server/model/es"
-	//"server/service"
+	"server/service"
 )
 
-// ImportESData 从JSON文件导入数据到Elasticsearch
-func importEsData(filePath string) error {
-	// 读取JSON文件
-	data, err := ioutil.ReadFile(filePath)
+// esCheckpoint 导入检查点，记录导入文件的大小和修改时间，文件变化后检查点失效
+type esCheckpoint struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Done    int64     `json:"done"`
+}
+
+// esCheckpointPath 检查点文件路径
+func esCheckpointPath(path string) string {
+	return path + ".checkpoint"
+}
+
+// loadEsCheckpoint 读取与导入文件匹配的检查点，没有或不匹配时返回 0
+func loadEsCheckpoint(path string, info os.FileInfo) int64 {
+	data, err := os.ReadFile(esCheckpointPath(path))
 	if err != nil {
-		global.ZapLog.Error("读取文件失败", zap.String("file_path", filePath), zap.Error(err))
-		return fmt.Errorf("读取文件失败: %v", err)
+		return 0
 	}
-
-	// 解析JSON数据到文章切片
-	var articles []es.ArticleES
-	if err := json.Unmarshal(data, &articles); err != nil {
-		global.ZapLog.Error("解析JSON失败", zap.String("file_path", filePath), zap.Error(err))
-		return fmt.Errorf("解析JSON失败: %v", err)
+	var cp esCheckpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return 0
 	}
-
-	// 批量导入到Elasticsearch
-	total := len(articles)
-	if total == 0 {
-		global.ZapLog.Info("没有数据需要导入", zap.String("file_path", filePath))
-		return nil
+	if cp.Size != info.Size() || !cp.ModTime.Equal(info.ModTime()) {
+		global.ZapLog.Warn("导入文件已变化，忽略检查点", zap.String("path", path))
+		return 0
 	}
+	return cp.Done
+}
 
-	indexName := (&es.ArticleES{}).IndexName()
-
-	//esService := service.NewArticleESService()
-	// 检查索引是否存在
-	// exists, err := esService.IndexExists()
-	// if err != nil {
-	// 	global.ZapLog.Error("检查索引存在性失败", zap.String("index_name", indexName), zap.Error(err))
-	// 	return fmt.Errorf("检查索引存在性失败: %v", err)
-	// }
+// saveEsCheckpoint 保存检查点，先写临时文件再重命名
+func saveEsCheckpoint(path string, info os.FileInfo, done int64) error {
+	data, err := json.Marshal(esCheckpoint{Path: path, Size: info.Size(), ModTime: info.ModTime(), Done: done})
+	if err != nil {
+		return err
+	}
+	cpPath := esCheckpointPath(path)
+	if err := os.WriteFile(cpPath+".tmp", data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(cpPath+".tmp", cpPath)
+}
 
-	// // 如果索引存在则删除
-	// if exists {
-	// 	if err := esService.DeleteIndex(); err != nil {
-	// 		global.ZapLog.Error("删除索引失败", zap.String("index_name", indexName), zap.Error(err))
-	// 		return fmt.Errorf("删除索引失败: %v", err)
-	// 	}
-	// }
+// openEsImport 打开导入文件，根据文件头自动识别 gzip 压缩
+func openEsImport(file *os.File) (io.Reader, error) {
+	reader := bufio.NewReader(file)
+	magic, err := reader.Peek(2)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(reader)
+	}
+	return reader, nil
+}
 
-	// 创建新索引
-	// if err := esService.CreateIndex(); err != nil {
-	// 	global.ZapLog.Error("创建索引失败", zap.String("index_name", indexName), zap.Error(err))
-	// 	return fmt.Errorf("创建索引失败: %v", err)
-	// }
+// importEsData 从 JSON Lines 文件批量导入文档，中断后再次运行会从检查点继续，restart 为 true 时从头导入
+// 导入完成后校验索引中的文档数不少于文件中的文档数
+func importEsData(path string, batchSize, retries int, restart bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		global.ZapLog.Error("读取文件失败", zap.String("file_path", path), zap.Error(err))
+		return fmt.Errorf("读取文件失败: %v", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	reader, err := openEsImport(file)
+	if err != nil {
+		return fmt.Errorf("读取文件失败: %v", err)
+	}
 
-	// 构建批量请求数据
-	var request bulk.Request
-	for _, article := range articles {
-		// 将uint64类型的ID转换为string并获取指针
-		idStr := strconv.FormatUint(article.ID, 10)
-		// 为每条数据创建索引操作，指定文档的ID
-		request = append(request, types.OperationContainer{Index: &types.IndexOperation{Id_: &idStr}})
-		// 添加文档数据到请求
-		request = append(request, article)
+	var skip int64
+	if restart {
+		os.Remove(esCheckpointPath(path))
+	} else if skip = loadEsCheckpoint(path, info); skip > 0 {
+		fmt.Printf("从检查点继续，跳过已导入的 %d 篇文档\n", skip)
 	}
 
-	// 使用Elasticsearch客户端执行批量操作
-	_, err = global.ES.Bulk().
-		Request(&request).       // 提交请求数据
-		Index(indexName).        // 指定索引名称
-		Refresh(refresh.True).   // 强制刷新索引以使文档立即可见
-		Do(context.Background()) // 执行请求
+	esService := service.NewArticleESService()
+	total, err := esService.ImportDocuments(reader, service.ESImportOptions{
+		BatchSize: batchSize,
+		Retries:   retries,
+		Skip:      skip,
+		Checkpoint: func(done int64) error {
+			return saveEsCheckpoint(path, info, done)
+		},
+		Progress: func(done int64) {
+			fmt.Printf("\r已导入 %d 篇文档", done)
+		},
+	})
+	fmt.Println()
 	if err != nil {
-		global.ZapLog.Error("批量导入失败", zap.String("index_name", indexName), zap.Int("total_records", total), zap.Error(err))
-		return fmt.Errorf("批量导入失败: %v", err)
+		global.ZapLog.Error("批量导入失败", zap.String("file_path", path), zap.Int64("done", total), zap.Error(err))
+		return fmt.Errorf("导入中断（已完成 %d 篇，再次运行将从检查点继续）: %v", total, err)
 	}
+	os.Remove(esCheckpointPath(path))
 
-	global.ZapLog.Info("数据导入成功", zap.String("index_name", indexName), zap.Int("total_records", total))
+	count, err := esService.CountDocuments()
+	if err != nil {
+		return err
+	}
+	if count < total {
+		return fmt.Errorf("索引中的文档数 %d 少于文件中的文档数 %d", count, total)
+	}
+	fmt.Printf("共导入 %d 篇文档，索引中现有 %d 篇文档\n", total, count)
+	global.ZapLog.Info("数据导入成功", zap.String("file_path", path), zap.Int64("total_records", total), zap.Int64("index_count", count))
 	return nil
 }
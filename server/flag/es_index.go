@@ -1,6 +1,8 @@
 package flag
 
 import (
+	"fmt"
+
 	"server/global"
 	"server/service"
 
@@ -19,3 +21,14 @@ func createEsIndex() error {
 	global.ZapLog.Info("Elasticsearch索引创建成功")
 	return nil
 }
+
+// reindexEs 零停机重建索引：从 MySQL 构建新版本索引后切换别名
+func reindexEs() error {
+	index, count, err := service.NewArticleESService().Reindex()
+	if err != nil {
+		global.ZapLog.Error("重建Elasticsearch索引失败", zap.Error(err))
+		return err
+	}
+	fmt.Printf("已重建索引 %s，写入 %d 篇文章，别名已切换\n", index, count)
+	return nil
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 
+	"server/utils/importer"
+
 	"github.com/urfave/cli"
 )
 
@@ -15,12 +17,12 @@ var (
 	}
 	exportFlag = &cli.BoolFlag{
 		Name:  "export",
-		Usage: "导出MySQL数据到SQL文件",
+		Usage: "备份MySQL数据到压缩归档文件",
 	}
 	exportPathFlag = &cli.StringFlag{
 		Name:  "export-path",
 		Usage: "指定导出文件路径",
-		Value: "backup.sql",
+		Value: "backup.json.gz",
 	}
 	tablesFlag = &cli.StringFlag{
 		Name:  "tables",
@@ -32,16 +34,20 @@ var (
 	}
 	importFlag = &cli.BoolFlag{
 		Name:  "import",
-		Usage: "从SQL文件导入MySQL数据",
+		Usage: "从备份归档文件恢复MySQL数据",
 	}
 	importPathFlag = &cli.StringFlag{
 		Name:  "import-path",
 		Usage: "指定导入文件路径",
-		Value: "backup.sql",
+		Value: "backup.json.gz",
 	}
 	createEsIndexFlag = &cli.BoolFlag{
 		Name:  "create-es-index",
-		Usage: "创建Elasticsearch索引结构",
+		Usage: "创建Elasticsearch索引结构（清空现有数据）",
+	}
+	reindexEsFlag = &cli.BoolFlag{
+		Name:  "reindex-es",
+		Usage: "从MySQL重建Elasticsearch索引，完成后切换别名，搜索不中断",
 	}
 	exportEsFlag = &cli.BoolFlag{
 		Name:  "export-es",
@@ -49,8 +55,8 @@ var (
 	}
 	exportEsPathFlag = &cli.StringFlag{
 		Name:  "export-es-path",
-		Usage: "指定Elasticsearch导出文件路径",
-		Value: "es_backup.json",
+		Usage: "指定Elasticsearch导出文件路径（JSON Lines，以 .gz 结尾时压缩）",
+		Value: "es_backup.jsonl.gz",
 	}
 	importEsFlag = &cli.BoolFlag{
 		Name:  "import-es",
@@ -58,8 +64,109 @@ var (
 	}
 	importEsPathFlag = &cli.StringFlag{
 		Name:  "import-es-path",
-		Usage: "指定Elasticsearch导入文件路径",
-		Value: "es_backup.json",
+		Usage: "指定Elasticsearch导入文件路径（JSON Lines，自动识别 gzip 压缩）",
+		Value: "es_backup.jsonl.gz",
+	}
+	esBatchSizeFlag = &cli.IntFlag{
+		Name:  "es-batch-size",
+		Usage: "Elasticsearch导出每页、导入每批的文档数",
+		Value: 500,
+	}
+	esRetriesFlag = &cli.IntFlag{
+		Name:  "es-retries",
+		Usage: "Elasticsearch批量写入失败时的重试次数，-1 为不重试",
+		Value: 3,
+	}
+	importEsRestartFlag = &cli.BoolFlag{
+		Name:  "import-es-restart",
+		Usage: "忽略检查点，从头导入Elasticsearch数据",
+	}
+	checkEsFlag = &cli.BoolFlag{
+		Name:  "check-es",
+		Usage: "检查Elasticsearch索引与MySQL中已发布文章是否一致",
+	}
+	checkEsFixFlag = &cli.BoolFlag{
+		Name:  "check-es-fix",
+		Usage: "检查后修复不一致：重新写入缺失和过期的文档，删除多余的文档",
+	}
+	exportMdFlag = &cli.BoolFlag{
+		Name:  "export-md",
+		Usage: "导出全部文章为带YAML头部元数据的Markdown文件",
+	}
+	exportMdPathFlag = &cli.StringFlag{
+		Name:  "export-md-path",
+		Usage: "指定Markdown导出目录",
+		Value: "markdown_export",
+	}
+	importMdFlag = &cli.BoolFlag{
+		Name:  "import-md",
+		Usage: "从Markdown文件导入文章（按slug新建或更新）",
+	}
+	importMdPathFlag = &cli.StringFlag{
+		Name:  "import-md-path",
+		Usage: "指定Markdown导入目录",
+		Value: "markdown_export",
+	}
+	importMdAuthorFlag = &cli.StringFlag{
+		Name:  "import-md-author",
+		Usage: "指定新建文章的作者用户名，默认为第一个管理员",
+	}
+	importMdDryRunFlag = &cli.BoolFlag{
+		Name:  "import-md-dry-run",
+		Usage: "只预演Markdown导入，不写入数据",
+	}
+	importWxrFlag = &cli.BoolFlag{
+		Name:  "import-wxr",
+		Usage: "导入WordPress导出的WXR文件",
+	}
+	importWxrPathFlag = &cli.StringFlag{
+		Name:  "import-wxr-path",
+		Usage: "指定WXR文件路径",
+		Value: "wordpress.xml",
+	}
+	importHexoFlag = &cli.BoolFlag{
+		Name:  "import-hexo",
+		Usage: "导入Hexo或Hugo的文章目录",
+	}
+	importHexoPathFlag = &cli.StringFlag{
+		Name:  "import-hexo-path",
+		Usage: "指定Hexo的source/_posts或Hugo的content目录",
+		Value: "source/_posts",
+	}
+	importHexoPermalinkFlag = &cli.StringFlag{
+		Name:  "import-hexo-permalink",
+		Usage: "旧站的固定链接格式，用于生成重定向（Hugo可用 /posts/:slug/）",
+		Value: importer.DefaultHexoPermalink,
+	}
+	importBlogAuthorFlag = &cli.StringFlag{
+		Name:  "import-blog-author",
+		Usage: "指定导入文章的作者用户名，默认为第一个管理员",
+	}
+	importBlogMatchEmailFlag = &cli.BoolFlag{
+		Name:  "import-blog-match-email",
+		Usage: "按评论者邮箱把导入的评论归属到本站用户（旧站邮箱未经验证，默认全部归属占位账户）",
+	}
+	backupFlag = &cli.BoolFlag{
+		Name:  "backup",
+		Usage: "创建整站备份包（数据库、搜索索引和上传的媒体文件）",
+	}
+	backupPathFlag = &cli.StringFlag{
+		Name:  "backup-path",
+		Usage: "指定整站备份包路径",
+		Value: "site_backup.tar.gz",
+	}
+	restoreFlag = &cli.BoolFlag{
+		Name:  "restore",
+		Usage: "校验并恢复整站备份包，恢复前必须停止博客服务",
+	}
+	restorePathFlag = &cli.StringFlag{
+		Name:  "restore-path",
+		Usage: "指定要恢复的整站备份包路径",
+		Value: "site_backup.tar.gz",
+	}
+	restoreEsSnapshotFlag = &cli.BoolFlag{
+		Name:  "restore-es-snapshot",
+		Usage: "恢复备份包中的索引快照并按数据库修复差异，默认从数据库重建搜索索引",
 	}
 )
 
@@ -83,10 +190,34 @@ func setupFlags(app *cli.App) {
 		importFlag,
 		importPathFlag,
 		createEsIndexFlag,
+		reindexEsFlag,
 		exportEsFlag,
 		exportEsPathFlag,
 		importEsFlag,
 		importEsPathFlag,
+		esBatchSizeFlag,
+		esRetriesFlag,
+		importEsRestartFlag,
+		checkEsFlag,
+		checkEsFixFlag,
+		exportMdFlag,
+		exportMdPathFlag,
+		importMdFlag,
+		importMdPathFlag,
+		importMdAuthorFlag,
+		importMdDryRunFlag,
+		importWxrFlag,
+		importWxrPathFlag,
+		importHexoFlag,
+		importHexoPathFlag,
+		importHexoPermalinkFlag,
+		importBlogAuthorFlag,
+		importBlogMatchEmailFlag,
+		backupFlag,
+		backupPathFlag,
+		restoreFlag,
+		restorePathFlag,
+		restoreEsSnapshotFlag,
 	}
 }
 
@@ -125,9 +256,15 @@ func setupActions(app *cli.App) {
 			}
 			return nil
 		}
+		if c.Bool("reindex-es") {
+			if err := reindexEs(); err != nil {
+				return fmt.Errorf("重建ES索引失败: %v", err)
+			}
+			return nil
+		}
 		if c.Bool("export-es") {
 			filePath := c.String("export-es-path")
-			if err := exportEsData(filePath); err != nil {
+			if err := exportEsData(filePath, c.Int("es-batch-size")); err != nil {
 				return fmt.Errorf("ES数据导出失败: %v", err)
 			}
 			fmt.Printf("ES数据已成功导出至 %s\n", filePath)
@@ -135,12 +272,54 @@ func setupActions(app *cli.App) {
 		}
 		if c.Bool("import-es") {
 			filePath := c.String("import-es-path")
-			if err := importEsData(filePath); err != nil {
+			if err := importEsData(filePath, c.Int("es-batch-size"), c.Int("es-retries"), c.Bool("import-es-restart")); err != nil {
 				return fmt.Errorf("ES数据导入失败: %v", err)
 			}
 			fmt.Printf("ES数据已成功从 %s 导入\n", filePath)
 			return nil
 		}
+		if c.Bool("check-es") || c.Bool("check-es-fix") {
+			if err := checkEsConsistency(c.Bool("check-es-fix")); err != nil {
+				return fmt.Errorf("ES一致性检查失败: %v", err)
+			}
+			return nil
+		}
+		if c.Bool("export-md") {
+			if err := exportMarkdown(c.String("export-md-path")); err != nil {
+				return fmt.Errorf("Markdown导出失败: %v", err)
+			}
+			return nil
+		}
+		if c.Bool("import-md") {
+			if err := importMarkdown(c.String("import-md-path"), c.String("import-md-author"), c.Bool("import-md-dry-run")); err != nil {
+				return fmt.Errorf("Markdown导入失败: %v", err)
+			}
+			return nil
+		}
+		if c.Bool("import-wxr") {
+			if err := importWordPress(c.String("import-wxr-path"), c.String("import-blog-author"), c.Bool("import-blog-match-email")); err != nil {
+				return fmt.Errorf("WordPress导入失败: %v", err)
+			}
+			return nil
+		}
+		if c.Bool("import-hexo") {
+			if err := importHexo(c.String("import-hexo-path"), c.String("import-hexo-permalink"), c.String("import-blog-author"), c.Bool("import-blog-match-email")); err != nil {
+				return fmt.Errorf("Hexo导入失败: %v", err)
+			}
+			return nil
+		}
+		if c.Bool("backup") {
+			if err := backupSite(c.String("backup-path")); err != nil {
+				return fmt.Errorf("整站备份失败: %v", err)
+			}
+			return nil
+		}
+		if c.Bool("restore") {
+			if err := restoreSite(c.String("restore-path"), c.Bool("restore-es-snapshot")); err != nil {
+				return fmt.Errorf("整站恢复失败: %v", err)
+			}
+			return nil
+		}
 		return cli.ShowAppHelp(c)
 
 	}
@@ -0,0 +1,95 @@
+package flag
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gorm.io/gorm"
+
+	"server/global"
+	"server/model/appType"
+	"server/model/database"
+	"server/service"
+)
+
+// exportMarkdown 把全部文章导出为 Markdown 文件到指定目录
+func exportMarkdown(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	count, err := service.ServiceGroups.ArticleMarkdownService.Export(func(name string, data []byte) error {
+		return os.WriteFile(filepath.Join(dir, name), data, 0644)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("已导出 %d 篇文章至 %s\n", count, dir)
+	return nil
+}
+
+// importMarkdown 从目录导入 Markdown 文章，author 为空时使用第一个管理员作为作者
+func importMarkdown(dir, author string, dryRun bool) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s 不是目录", dir)
+	}
+
+	authorID, err := findImportAuthor(author)
+	if err != nil {
+		return err
+	}
+
+	report, err := service.ServiceGroups.ArticleMarkdownService.Import(os.DirFS(dir), service.MarkdownImportOptions{
+		AuthorID: authorID,
+		DryRun:   dryRun,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, item := range report.Items {
+		line := fmt.Sprintf("[%s] %s", item.Action, item.File)
+		if item.Slug != "" {
+			line += " -> " + item.Slug
+		}
+		if item.Images > 0 {
+			line += fmt.Sprintf("，图片 %d 张", item.Images)
+		}
+		if len(item.MissingImages) > 0 {
+			line += fmt.Sprintf("，缺失图片 %v", item.MissingImages)
+		}
+		if item.Error != "" {
+			line += "，错误: " + item.Error
+		}
+		fmt.Println(line)
+	}
+	if dryRun {
+		fmt.Print("预演结果（未写入）：")
+	}
+	fmt.Printf("新建 %d 篇，更新 %d 篇，失败 %d 篇，新建标签 %v，新建分类 %v\n",
+		report.Created, report.Updated, report.Failed, report.CreatedTags, report.CreatedCategories)
+	return nil
+}
+
+// findImportAuthor 按用户名查找导入内容的作者，用户名为空时使用第一个管理员
+func findImportAuthor(username string) (uint, error) {
+	var user database.User
+	query := global.DB.Order("id")
+	if username != "" {
+		query = query.Where("username = ?", username)
+	} else {
+		query = query.Where("role = ?", appType.RoleAdmin)
+	}
+	if err := query.First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, errors.New("找不到作者，请指定作者用户名")
+		}
+		return 0, err
+	}
+	return user.ID, nil
+}
@@ -0,0 +1,31 @@
+package flag
+
+import (
+	"fmt"
+
+	"server/service"
+)
+
+// backupSite 创建整站备份包（数据库、搜索索引和媒体文件）
+func backupSite(path string) error {
+	manifest, err := service.ServiceGroups.SiteBackupService.CreateBundle(path)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("整站备份已保存至 %s：表结构版本 %s，索引文档 %d 篇，媒体文件 %d 个\n",
+		path, manifest.SchemaVersion, manifest.ESDocuments, manifest.MediaFiles)
+	if !manifest.ESIncluded {
+		fmt.Println("注意：导出搜索索引失败，备份包不包含索引快照，恢复时只能从数据库重建")
+	}
+	return nil
+}
+
+// restoreSite 校验并恢复整站备份包，useESSnapshot 为 true 时恢复备份包中的索引快照，否则从数据库重建搜索索引
+func restoreSite(path string, useESSnapshot bool) error {
+	manifest, err := service.ServiceGroups.SiteBackupService.RestoreBundle(path, service.RestoreBundleOptions{UseESSnapshot: useESSnapshot})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("已从 %s 恢复（备份时间 %s），媒体文件 %d 个\n", path, manifest.CreatedAt.Format("2006-01-02 15:04:05"), manifest.MediaFiles)
+	return nil
+}
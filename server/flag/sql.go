@@ -5,26 +5,39 @@ import (
 	"server/model/database"
 
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 func migrateDatabase() error {
+	// 迁移前记录 users 表是否已有 email_verified 列，新增该列时需要回填已有用户
+	backfillEmailVerified := global.DB.Migrator().HasTable(&database.User{}) &&
+		!global.DB.Migrator().HasColumn(&database.User{}, "EmailVerified")
 
-	err := global.DB.AutoMigrate(
-		&database.User{},
-		&database.Comment{},
-		&database.Article{},
-		&database.Like{},
-		&database.Favorite{},
-		&database.Category{},
-		&database.Tag{},
-		&database.ArticleTag{},
-		&database.Media{},
-		&database.Page{},
-	)
+	err := global.DB.AutoMigrate(database.AllModels()...)
 	if err != nil {
 		global.ZapLog.Error("数据库表结构迁移失败", zap.Error(err))
 		return err
 	}
+
+	if backfillEmailVerified {
+		if err := backfillUserEmailVerified(); err != nil {
+			global.ZapLog.Error("回填邮箱验证时间失败", zap.Error(err))
+			return err
+		}
+	}
 	global.ZapLog.Info("数据库表结构迁移成功")
 	return nil
 }
+
+// backfillUserEmailVerified 新增 email_verified 列之前注册的用户没有验证记录，视为注册时已验证
+// 否则开启 require_verified_email 相关选项后这些用户会被拒之门外；只在新增该列的那次迁移中执行
+func backfillUserEmailVerified() error {
+	result := global.DB.Model(&database.User{}).
+		Where("email_verified IS NULL AND email <> ''").
+		Update("email_verified", gorm.Expr("created_at"))
+	if result.Error != nil {
+		return result.Error
+	}
+	global.ZapLog.Info("已回填已有用户的邮箱验证时间", zap.Int64("rows", result.RowsAffected))
+	return nil
+}
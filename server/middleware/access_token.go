@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"server/model/appType"
+	"server/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// accessTokenRoutes 允许使用个人访问令牌访问的接口及所需权限范围
+// 未列出的接口（账户、令牌管理、后台管理等）一律拒绝访问令牌，只能通过登录会话访问
+var accessTokenRoutes = map[string]appType.TokenScope{
+	"GET /api/articles/my":         appType.ScopeArticlesRead,
+	"GET /api/articles/:id":        appType.ScopeArticlesRead,
+	"POST /api/articles":           appType.ScopeArticlesWrite,
+	"PUT /api/articles/:id":        appType.ScopeArticlesWrite,
+	"DELETE /api/articles/:id":     appType.ScopeArticlesWrite,
+	"GET /api/image/list":          appType.ScopeMediaWrite,
+	"POST /api/image/upload":       appType.ScopeMediaWrite,
+	"PUT /api/image/update/:id":    appType.ScopeMediaWrite,
+	"DELETE /api/image/delete/:id": appType.ScopeMediaWrite,
+	"POST /api/pages":              appType.ScopePagesWrite,
+	"PUT /api/pages":               appType.ScopePagesWrite,
+	"DELETE /api/pages/:id":        appType.ScopePagesWrite,
+	"POST /api/comments":           appType.ScopeCommentsWrite,
+	"PUT /api/comments/:id":        appType.ScopeCommentsWrite,
+	"DELETE /api/comments/:id":     appType.ScopeCommentsWrite,
+	"POST /api/comments/:id/reply": appType.ScopeCommentsWrite,
+}
+
+// authenticateAccessToken 使用个人访问令牌认证，校验令牌本身以及当前接口所需的权限范围
+func authenticateAccessToken(c *gin.Context, raw string) bool {
+	scope, ok := accessTokenRoutes[c.Request.Method+" "+c.FullPath()]
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "该接口不支持使用访问令牌"})
+		c.Abort()
+		return false
+	}
+
+	token, user, err := service.ServiceGroups.AccessTokenService.Authenticate(raw, c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "msg": err.Error()})
+		c.Abort()
+		return false
+	}
+	if !token.HasScope(scope) {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "访问令牌缺少权限: " + string(scope)})
+		c.Abort()
+		return false
+	}
+
+	c.Set("userID", user.ID)
+	c.Set("username", user.Username)
+	c.Set("accessTokenID", token.ID)
+	return true
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"server/global"
+	"server/model/database"
+	"server/model/response"
+	"server/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireVerifiedEmail 邮箱未验证的账户禁止执行受限操作，enabled 返回 false 时不做限制
+// 需放在 InitJWT 之后使用
+func RequireVerifiedEmail(enabled func() bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !enabled() {
+			c.Next()
+			return
+		}
+
+		userID, err := utils.GetUserID(c)
+		if err != nil {
+			response.NoAuth(err.Error(), c)
+			c.Abort()
+			return
+		}
+
+		var user database.User
+		if err := global.DB.Select("id", "email_verified").Where("id = ?", userID).First(&user).Error; err != nil {
+			response.NoAuth("用户不存在", c)
+			c.Abort()
+			return
+		}
+		if !user.IsEmailVerified() {
+			response.Forbidden("请先验证邮箱", c)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// CommentRequiresVerifiedEmail 评论是否要求邮箱已验证
+func CommentRequiresVerifiedEmail() bool {
+	return global.Config.Account.RequireVerifiedEmailToComment
+}
+
+// PostRequiresVerifiedEmail 发布文章是否要求邮箱已验证
+func PostRequiresVerifiedEmail() bool {
+	return global.Config.Account.RequireVerifiedEmailToPost
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"fmt"
 	"net/http"
+	"server/service"
 	"server/utils"
 	"strings"
 	"time"
@@ -39,9 +40,18 @@ func InitJWT() gin.HandlerFunc {
 			return
 		}
 
+		// 个人访问令牌只能访问允许的接口，且需要具备对应的权限范围
+		if service.IsAccessToken(parts[1]) {
+			if authenticateAccessToken(c, parts[1]) {
+				c.Next()
+			}
+			return
+		}
+
 		// 解析token - 使用utils包中的ParseToken函数
 		claims, err := utils.ParseToken(parts[1], false)
-		if err != nil {
+		// 两步验证挑战令牌等受限令牌不能作为访问令牌使用
+		if err != nil || claims.Purpose != "" {
 			// 对于GET请求，允许未登录用户访问，但不设置用户信息
 			if c.Request.Method == "GET" {
 				c.Next()
@@ -76,3 +86,29 @@ func InitJWT() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// InitTwoFactorSetupJWT 开启两步验证接口使用的认证中间件
+// 除普通访问令牌外，还接受角色强制要求两步验证、但尚未开启的用户登录时获得的受限令牌
+func InitTwoFactorSetupJWT() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := strings.SplitN(c.Request.Header.Get("Authorization"), " ", 2)
+		if !(len(parts) == 2 && parts[0] == "Bearer") {
+			c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "msg": "未提供token"})
+			c.Abort()
+			return
+		}
+
+		claims, err := utils.ParseToken(parts[1], false)
+		if err != nil || (claims.Purpose != "" && claims.Purpose != utils.TokenPurposeTwoFactorSetup) {
+			c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "msg": "无效的token"})
+			c.Abort()
+			return
+		}
+
+		c.Set("userID", claims.UserID)
+		c.Set("username", claims.Username)
+		c.Set("tokenPurpose", claims.Purpose)
+		c.Set("tokenID", claims.ID)
+		c.Next()
+	}
+}
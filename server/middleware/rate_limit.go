@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"fmt"
+	"server/global"
+	"server/model/response"
+	"server/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RateLimit 基于Redis固定窗口的限流中间件，policy 为配置文件 rate_limit.policies 中的策略名
+// 响应中会携带 RateLimit-Limit / RateLimit-Remaining / RateLimit-Reset 头，超限时额外返回 Retry-After
+func RateLimit(policy string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		conf := global.Config.RateLimit
+		rule, ok := conf.Policy(policy)
+		if !conf.Enable || !ok {
+			c.Next()
+			return
+		}
+
+		// 确定限流主体：按用户限流时优先使用登录用户ID，未登录则退化为IP
+		subject := "ip:" + c.ClientIP()
+		if rule.By == "user" {
+			if userID, err := utils.GetUserID(c); err == nil {
+				subject = fmt.Sprintf("user:%d", userID)
+			}
+		}
+
+		key := fmt.Sprintf("rate_limit:%s:%s", policy, subject)
+		count, reset, err := utils.IncrWindowCounter(key, rule.Window)
+		if err != nil {
+			// Redis不可用时放行，避免限流组件成为单点故障
+			global.ZapLog.Error("限流计数失败", zap.String("policy", policy), zap.Error(err))
+			c.Next()
+			return
+		}
+
+		remaining := int64(rule.Limit) - count
+		if remaining < 0 {
+			remaining = 0
+		}
+		c.Header("RateLimit-Limit", strconv.Itoa(rule.Limit))
+		c.Header("RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+		c.Header("RateLimit-Reset", utils.RetryAfterSeconds(reset))
+		c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", rule.Limit, int64(rule.Window.Seconds())))
+
+		if count > int64(rule.Limit) {
+			c.Header("Retry-After", utils.RetryAfterSeconds(reset))
+			global.ZapLog.Warn("请求触发限流",
+				zap.String("policy", policy),
+				zap.String("subject", subject),
+				zap.Int64("count", count))
+			response.TooManyRequests("请求过于频繁，请稍后再试", c)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
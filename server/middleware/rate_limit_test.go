@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"server/config"
+	"server/global"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis"
+	"go.uber.org/zap"
+)
+
+// Redis 不可用时限流中间件放行请求，不让限流组件成为单点故障
+func TestRateLimitFailOpen(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("获取端口失败: %v", err)
+	}
+	listener.Close()
+	global.Redis = redis.NewClient(&redis.Options{Addr: listener.Addr().String(), DialTimeout: time.Second})
+	defer global.Redis.Close()
+	global.ZapLog = zap.NewNop()
+	global.Config = &config.Config{}
+	global.Config.RateLimit = config.RateLimit{
+		Enable:   true,
+		Policies: map[string]config.RateLimitPolicy{"test": {Limit: 1, Window: time.Minute}},
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", RateLimit("test"), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for i := 1; i <= 3; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != http.StatusNoContent {
+			t.Errorf("第 %d 次请求状态码为 %d，Redis 不可用时应当放行", i, w.Code)
+		}
+		if w.Header().Get("Retry-After") != "" {
+			t.Errorf("第 %d 次请求不应返回 Retry-After", i)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package appType
+
+// AccountDeletionMode 注销账户时对用户内容的处理方式
+type AccountDeletionMode string
+
+// 注销方式常量
+const (
+	AccountDeletionAnonymize AccountDeletionMode = "anonymize" // 保留文章和评论，抹去个人信息
+	AccountDeletionDelete    AccountDeletionMode = "delete"    // 彻底删除用户的所有内容
+)
+
+// 检查注销方式是否有效
+func (m AccountDeletionMode) IsValid() bool {
+	return m == AccountDeletionAnonymize || m == AccountDeletionDelete
+}
+
+// AccountDeletionStatus 注销任务状态
+type AccountDeletionStatus string
+
+// 注销任务状态常量
+const (
+	AccountDeletionPending   AccountDeletionStatus = "pending"   // 等待执行
+	AccountDeletionRunning   AccountDeletionStatus = "running"   // 执行中
+	AccountDeletionCompleted AccountDeletionStatus = "completed" // 已完成
+	AccountDeletionFailed    AccountDeletionStatus = "failed"    // 执行失败
+)
@@ -0,0 +1,35 @@
+package appType
+
+// AuditAction 审计日志记录的操作类型
+type AuditAction string
+
+// 操作类型常量
+const (
+	AuditActionCreate         AuditAction = "create"          // 创建
+	AuditActionUpdate         AuditAction = "update"          // 修改
+	AuditActionDelete         AuditAction = "delete"          // 删除
+	AuditActionEnable         AuditAction = "enable"          // 启用
+	AuditActionDisable        AuditAction = "disable"         // 禁用
+	AuditActionChangePassword AuditAction = "change_password" // 修改密码
+	AuditActionResetTwoFactor AuditAction = "reset_2fa"       // 重置两步验证
+	AuditActionCleanup        AuditAction = "cleanup"         // 批量清理
+	AuditActionRestore        AuditAction = "restore"         // 从回收站恢复
+	AuditActionPurge          AuditAction = "purge"           // 从回收站彻底删除
+)
+
+// AuditTargetType 审计日志记录的操作对象类型
+type AuditTargetType string
+
+// 操作对象类型常量
+const (
+	AuditTargetArticle  AuditTargetType = "article"   // 文章
+	AuditTargetPage     AuditTargetType = "page"      // 页面
+	AuditTargetCategory AuditTargetType = "category"  // 分类
+	AuditTargetTag      AuditTargetType = "tag"       // 标签
+	AuditTargetComment  AuditTargetType = "comment"   // 评论
+	AuditTargetMedia    AuditTargetType = "media"     // 图片
+	AuditTargetUser     AuditTargetType = "user"      // 用户
+	AuditTargetSynonym  AuditTargetType = "synonym"   // 搜索同义词
+	AuditTargetESOutbox AuditTargetType = "es_outbox" // ES同步事件
+	AuditTargetESIndex  AuditTargetType = "es_index"  // ES索引
+)
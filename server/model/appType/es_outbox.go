@@ -0,0 +1,19 @@
+package appType
+
+// ESOutboxAction 文章变更事件的类型
+type ESOutboxAction string
+
+// 事件类型常量
+const (
+	ESOutboxIndex  ESOutboxAction = "index"  // 新建或修改文章
+	ESOutboxDelete ESOutboxAction = "delete" // 删除文章
+)
+
+// ESOutboxStatus 事件的投递状态，投递成功的事件直接删除
+type ESOutboxStatus string
+
+// 投递状态常量
+const (
+	ESOutboxPending ESOutboxStatus = "pending" // 等待投递（包括等待重试）
+	ESOutboxFailed  ESOutboxStatus = "failed"  // 超过重试次数，需要管理员处理
+)
@@ -8,11 +8,13 @@ const (
 	LoginTypePassword LoginType = "password" // 密码登录
 	LoginTypeQQ       LoginType = "qq"       // QQ登录
 	LoginTypeWeChat   LoginType = "wechat"   // 微信登录
+	LoginTypeGitHub   LoginType = "github"   // GitHub登录
 )
 
-// 支持的登录方式列表
+// 支持的登录方式列表（通用 OpenID Connect 提供方以配置中的名称作为登录方式，不在此列表中）
 var SupportedLoginTypes = []LoginType{
 	LoginTypePassword,
 	LoginTypeQQ,
 	LoginTypeWeChat,
+	LoginTypeGitHub,
 }
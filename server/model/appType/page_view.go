@@ -0,0 +1,12 @@
+package appType
+
+// PageViewScope 浏览统计的汇总范围
+type PageViewScope string
+
+// 汇总范围常量
+const (
+	PageViewSite     PageViewScope = "site"     // 全站
+	PageViewArticle  PageViewScope = "article"  // 单篇文章
+	PageViewCategory PageViewScope = "category" // 分类下的全部文章
+	PageViewAuthor   PageViewScope = "author"   // 作者的全部文章
+)
@@ -0,0 +1,32 @@
+package appType
+
+// TokenScope 个人访问令牌的权限范围
+type TokenScope string
+
+// 权限范围常量
+const (
+	ScopeArticlesRead  TokenScope = "articles:read"  // 读取自己的文章（含草稿）
+	ScopeArticlesWrite TokenScope = "articles:write" // 发布、修改、删除文章
+	ScopeMediaWrite    TokenScope = "media:write"    // 上传、管理图片
+	ScopePagesWrite    TokenScope = "pages:write"    // 创建、修改、删除页面
+	ScopeCommentsWrite TokenScope = "comments:write" // 发表、修改、删除评论
+)
+
+// 所有权限范围列表
+var AllTokenScopes = []TokenScope{
+	ScopeArticlesRead,
+	ScopeArticlesWrite,
+	ScopeMediaWrite,
+	ScopePagesWrite,
+	ScopeCommentsWrite,
+}
+
+// 检查权限范围是否有效
+func (s TokenScope) IsValid() bool {
+	for _, scope := range AllTokenScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package appType
+
+// TrashResource 回收站支持的资源类型
+type TrashResource string
+
+// 资源类型常量，与路由中的资源名一致
+const (
+	TrashArticles TrashResource = "articles" // 文章
+	TrashPages    TrashResource = "pages"    // 页面
+	TrashComments TrashResource = "comments" // 评论
+	TrashMedia    TrashResource = "media"    // 图片
+)
+
+// 所有回收站资源类型列表
+var AllTrashResources = []TrashResource{
+	TrashArticles,
+	TrashPages,
+	TrashComments,
+	TrashMedia,
+}
+
+// 检查资源类型是否有效
+func (r TrashResource) IsValid() bool {
+	for _, resource := range AllTrashResources {
+		if r == resource {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditTarget 资源类型对应的审计对象类型
+func (r TrashResource) AuditTarget() AuditTargetType {
+	switch r {
+	case TrashArticles:
+		return AuditTargetArticle
+	case TrashPages:
+		return AuditTargetPage
+	case TrashComments:
+		return AuditTargetComment
+	default:
+		return AuditTargetMedia
+	}
+}
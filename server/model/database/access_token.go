@@ -0,0 +1,46 @@
+package database
+
+import (
+	"server/model/appType"
+	"strings"
+	"time"
+)
+
+// PersonalAccessToken 个人访问令牌，只保存令牌的哈希值
+type PersonalAccessToken struct {
+	BaseModel
+	UserID     uint       `gorm:"index;not null" json:"user_id"`
+	Name       string     `gorm:"size:100;not null" json:"name"`         // 令牌名称，便于用户区分用途
+	Prefix     string     `gorm:"size:16;not null" json:"prefix"`        // 令牌前几位，用于在列表中识别令牌
+	TokenHash  string     `gorm:"size:64;uniqueIndex;not null" json:"-"` // 令牌的 SHA-256 哈希
+	Scopes     string     `gorm:"size:255;not null" json:"-"`            // 权限范围，逗号分隔
+	ExpiresAt  *time.Time `json:"expires_at"`                            // 过期时间，为空表示永不过期
+	LastUsedAt *time.Time `json:"last_used_at"`                          // 最后使用时间
+	LastUsedIP string     `gorm:"size:64" json:"last_used_ip"`           // 最后使用的IP
+}
+
+// ScopeList 解析权限范围
+func (t *PersonalAccessToken) ScopeList() []appType.TokenScope {
+	var scopes []appType.TokenScope
+	for _, s := range strings.Split(t.Scopes, ",") {
+		if s != "" {
+			scopes = append(scopes, appType.TokenScope(s))
+		}
+	}
+	return scopes
+}
+
+// HasScope 是否拥有指定权限
+func (t *PersonalAccessToken) HasScope(scope appType.TokenScope) bool {
+	for _, s := range t.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExpired 是否已过期
+func (t *PersonalAccessToken) IsExpired() bool {
+	return t.ExpiresAt != nil && t.ExpiresAt.Before(time.Now())
+}
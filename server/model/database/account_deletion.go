@@ -0,0 +1,18 @@
+package database
+
+import (
+	"server/model/appType"
+	"time"
+)
+
+// AccountDeletion 账户注销任务，提交后在后台执行
+type AccountDeletion struct {
+	BaseModel
+	UserID      uint                          `gorm:"index;not null" json:"user_id"` // 被注销的用户
+	RequestedBy uint                          `gorm:"not null" json:"requested_by"`  // 发起注销的用户（本人或管理员）
+	Mode        appType.AccountDeletionMode   `gorm:"size:20;not null" json:"mode"`  // 注销方式
+	Status      appType.AccountDeletionStatus `gorm:"size:20;index;not null" json:"status"`
+	Error       string                        `gorm:"type:text" json:"error,omitempty"` // 失败原因
+	StartedAt   *time.Time                    `json:"started_at"`
+	FinishedAt  *time.Time                    `json:"finished_at"`
+}
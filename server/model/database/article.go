@@ -14,6 +14,7 @@ type Article struct {
 	CommentCount        int    `gorm:"default:0" json:"comment_count"`
 	LikeCount           int    `gorm:"default:0" json:"like_count"`
 	FavoriteCount       int    `gorm:"default:0" json:"favorite_count"`
+	DeletedBy           uint   `gorm:"default:0" json:"deleted_by,omitempty"` // 删除操作人ID，用于区分作者自己删除和管理员删除
 
 	// 关联
 	Author   User     `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
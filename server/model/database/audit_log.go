@@ -0,0 +1,21 @@
+package database
+
+import (
+	"server/model/appType"
+	"time"
+)
+
+// AuditLog 审计日志，记录谁在什么时候对什么对象做了什么修改
+// 审计日志只追加不修改，不使用软删除，过期记录由定时任务物理删除
+type AuditLog struct {
+	ID         uint                    `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time               `gorm:"index" json:"created_at"`
+	ActorID    uint                    `gorm:"index" json:"actor_id"`                             // 操作人ID，系统操作为0
+	ActorName  string                  `gorm:"size:50" json:"actor_name"`                         // 操作人用户名，用户被删除后仍可追溯
+	Action     appType.AuditAction     `gorm:"size:32;index" json:"action"`                       // 操作类型
+	TargetType appType.AuditTargetType `gorm:"size:32;index:idx_audit_target" json:"target_type"` // 操作对象类型
+	TargetID   string                  `gorm:"size:64;index:idx_audit_target" json:"target_id"`   // 操作对象ID（用户为UUID）
+	Diff       string                  `gorm:"type:longtext" json:"diff"`                         // 变更内容（JSON），修改时只包含变化的字段
+	IP         string                  `gorm:"size:64" json:"ip"`                                 // 操作人IP
+	UserAgent  string                  `gorm:"size:255" json:"user_agent"`                        // 操作人User-Agent
+}
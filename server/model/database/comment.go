@@ -12,6 +12,7 @@ type Comment struct {
 	ParentID      *uint                     `gorm:"index" json:"parent_id,omitempty"`  // 父评论ID(支持回复)
 	Content       string                    `gorm:"type:text;not null" json:"content"` // 评论内容
 	CommentStatus appType.CommentStatusType `gorm:"type:tinyint;default:0;comment:'评论状态：0-待审核，1-已发布，2-已拒绝'" json:"comment_status"`
+	DeletedBy     uint                      `gorm:"default:0" json:"deleted_by,omitempty"` // 删除操作人ID，用于区分评论作者自己删除和他人删除
 	User          User                      `gorm:"foreignKey:UserID" json:"user,omitempty"`
 	Article       Article                   `gorm:"foreignKey:ArticleID" json:"article,omitempty"`
 	Replies       []Comment                 `gorm:"foreignKey:ParentID" json:"replies,omitempty"`
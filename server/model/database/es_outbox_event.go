@@ -0,0 +1,20 @@
+package database
+
+import (
+	"server/model/appType"
+	"time"
+)
+
+// ESOutboxEvent 待同步到 Elasticsearch 的文章变更，与文章的修改在同一事务中写入，由后台任务投递
+// 投递成功后物理删除，不使用软删除
+type ESOutboxEvent struct {
+	ID            uint                   `gorm:"primarykey" json:"id"`
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+	ArticleID     uint                   `gorm:"index" json:"article_id"`
+	Action        appType.ESOutboxAction `gorm:"size:16" json:"action"`
+	Status        appType.ESOutboxStatus `gorm:"size:16;index:idx_es_outbox_due" json:"status"`
+	Attempts      int                    `json:"attempts"`                                       // 已尝试投递的次数
+	NextAttemptAt time.Time              `gorm:"index:idx_es_outbox_due" json:"next_attempt_at"` // 下次投递时间，投递中的事件会推迟到租约到期
+	LastError     string                 `gorm:"size:1000" json:"last_error"`
+}
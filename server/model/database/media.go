@@ -9,4 +9,5 @@ type Media struct {
   Width       int    `json:"width,omitempty"` // 图片宽度(仅图片类型)
   Height      int    `json:"height,omitempty"` // 图片高度(仅图片类型)
   UserID      uint   `gorm:"index;not null" json:"user_id"` // 上传用户
+  DeletedBy   uint   `gorm:"default:0" json:"deleted_by,omitempty"` // 删除操作人ID
 }
\ No newline at end of file
@@ -0,0 +1,28 @@
+package database
+
+// AllModels 全部数据表模型，数据库迁移和备份恢复都以此为准，新增模型需要加入这里
+func AllModels() []interface{} {
+	return []interface{}{
+		&User{},
+		&Comment{},
+		&Article{},
+		&Like{},
+		&Favorite{},
+		&Category{},
+		&Tag{},
+		&ArticleTag{},
+		&Media{},
+		&Page{},
+		&TwoFactorRecoveryCode{},
+		&UserOAuth{},
+		&PersonalAccessToken{},
+		&AuditLog{},
+		&AccountDeletion{},
+		&Redirect{},
+		&SearchSynonym{},
+		&SearchQueryStat{},
+		&ESOutboxEvent{},
+		&PageViewStat{},
+		&ReferrerStat{},
+	}
+}
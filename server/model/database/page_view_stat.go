@@ -0,0 +1,29 @@
+package database
+
+import (
+	"server/model/appType"
+	"time"
+)
+
+// PageViewStat 每天全站、每篇文章、每个分类和每个作者的浏览量与独立访客数，由定时任务从 Redis 汇总写入
+// 统计数据只累加，不使用软删除，过期记录由定时任务物理删除
+type PageViewStat struct {
+	ID        uint                  `gorm:"primarykey" json:"id"`
+	Date      time.Time             `gorm:"type:date;uniqueIndex:idx_page_view_stat" json:"date"`
+	Scope     appType.PageViewScope `gorm:"size:20;uniqueIndex:idx_page_view_stat" json:"scope"`
+	TargetID  uint                  `gorm:"uniqueIndex:idx_page_view_stat" json:"target_id"` // 文章、分类或作者ID，全站为 0
+	PageViews int64                 `json:"page_views"`                                      // 浏览量
+	Visitors  int64                 `json:"visitors"`                                        // 当天的独立访客数（HyperLogLog 估算）
+}
+
+// ReferrerStat 每天每篇文章按来源网站和 UTM 参数统计的浏览量
+type ReferrerStat struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	Date        time.Time `gorm:"type:date;uniqueIndex:idx_referrer_stat" json:"date"`
+	ArticleID   uint      `gorm:"uniqueIndex:idx_referrer_stat" json:"article_id"`
+	Referrer    string    `gorm:"size:100;uniqueIndex:idx_referrer_stat" json:"referrer"`    // 来源网站的域名，空字符串表示直接访问或站内跳转
+	UTMSource   string    `gorm:"size:50;uniqueIndex:idx_referrer_stat" json:"utm_source"`   // utm_source 参数
+	UTMMedium   string    `gorm:"size:50;uniqueIndex:idx_referrer_stat" json:"utm_medium"`   // utm_medium 参数
+	UTMCampaign string    `gorm:"size:50;uniqueIndex:idx_referrer_stat" json:"utm_campaign"` // utm_campaign 参数
+	Views       int64     `json:"views"`                                                     // 浏览量
+}
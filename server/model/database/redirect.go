@@ -0,0 +1,10 @@
+package database
+
+// Redirect 旧地址重定向，用于迁移博客后保留原有的固定链接
+type Redirect struct {
+	BaseModel
+	FromPath   string `gorm:"size:255;uniqueIndex;not null" json:"from_path"` // 旧地址（站内路径，可带查询参数）
+	ToPath     string `gorm:"size:255;not null" json:"to_path"`               // 新地址
+	StatusCode int    `gorm:"default:301" json:"status_code"`                 // 重定向状态码
+	Source     string `gorm:"size:50" json:"source"`                          // 来源，如 wordpress、hexo
+}
@@ -0,0 +1,18 @@
+package database
+
+import "time"
+
+// SearchQueryStat 每天每个搜索词（及筛选条件）的搜索统计，由定时任务从 Redis 汇总写入
+// 统计数据只累加，不使用软删除，过期记录由定时任务物理删除
+type SearchQueryStat struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	Date        time.Time `gorm:"type:date;uniqueIndex:idx_search_stat" json:"date"`
+	Query       string    `gorm:"size:100;uniqueIndex:idx_search_stat" json:"query"`   // 规范化后的搜索词，空字符串表示只按筛选条件浏览
+	Filters     string    `gorm:"size:255;uniqueIndex:idx_search_stat" json:"filters"` // 筛选条件，如 "category=1,2;tag=go"，点击记录在空筛选条件下
+	Searches    int64     `json:"searches"`                                            // 搜索次数
+	ZeroResults int64     `json:"zero_results"`                                        // 没有结果的次数
+	Results     int64     `json:"results"`                                             // 结果数之和
+	LatencyMs   int64     `json:"latency_ms"`                                          // 耗时之和（毫秒）
+	Fallbacks   int64     `json:"fallbacks"`                                           // 降级到 MySQL 搜索的次数
+	Clicks      int64     `json:"clicks"`                                              // 从搜索结果点击进入文章的次数
+}
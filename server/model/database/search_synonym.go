@@ -0,0 +1,7 @@
+package database
+
+// SearchSynonym 搜索同义词组，组内的词在搜索时互相扩展
+type SearchSynonym struct {
+	BaseModel
+	Terms string `gorm:"size:500;not null" json:"terms"` // 同义词，英文逗号分隔，如 "笔记本,笔记本电脑,laptop"
+}
@@ -0,0 +1,12 @@
+package database
+
+import "time"
+
+// TwoFactorRecoveryCode 两步验证恢复码，每个恢复码只能使用一次
+type TwoFactorRecoveryCode struct {
+	ID        uint       `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	CodeHash  string     `gorm:"size:64;not null" json:"-"` // 恢复码的 SHA-256 哈希
+	UsedAt    *time.Time `json:"used_at"`                   // 使用时间，为空表示未使用
+}
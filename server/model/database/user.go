@@ -22,9 +22,22 @@ type User struct {
 	Role                appType.RoleType  `gorm:"size:20;default:'user'" json:"role"`
 	LoginMethod         appType.LoginType `gorm:"size:20;default:'password'" json:"login_method"`
 	LastLoginAt         *time.Time        `json:"last_login_at"`
+	EmailVerified       *time.Time        `json:"email_verified"`        // 邮箱验证时间，为空表示邮箱未验证
+	TwoFactorSecret     string            `gorm:"size:64" json:"-"`      // TOTP 密钥（base32）
+	TwoFactorEnabledAt  *time.Time        `json:"two_factor_enabled_at"` // 开启两步验证的时间，为空表示未开启
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	u.UUID = uuid.New().String()
 	return nil
 }
+
+// IsEmailVerified 邮箱是否已验证
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerified != nil
+}
+
+// IsTwoFactorEnabled 是否已开启两步验证
+func (u *User) IsTwoFactorEnabled() bool {
+	return u.TwoFactorEnabledAt != nil && u.TwoFactorSecret != ""
+}
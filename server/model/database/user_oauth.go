@@ -0,0 +1,16 @@
+package database
+
+import "time"
+
+// UserOAuth 用户绑定的第三方账户
+type UserOAuth struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_user_oauth_user_provider" json:"user_id"`
+	Provider  string    `gorm:"size:20;not null;uniqueIndex:idx_user_oauth_user_provider;uniqueIndex:idx_user_oauth_provider_subject" json:"provider"` // 提供方，如 qq
+	Subject   string    `gorm:"size:128;not null;uniqueIndex:idx_user_oauth_provider_subject" json:"-"`                                                // 提供方内的唯一标识
+	UnionID   string    `gorm:"size:128" json:"-"`                                                                                                     // 跨应用统一标识
+	Nickname  string    `gorm:"size:100" json:"nickname"`                                                                                              // 第三方昵称
+	Avatar    string    `gorm:"size:255" json:"avatar"`                                                                                                // 第三方头像
+}
@@ -0,0 +1,96 @@
+package es
+
+import (
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types"
+)
+
+// 中文分词方式
+const (
+	TokenizerIK       = "ik"       // analysis-ik 插件，索引时细粒度切分，搜索时智能切分
+	TokenizerSmartCN  = "smartcn"  // analysis-smartcn 插件
+	TokenizerNGram    = "ngram"    // 不依赖插件：中日韩文字按相邻两字切分，同时保留单字
+	TokenizerStandard = "standard" // 标准分词器，中文按单字切分
+)
+
+// 文章索引中定义的分析器
+const (
+	AnalyzerText    = "article_text"    // 文本字段的索引分析器
+	AnalyzerSearch  = "article_search"  // 文本字段的搜索分析器
+	AnalyzerPinyin  = "article_pinyin"  // 拼音子字段的分析器
+	AnalyzerSuggest = "article_suggest" // 自动补全字段的分析器：整个标题或标签作为一个词，按前缀匹配
+	AnalyzerShingle = "article_shingle" // 纠错子字段的分析器：分词后组合相邻的词，供短语建议使用
+
+	PinyinSubField  = "pinyin"
+	SuggestSubField = "suggest"
+	ShingleSubField = "shingle"
+)
+
+// 需要的 Elasticsearch 插件
+const (
+	PluginIK      = "analysis-ik"
+	PluginSmartCN = "analysis-smartcn"
+	PluginPinyin  = "analysis-pinyin"
+)
+
+// Analysis 创建索引时使用的分词配置
+type Analysis struct {
+	Tokenizer string `json:"tokenizer"` // 中文分词方式，见 Tokenizer* 常量
+	Pinyin    bool   `json:"pinyin"`    // 是否为标题、摘要和作者昵称添加拼音子字段（需要 analysis-pinyin 插件）
+}
+
+// GetSettings 获取文章索引的分析设置
+func GetSettings(analysis Analysis) *types.IndexSettings {
+	filters := []string{"cjk_width", "lowercase"}
+	textAnalyzer := types.CustomAnalyzer{Type: "custom", Tokenizer: "standard", Filter: filters}
+	searchAnalyzer := textAnalyzer
+	switch analysis.Tokenizer {
+	case TokenizerIK:
+		textAnalyzer.Tokenizer = "ik_max_word"
+		searchAnalyzer.Tokenizer = "ik_smart"
+	case TokenizerSmartCN:
+		textAnalyzer.Tokenizer = "smartcn_tokenizer"
+		searchAnalyzer.Tokenizer = "smartcn_tokenizer"
+	case TokenizerNGram:
+		textAnalyzer.Filter = append(filters, "article_cjk_bigram")
+		searchAnalyzer.Filter = textAnalyzer.Filter
+	}
+
+	settings := &types.IndexSettingsAnalysis{
+		Analyzer: map[string]types.Analyzer{
+			AnalyzerText:    textAnalyzer,
+			AnalyzerSearch:  searchAnalyzer,
+			AnalyzerSuggest: types.CustomAnalyzer{Type: "custom", Tokenizer: "keyword", Filter: filters},
+			AnalyzerShingle: types.CustomAnalyzer{Type: "custom", Tokenizer: searchAnalyzer.Tokenizer, Filter: append(filters, "article_shingle")},
+		},
+		Filter: map[string]types.TokenFilter{
+			"article_shingle": map[string]interface{}{
+				"type":             "shingle",
+				"min_shingle_size": 2,
+				"max_shingle_size": 3,
+			},
+		},
+		Tokenizer: map[string]types.Tokenizer{},
+	}
+	if analysis.Tokenizer == TokenizerNGram {
+		// 保留单字，单字查询也能命中
+		settings.Filter["article_cjk_bigram"] = map[string]interface{}{
+			"type":            "cjk_bigram",
+			"output_unigrams": true,
+		}
+	}
+	if analysis.Pinyin {
+		settings.Tokenizer["article_pinyin"] = map[string]interface{}{
+			"type":                         "pinyin",
+			"keep_full_pinyin":             true,
+			"keep_joined_full_pinyin":      false,
+			"keep_first_letter":            false,
+			"keep_original":                false,
+			"keep_none_chinese":            true,
+			"none_chinese_pinyin_tokenize": true,
+			"lowercase":                    true,
+			"remove_duplicated_term":       true,
+		}
+		settings.Analyzer[AnalyzerPinyin] = types.CustomAnalyzer{Type: "custom", Tokenizer: "article_pinyin"}
+	}
+	return &types.IndexSettings{Analysis: settings}
+}
@@ -34,24 +34,46 @@ func (a *ArticleES) IndexName() string {
 	return "articles"
 }
 
-// GetMapping 获取文章索引映射
-func GetMapping() *types.TypeMapping {
+// GetMapping 获取文章索引映射，文本字段使用 analysis 对应的分析器
+func GetMapping(analysis Analysis) *types.TypeMapping {
+	textAnalyzer, searchAnalyzer := AnalyzerText, AnalyzerSearch
+	text := func(fields map[string]types.Property) types.TextProperty {
+		return types.TextProperty{Analyzer: &textAnalyzer, SearchAnalyzer: &searchAnalyzer, Fields: fields}
+	}
+	// 拼音子字段，用于拼音输入匹配中文标题
+	var pinyin map[string]types.Property
+	if analysis.Pinyin {
+		pinyinAnalyzer := AnalyzerPinyin
+		pinyin = map[string]types.Property{
+			PinyinSubField: types.TextProperty{Analyzer: &pinyinAnalyzer},
+		}
+	}
+
+	// 标题和标签的自动补全字段，标题的纠错子字段
+	suggestAnalyzer, shingleAnalyzer := AnalyzerSuggest, AnalyzerShingle
+	completion := types.CompletionProperty{Analyzer: &suggestAnalyzer}
+
+	title := text(map[string]types.Property{
+		"keyword":       types.KeywordProperty{},
+		SuggestSubField: completion,
+		ShingleSubField: types.TextProperty{Analyzer: &shingleAnalyzer},
+	})
+	if analysis.Pinyin {
+		title.Fields[PinyinSubField] = pinyin[PinyinSubField]
+	}
+
 	return &types.TypeMapping{
 		Properties: map[string]types.Property{
-			"id": types.LongNumberProperty{},
-			"title": types.TextProperty{
-				Fields: map[string]types.Property{
-					"keyword": types.KeywordProperty{},
-				},
-			},
-			"content":         types.TextProperty{},
-			"summary":         types.TextProperty{},
+			"id":              types.LongNumberProperty{},
+			"title":           title,
+			"content":         text(nil),
+			"summary":         text(pinyin),
 			"cover_image":     types.KeywordProperty{},
-			"tags":            types.KeywordProperty{},
+			"tags":            types.KeywordProperty{Fields: map[string]types.Property{SuggestSubField: completion}},
 			"category_id":     types.IntegerNumberProperty{},
 			"user_id":         types.IntegerNumberProperty{},
-			"author_name":     types.TextProperty{}, // 添加作者名字字段映射
-			"author_nickname": types.TextProperty{}, // 添加作者昵称字段映射
+			"author_name":     text(nil),    // 添加作者名字字段映射
+			"author_nickname": text(pinyin), // 添加作者昵称字段映射
 			"author_avatar":   types.KeywordProperty{},
 			"status":          types.IntegerNumberProperty{},
 			"view_count":      types.IntegerNumberProperty{},
@@ -1,10 +1,38 @@
 package es
 
-// ArticleSearchResult 文章搜索结果
+// ArticleSearchResult 文章搜索结果，文章不包含正文，正文以高亮片段的形式返回
 type ArticleSearchResult struct {
-	Articles  []ArticleES `json:"articles"`
-	Total     int64       `json:"total"`
-	Page      int         `json:"page"`
-	Size      int         `json:"size"`
-	TotalPage int         `json:"total_page"`
+	Articles   []ArticleES                 `json:"articles"`
+	Highlights map[uint64]ArticleHighlight `json:"highlights"` // 文章ID -> 高亮片段，没有关键词或没有命中时不包含该文章
+	Facets     *SearchFacets               `json:"facets"`
+	Total      int64                       `json:"total"`
+	Page       int                         `json:"page"`
+	Size       int                         `json:"size"`
+	TotalPage  int                         `json:"total_page"`
+	Suggestion string                      `json:"suggestion,omitempty"` // 没有搜索结果时的纠错建议（“您是不是要找”）
+	SearchID   string                      `json:"search_id,omitempty"`  // 有关键词时本次搜索的ID，记录搜索结果点击时回传
+}
+
+// ArticleHighlight 单篇文章的高亮片段，内容已做 HTML 转义，命中的关键词用配置的标签包裹
+type ArticleHighlight struct {
+	Title   string   `json:"title,omitempty"`   // 高亮后的完整标题
+	Summary string   `json:"summary,omitempty"` // 高亮后的完整摘要
+	Content []string `json:"content,omitempty"` // 正文中包含关键词的片段
+}
+
+// SearchFacets 搜索结果的分面计数，用于筛选侧栏；每个分面的计数不受该分面自身筛选条件的影响
+type SearchFacets struct {
+	Categories []FacetBucket `json:"categories"`
+	Tags       []FacetBucket `json:"tags"`
+	Authors    []FacetBucket `json:"authors"`
+	Years      []FacetBucket `json:"years"`  // 发布年份，如 "2024"
+	Months     []FacetBucket `json:"months"` // 发布月份，如 "2024-05"
+}
+
+// FacetBucket 分面中的一个选项
+type FacetBucket struct {
+	Key      string `json:"key"`   // 筛选时使用的值：分类ID、标签名、作者ID、年份或月份
+	Label    string `json:"label"` // 显示名称
+	Count    int64  `json:"count"`
+	Selected bool   `json:"selected"`
 }
@@ -0,0 +1,15 @@
+package es
+
+// 自动补全建议的来源
+const (
+	SuggestTypeTitle = "title" // 文章标题
+	SuggestTypeTag   = "tag"   // 标签
+	SuggestTypeQuery = "query" // 热门搜索词
+)
+
+// ArticleSuggestion 搜索框的一条自动补全建议
+type ArticleSuggestion struct {
+	Text      string `json:"text"`
+	Type      string `json:"type"`                 // 见 SuggestType* 常量
+	ArticleID uint64 `json:"article_id,omitempty"` // 标题建议对应的文章
+}
@@ -0,0 +1,8 @@
+package request
+
+// AccessTokenCreateRequest 创建个人访问令牌请求
+type AccessTokenCreateRequest struct {
+	Name          string   `json:"name" validate:"required,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,required"` // 权限范围，如 articles:write、media:write
+	ExpiresInDays int      `json:"expires_in_days" validate:"min=0,max=3650"`      // 有效天数，0 表示永不过期
+}
@@ -73,4 +73,34 @@ type SearchArticleRequest struct {
 	Tag        string `form:"tag" binding:"omitempty" comment:"标签筛选"`
 	Sort       string `form:"sort" binding:"omitempty,oneof=time view comment like"`
 	Order      string `form:"order" binding:"omitempty,oneof=asc desc"`
+
+	// 分面筛选，同一参数可重复传入多个值（如 tags=go&tags=web），同一分面内满足任意一个即可
+	CategoryIDs []uint   `form:"category_ids" binding:"omitempty,max=20" comment:"分类ID多选"`
+	Tags        []string `form:"tags" binding:"omitempty,max=20" comment:"标签多选"`
+	AuthorIDs   []uint   `form:"author_ids" binding:"omitempty,max=20" comment:"作者ID多选"`
+	DateFrom    string   `form:"date_from" binding:"omitempty,datetime=2006-01-02" comment:"发布日期起始（含）"`
+	DateTo      string   `form:"date_to" binding:"omitempty,datetime=2006-01-02" comment:"发布日期截止（含）"`
+}
+
+// SuggestArticleRequest 搜索框自动补全请求，前缀为空时返回热门搜索词
+type SuggestArticleRequest struct {
+	Prefix string `form:"prefix" binding:"omitempty,max=50" comment:"输入的前缀"`
+	Size   int    `form:"size" binding:"omitempty,min=1,max=20" comment:"建议条数"`
+}
+
+// SearchClickRequest 记录从搜索结果点击进入文章
+type SearchClickRequest struct {
+	SearchID  string `json:"search_id" binding:"required,max=64" comment:"搜索接口返回的搜索ID"`
+	ArticleID uint   `json:"article_id" binding:"required" comment:"点击的文章ID"`
+}
+
+// SearchAnalyticsRequest 搜索统计报表请求
+type SearchAnalyticsRequest struct {
+	Days int `form:"days" binding:"omitempty,min=1,max=365" comment:"统计最近几天，默认7天"`
+	Size int `form:"size" binding:"omitempty,min=1,max=100" comment:"每个榜单的条数，默认20"`
+}
+
+// ESOutboxRetryRequest 重试失败的ES同步事件
+type ESOutboxRetryRequest struct {
+	IDs []uint `json:"ids" binding:"omitempty,max=1000" comment:"事件ID，为空时重试全部失败事件"`
 }
@@ -0,0 +1,16 @@
+package request
+
+import "time"
+
+// AuditLogQueryRequest 审计日志查询请求
+type AuditLogQueryRequest struct {
+	ActorID    uint      `form:"actor_id"`                                // 操作人ID
+	Action     string    `form:"action"`                                  // 操作类型
+	TargetType string    `form:"target_type"`                             // 操作对象类型
+	TargetID   string    `form:"target_id"`                               // 操作对象ID
+	StartDate  time.Time `form:"start_date" time_format:"2006-01-02"`     // 开始日期（含）
+	EndDate    time.Time `form:"end_date" time_format:"2006-01-02"`       // 结束日期（含）
+	Keyword    string    `form:"keyword"`                                 // 在操作人用户名和变更内容中模糊搜索
+	Page       int       `form:"page" validate:"omitempty,min=1"`         // 页码
+	Size       int       `form:"size" validate:"omitempty,min=1,max=100"` // 每页条数
+}
@@ -0,0 +1,18 @@
+package request
+
+// PageViewBeaconRequest 文章页面加载后发送的浏览信标
+type PageViewBeaconRequest struct {
+	ArticleID   uint   `json:"article_id" binding:"required" comment:"文章ID"`
+	Referrer    string `json:"referrer" binding:"max=2000" comment:"来源页面地址（document.referrer）"`
+	UTMSource   string `json:"utm_source" binding:"max=200" comment:"utm_source 参数"`
+	UTMMedium   string `json:"utm_medium" binding:"max=200" comment:"utm_medium 参数"`
+	UTMCampaign string `json:"utm_campaign" binding:"max=200" comment:"utm_campaign 参数"`
+}
+
+// PageViewReportRequest 浏览统计报表请求
+type PageViewReportRequest struct {
+	Scope string `form:"scope" binding:"omitempty,oneof=site article category author" comment:"汇总范围，默认全站"`
+	ID    uint   `form:"id" comment:"文章、分类或作者ID，全站时不需要"`
+	Days  int    `form:"days" binding:"omitempty,min=1,max=365" comment:"统计最近几天，默认30天"`
+	Size  int    `form:"size" binding:"omitempty,min=1,max=100" comment:"每个榜单的条数，默认10"`
+}
@@ -0,0 +1,6 @@
+package request
+
+// SearchSynonymRequest 新建或修改同义词组请求
+type SearchSynonymRequest struct {
+	Terms []string `json:"terms" binding:"required,min=2,max=20,dive,required,max=50"`
+}
@@ -11,8 +11,8 @@ type RegisterRequest struct {
 	Password    string `json:"password" validate:"required,min=6,max=20"`
 	Nickname    string `json:"nickname" validate:"max=50"`
 	Email       string `json:"email" validate:"required,email"`
-	CaptchaID   string `json:"captcha_id" validate:"required"`
-	CaptchaCode string `json:"captcha_code" validate:"required,len=6"`
+	CaptchaID   string `json:"captcha_id" validate:"omitempty"`          // 风险评估要求验证时必填
+	CaptchaCode string `json:"captcha_code" validate:"omitempty,max=10"` // 风险评估要求验证时必填
 	EmailCode   string `json:"email_code" validate:"required,len=6"`
 }
 
@@ -20,8 +20,25 @@ type RegisterRequest struct {
 type LoginRequest struct {
 	Username    string `json:"username" validate:"required"`
 	Password    string `json:"password" validate:"required"`
-	CaptchaID   string `json:"captcha_id" validate:"required"`
-	CaptchaCode string `json:"captcha_code" validate:"required,len=6"`
+	CaptchaID   string `json:"captcha_id" validate:"omitempty"`          // 风险评估要求验证时必填
+	CaptchaCode string `json:"captcha_code" validate:"omitempty,max=10"` // 风险评估要求验证时必填
+}
+
+// TwoFactorLoginRequest 登录第二步：提交两步验证码
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required,max=20"` // TOTP验证码或恢复码
+}
+
+// TwoFactorCodeRequest 提交两步验证码
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required,max=20"`
+}
+
+// TwoFactorDisableRequest 关闭两步验证请求
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required,max=20"` // TOTP验证码或恢复码
 }
 
 // UserUpdateRequest 用户更新信息请求
@@ -44,6 +61,15 @@ type ChangePasswordRequest struct {
 	ConfirmPassword string `json:"confirm_password" validate:"required,min=6,max=20"`
 }
 
+// DeleteUserRequest 注销账户请求
+type DeleteUserRequest struct {
+	ID          uint   `json:"id" validate:"required"`
+	Mode        string `json:"mode" validate:"omitempty,oneof=anonymize delete"` // 内容处理方式，默认 anonymize（保留内容、抹去个人信息）
+	Password    string `json:"password" validate:"omitempty,max=20"`             // 本人注销时密码登录的账户必填
+	Code        string `json:"code" validate:"omitempty,max=32"`                 // 两步验证码或恢复码，开启两步验证时必填
+	ReauthToken string `json:"reauth_token" validate:"omitempty,max=128"`        // 第三方登录创建且未开启两步验证的账户必填，通过 /oauth/:provider/reauth 获取
+}
+
 // UserListRequest 用户列表查询请求
 type UserListRequest struct {
 	Page      int    `form:"page" validate:"min=1"`
@@ -59,8 +85,21 @@ type UserListRequest struct {
 // ForgotPasswordRequest 忘记密码请求
 type ForgotPasswordRequest struct {
 	Email     string `json:"email" binding:"required,email"`
-	CaptchaID string `json:"captcha_id" binding:"required"`
-	Captcha   string `json:"captcha" binding:"required,len=6"`
+	CaptchaID string `json:"captcha_id"`                         // 风险评估要求验证时必填
+	Captcha   string `json:"captcha" binding:"omitempty,max=10"` // 风险评估要求验证时必填
+}
+
+// EmailChangeRequest 申请修改邮箱请求
+type EmailChangeRequest struct {
+	NewEmail    string `json:"new_email" validate:"required,email"`
+	Password    string `json:"password"`     // 密码登录的账户必填
+	Code        string `json:"code"`         // 两步验证码或恢复码，开启两步验证时必填
+	ReauthToken string `json:"reauth_token"` // 第三方登录创建且未开启两步验证的账户必填，通过 /oauth/:provider/reauth 获取
+}
+
+// EmailCodeRequest 提交邮箱验证码请求
+type EmailCodeRequest struct {
+	Code string `json:"code" validate:"required,len=6"`
 }
 
 // ResetPasswordRequest 重置密码请求
@@ -0,0 +1,15 @@
+package response
+
+import (
+	"server/model/database"
+	"time"
+)
+
+// ESOutboxStatus 文章变更事件的积压情况
+type ESOutboxStatus struct {
+	Pending         int64                    `json:"pending"`           // 等待投递的事件数
+	Failed          int64                    `json:"failed"`            // 超过重试次数的事件数
+	OldestPendingAt *time.Time               `json:"oldest_pending_at"` // 最早的待投递事件的创建时间
+	LagSeconds      int64                    `json:"lag_seconds"`       // 同步延迟：最早的待投递事件已等待的秒数
+	FailedEvents    []database.ESOutboxEvent `json:"failed_events"`     // 最近失败的事件
+}
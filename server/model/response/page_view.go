@@ -0,0 +1,46 @@
+package response
+
+import "server/model/appType"
+
+// PageViewReport 浏览统计报表
+type PageViewReport struct {
+	Scope       appType.PageViewScope `json:"scope"`
+	TargetID    uint                  `json:"target_id,omitempty"`
+	From        string                `json:"from"`         // 统计起始日期（含）
+	To          string                `json:"to"`           // 统计截止日期（含）
+	PageViews   int64                 `json:"page_views"`   // 浏览量合计
+	VisitorDays int64                 `json:"visitor_days"` // 每天独立访客数之和（访客·天），访客标识每天轮换，无法跨日去重
+	Series      []PageViewPoint       `json:"series"`       // 每天的浏览量和独立访客数，没有数据的日期为 0
+	TopArticles []ArticleViewRank     `json:"top_articles"` // 浏览量最多的文章，单篇文章的报表为空
+	Referrers   []ReferrerRank        `json:"referrers"`    // 浏览量最多的来源网站
+	Campaigns   []CampaignRank        `json:"campaigns"`    // 浏览量最多的 UTM 推广活动
+}
+
+// PageViewPoint 一天的浏览统计
+type PageViewPoint struct {
+	Date      string `json:"date"`
+	PageViews int64  `json:"page_views"`
+	Visitors  int64  `json:"visitors"`
+}
+
+// ArticleViewRank 文章浏览量排行
+type ArticleViewRank struct {
+	ArticleID   uint   `json:"article_id"`
+	Title       string `json:"title"`
+	PageViews   int64  `json:"page_views"`
+	VisitorDays int64  `json:"visitor_days"` // 每天独立访客数之和，同一访客在不同日期分别计数
+}
+
+// ReferrerRank 来源网站排行，Referrer 为空表示直接访问或站内跳转
+type ReferrerRank struct {
+	Referrer string `json:"referrer"`
+	Views    int64  `json:"views"`
+}
+
+// CampaignRank UTM 推广活动排行
+type CampaignRank struct {
+	Source   string `json:"utm_source"`
+	Medium   string `json:"utm_medium"`
+	Campaign string `json:"utm_campaign"`
+	Views    int64  `json:"views"`
+}
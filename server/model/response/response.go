@@ -23,9 +23,10 @@ type ImageResponse struct {
 
 // 状态码常量
 const (
-	ERROR   = 7
-	SUCCESS = 0
-	NO_AUTH = 401
+	ERROR             = 7
+	SUCCESS           = 0
+	NO_AUTH           = 401
+	TOO_MANY_REQUESTS = 429
 )
 
 // 通用响应函数
@@ -79,3 +80,8 @@ func Forbidden(message string, c *gin.Context) {
 		Msg:  message,
 	})
 }
+
+// 限流相关响应
+func TooManyRequests(message string, c *gin.Context) {
+	Result(TOO_MANY_REQUESTS, map[string]interface{}{}, message, http.StatusTooManyRequests, c)
+}
@@ -0,0 +1,22 @@
+package response
+
+// SearchAnalyticsReport 搜索统计报表
+type SearchAnalyticsReport struct {
+	From              string              `json:"from"`                // 统计起始日期（含）
+	To                string              `json:"to"`                  // 统计截止日期（含）
+	Summary           SearchQueryReport   `json:"summary"`             // 全部有搜索词的搜索
+	TopQueries        []SearchQueryReport `json:"top_queries"`         // 搜索次数最多的搜索词
+	ZeroResultQueries []SearchQueryReport `json:"zero_result_queries"` // 没有结果次数最多的搜索词
+}
+
+// SearchQueryReport 一个搜索词（或全部搜索）的统计
+type SearchQueryReport struct {
+	Query        string  `json:"query,omitempty"`
+	Searches     int64   `json:"searches"`
+	ZeroResults  int64   `json:"zero_results"`
+	Clicks       int64   `json:"clicks"`
+	ClickRate    float64 `json:"click_rate"`     // 点击率：点击次数 / 搜索次数
+	AvgResults   float64 `json:"avg_results"`    // 平均结果数
+	AvgLatencyMs float64 `json:"avg_latency_ms"` // 平均耗时（毫秒）
+	FallbackRate float64 `json:"fallback_rate"`  // 降级到 MySQL 搜索的比例
+}
@@ -20,6 +20,8 @@ type UserResponse struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	LastLoginAt *time.Time `json:"last_login_at"`
+	EmailVerified *time.Time `json:"email_verified"`
+	TwoFactorEnabled bool `json:"two_factor_enabled"`
 }
 
 // LoginResponse 登录响应
@@ -28,6 +30,26 @@ type LoginResponse struct {
 	Token string       `json:"token"`
 }
 
+// TwoFactorChallengeResponse 密码校验通过但还需完成两步验证时的登录响应
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`       // 需要提交两步验证码
+	SetupRequired     bool   `json:"two_factor_setup_required"` // 角色要求开启两步验证但尚未开启
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int    `json:"expires_in"` // 挑战令牌有效期（秒）
+}
+
+// TwoFactorSetupResponse 获取两步验证密钥的响应
+type TwoFactorSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth:// 地址，前端生成二维码供验证器应用扫描
+}
+
+// TwoFactorEnableResponse 开启两步验证的响应
+type TwoFactorEnableResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+	Token         string   `json:"token,omitempty"` // 使用受限令牌开启时返回正式访问令牌
+}
+
 // 转换数据库用户模型为响应模型
 func ToUserResponse(user database.User) UserResponse {
 	return UserResponse{
@@ -43,6 +65,8 @@ func ToUserResponse(user database.User) UserResponse {
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
 		LastLoginAt: user.LastLoginAt,
+		EmailVerified: user.EmailVerified,
+		TwoFactorEnabled: user.IsTwoFactorEnabled(),
 	}
 }
 
@@ -54,3 +78,30 @@ type UserListResponse struct {
 	Page  int            `json:"page"`
 	Size  int            `json:"size"`
 }
+
+// AccessTokenResponse 个人访问令牌信息
+type AccessTokenResponse struct {
+	ID         uint                 `json:"id"`
+	Name       string               `json:"name"`
+	Prefix     string               `json:"prefix"`
+	Scopes     []appType.TokenScope `json:"scopes"`
+	ExpiresAt  *time.Time           `json:"expires_at"`
+	LastUsedAt *time.Time           `json:"last_used_at"`
+	LastUsedIP string               `json:"last_used_ip"`
+	CreatedAt  time.Time            `json:"created_at"`
+	Token      string               `json:"token,omitempty"` // 明文令牌，只在创建时返回
+}
+
+// ToAccessTokenResponse 转换个人访问令牌为响应模型
+func ToAccessTokenResponse(token database.PersonalAccessToken) AccessTokenResponse {
+	return AccessTokenResponse{
+		ID:         token.ID,
+		Name:       token.Name,
+		Prefix:     token.Prefix,
+		Scopes:     token.ScopeList(),
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		LastUsedIP: token.LastUsedIP,
+		CreatedAt:  token.CreatedAt,
+	}
+}
@@ -0,0 +1,19 @@
+package routers
+
+import (
+	"server/api"
+	"server/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessTokenRouter 注册个人访问令牌相关路由，令牌只能通过登录会话管理
+func AccessTokenRouter(router *gin.RouterGroup) {
+	accessTokenApi := api.AccessTokenApi{}
+	tokenRouter := router.Group("tokens").Use(middleware.InitJWT())
+	{
+		tokenRouter.GET("", accessTokenApi.ListTokens)         // 访问令牌列表
+		tokenRouter.POST("", accessTokenApi.CreateToken)       // 创建访问令牌
+		tokenRouter.DELETE("/:id", accessTokenApi.RevokeToken) // 撤销访问令牌
+	}
+}
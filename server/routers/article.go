@@ -14,6 +14,7 @@ func ArticleRouter(Router *gin.RouterGroup) {
 		// 公开路由
 		articleRouter.GET("", (&api.ArticleApi{}).GetArticleList)
 		articleRouter.GET("/search", (&api.ArticleApi{}).SearchArticles)
+		articleRouter.GET("/suggest", (&api.ArticleApi{}).SuggestArticles)
 		articleRouter.GET("/stats", (&api.ArticleApi{}).GetWebsiteStats)
 		articleRouter.GET("/:id/related", (&api.ArticleApi{}).GetRelatedArticles)
 		articleRouter.GET("/user/:user_id", (&api.ArticleApi{}).GetArticlesByUserID) // 根据用户ID获取文章列表
@@ -23,13 +24,15 @@ func ArticleRouter(Router *gin.RouterGroup) {
 		{
 			authArticleRouter.GET("/:id", (&api.ArticleApi{}).GetArticle)
 			authArticleRouter.GET("/my", (&api.ArticleApi{}).GetUserArticles)
-			authArticleRouter.POST("", (&api.ArticleApi{}).CreateArticle)
+			authArticleRouter.POST("", middleware.RequireVerifiedEmail(middleware.PostRequiresVerifiedEmail), (&api.ArticleApi{}).CreateArticle)
 			authArticleRouter.PUT("/:id", (&api.ArticleApi{}).UpdateArticle)
 			authArticleRouter.DELETE("/:id", (&api.ArticleApi{}).DeleteArticle)
 			authArticleRouter.POST("/like", (&api.ArticleApi{}).ToggleLike)
 			authArticleRouter.POST("/favorite", (&api.ArticleApi{}).ToggleFavorite)
 			authArticleRouter.GET("/favorites", (&api.ArticleApi{}).GetUserFavorites)
 			authArticleRouter.POST("/sync-es", (&api.ArticleApi{}).SyncAllArticlesToES)
+			authArticleRouter.GET("/markdown/export", (&api.ArticleApi{}).ExportArticlesMarkdown)
+			authArticleRouter.POST("/markdown/import", (&api.ArticleApi{}).ImportArticlesMarkdown)
 		}
 	}
 }
@@ -0,0 +1,18 @@
+package routers
+
+import (
+	"server/api"
+	"server/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditLogRouter 注册审计日志路由，仅管理员可访问
+func AuditLogRouter(router *gin.RouterGroup) {
+	auditLogApi := api.AuditLogApi{}
+	auditRouter := router.Group("audit-logs").Use(middleware.InitJWT())
+	{
+		auditRouter.GET("", auditLogApi.GetAuditLogList)        // 查询审计日志
+		auditRouter.GET("/export", auditLogApi.ExportAuditLogs) // 导出审计日志（CSV）
+	}
+}
@@ -17,11 +17,12 @@ func CommentRouter(router *gin.RouterGroup) {
 
 		// 需认证路由
 		authRouter := commentRouter.Use(middleware.InitJWT())
+		verified := middleware.RequireVerifiedEmail(middleware.CommentRequiresVerifiedEmail)
 		{
-			authRouter.POST("", (&api.CommentApi{}).CreateComment)            // 创建评论
-			authRouter.PUT("/:id", (&api.CommentApi{}).UpdateComment)         // 更新评论
-			authRouter.DELETE("/:id", (&api.CommentApi{}).DeleteComment)      // 删除评论
-			authRouter.POST("/:id/reply", (&api.CommentApi{}).ReplyToComment) // 回复评论
+			authRouter.POST("", verified, (&api.CommentApi{}).CreateComment)            // 创建评论
+			authRouter.PUT("/:id", (&api.CommentApi{}).UpdateComment)                   // 更新评论
+			authRouter.DELETE("/:id", (&api.CommentApi{}).DeleteComment)                // 删除评论
+			authRouter.POST("/:id/reply", verified, (&api.CommentApi{}).ReplyToComment) // 回复评论
 		}
 	}
 }
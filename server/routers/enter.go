@@ -1,6 +1,7 @@
 package routers
 
 import (
+	"server/api"
 	"server/middleware"
 
 	"github.com/gin-gonic/gin"
@@ -32,7 +33,32 @@ func SetupRouter() *gin.Engine {
 		CategoryRouter(publicGroup)
 		// 注册标签路由
 		TagRouter(publicGroup)
+		// 注册第三方登录路由
+		OAuthRouter(publicGroup)
+		// 注册个人访问令牌路由
+		AccessTokenRouter(publicGroup)
+		// 注册审计日志路由
+		AuditLogRouter(publicGroup)
+		// 注册回收站路由
+		TrashRouter(publicGroup)
+		// 注册博客导入路由
+		ImportRouter(publicGroup)
+		// 注册旧地址重定向路由
+		RedirectRouter(publicGroup)
+		// 注册搜索同义词路由
+		SearchSynonymRouter(publicGroup)
+		// 注册搜索统计路由
+		SearchAnalyticsRouter(publicGroup)
+		// 注册ES同步事件队列路由
+		ESOutboxRouter(publicGroup)
+		// 注册ES索引一致性检查路由
+		ESConsistencyRouter(publicGroup)
+		// 注册浏览统计路由
+		PageViewRouter(publicGroup)
 	}
 
+	// 未匹配的请求尝试按迁移前的旧地址重定向
+	router.NoRoute((&api.RedirectApi{}).HandleNoRoute)
+
 	return router
 }
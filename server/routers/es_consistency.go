@@ -0,0 +1,18 @@
+package routers
+
+import (
+	"server/api"
+	"server/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ESConsistencyRouter 注册ES索引一致性检查路由，仅管理员可访问
+func ESConsistencyRouter(router *gin.RouterGroup) {
+	consistencyApi := api.ESConsistencyApi{}
+	consistencyRouter := router.Group("es/consistency").Use(middleware.InitJWT())
+	{
+		consistencyRouter.GET("", consistencyApi.CheckConsistency)          // 检查索引与数据库的差异
+		consistencyRouter.POST("/repair", consistencyApi.RepairConsistency) // 修复差异
+	}
+}
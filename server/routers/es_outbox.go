@@ -0,0 +1,18 @@
+package routers
+
+import (
+	"server/api"
+	"server/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ESOutboxRouter 注册ES同步事件队列路由，仅管理员可访问
+func ESOutboxRouter(router *gin.RouterGroup) {
+	outboxApi := api.ESOutboxApi{}
+	outboxRouter := router.Group("es/outbox").Use(middleware.InitJWT())
+	{
+		outboxRouter.GET("", outboxApi.GetOutboxStatus)          // 同步延迟和失败事件
+		outboxRouter.POST("/retry", outboxApi.RetryOutboxEvents) // 重试失败事件
+	}
+}
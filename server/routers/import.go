@@ -0,0 +1,17 @@
+package routers
+
+import (
+	"server/api"
+	"server/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportRouter 从其他博客系统导入内容的路由（管理员）
+func ImportRouter(Router *gin.RouterGroup) {
+	importRouter := Router.Group("import").Use(middleware.InitJWT())
+	{
+		importRouter.POST("/wordpress", (&api.ImportApi{}).ImportWordPress)
+		importRouter.POST("/hexo", (&api.ImportApi{}).ImportHexo)
+	}
+}
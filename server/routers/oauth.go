@@ -0,0 +1,29 @@
+package routers
+
+import (
+	"server/api"
+	"server/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OAuthRouter 注册第三方登录相关路由
+func OAuthRouter(router *gin.RouterGroup) {
+	oauthApi := api.OAuthApi{}
+	// 公开路由
+	publicRouter := router.Group("oauth")
+	{
+		publicRouter.GET("providers", oauthApi.GetProviders)                                       // 已启用的登录方式
+		publicRouter.GET(":provider/authorize", middleware.RateLimit("login"), oauthApi.Authorize) // 获取授权跳转地址
+		publicRouter.GET(":provider/callback", middleware.RateLimit("login"), oauthApi.Callback)   // 授权回调
+	}
+
+	// 需认证路由
+	authRouter := router.Group("oauth").Use(middleware.InitJWT())
+	{
+		authRouter.GET("links", oauthApi.GetLinks)           // 已绑定的第三方账户
+		authRouter.POST(":provider/link", oauthApi.Link)     // 绑定第三方账户
+		authRouter.POST(":provider/reauth", oauthApi.Reauth) // 通过第三方账户重新验证身份
+		authRouter.DELETE(":provider", oauthApi.Unlink)      // 解绑第三方账户
+	}
+}
@@ -0,0 +1,18 @@
+package routers
+
+import (
+	"server/api"
+	"server/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PageViewRouter 注册浏览统计路由：浏览信标公开，统计报表仅管理员可访问
+func PageViewRouter(router *gin.RouterGroup) {
+	pageViewApi := api.PageViewApi{}
+	analyticsRouter := router.Group("analytics")
+	{
+		analyticsRouter.POST("/beacon", middleware.RateLimit("beacon"), pageViewApi.RecordBeacon) // 文章浏览信标
+		analyticsRouter.GET("/page-views", middleware.InitJWT(), pageViewApi.GetPageViewReport)   // 浏览统计报表
+	}
+}
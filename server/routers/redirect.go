@@ -0,0 +1,15 @@
+package routers
+
+import (
+	"server/api"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RedirectRouter 旧地址重定向路由
+func RedirectRouter(Router *gin.RouterGroup) {
+	redirectRouter := Router.Group("redirects")
+	{
+		redirectRouter.GET("/resolve", (&api.RedirectApi{}).ResolveRedirect)
+	}
+}
@@ -0,0 +1,18 @@
+package routers
+
+import (
+	"server/api"
+	"server/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchAnalyticsRouter 注册搜索统计路由：点击记录公开，统计报表仅管理员可访问
+func SearchAnalyticsRouter(router *gin.RouterGroup) {
+	analyticsApi := api.SearchAnalyticsApi{}
+	searchRouter := router.Group("search")
+	{
+		searchRouter.POST("/click", analyticsApi.RecordSearchClick)                           // 记录搜索结果点击
+		searchRouter.GET("/analytics", middleware.InitJWT(), analyticsApi.GetSearchAnalytics) // 搜索统计报表
+	}
+}
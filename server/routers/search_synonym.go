@@ -0,0 +1,20 @@
+package routers
+
+import (
+	"server/api"
+	"server/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchSynonymRouter 注册搜索同义词管理路由，仅管理员可访问
+func SearchSynonymRouter(router *gin.RouterGroup) {
+	synonymApi := api.SearchSynonymApi{}
+	synonymRouter := router.Group("search/synonyms").Use(middleware.InitJWT())
+	{
+		synonymRouter.GET("", synonymApi.ListSearchSynonyms)         // 获取同义词组
+		synonymRouter.POST("", synonymApi.CreateSearchSynonym)       // 新建同义词组
+		synonymRouter.PUT("/:id", synonymApi.UpdateSearchSynonym)    // 修改同义词组
+		synonymRouter.DELETE("/:id", synonymApi.DeleteSearchSynonym) // 删除同义词组
+	}
+}
@@ -0,0 +1,19 @@
+package routers
+
+import (
+	"server/api"
+	"server/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TrashRouter 注册回收站路由，resource 可选 articles、pages、comments、media
+func TrashRouter(router *gin.RouterGroup) {
+	trashApi := api.TrashApi{}
+	trashRouter := router.Group("trash").Use(middleware.InitJWT())
+	{
+		trashRouter.GET("/:resource", trashApi.ListTrash)                 // 回收站列表
+		trashRouter.POST("/:resource/:id/restore", trashApi.RestoreTrash) // 恢复
+		trashRouter.DELETE("/:resource/:id", trashApi.PurgeTrash)         // 彻底删除
+	}
+}
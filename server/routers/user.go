@@ -13,13 +13,15 @@ func UserRouter(router *gin.RouterGroup) {
 	// 公开路由
 	publicRouter := router.Group("users")
 	{
-		publicRouter.POST("register", userApi.Register)
-		publicRouter.POST("login", userApi.Login)
-		publicRouter.GET("captcha", userApi.GetCaptcha)       // 图片验证码
-		publicRouter.GET("email/code", userApi.SendEmailCode) // 发送邮箱验证码
-		publicRouter.POST("forgot", userApi.ForgotPassword)   // 忘记密码
-		publicRouter.POST("reset", userApi.ResetPassword)     // 重置密码
-		publicRouter.GET(":id", userApi.GetUserById)          // 根据ID获取用户信息
+		publicRouter.POST("register", middleware.RateLimit("register"), userApi.Register)
+		publicRouter.POST("login", middleware.RateLimit("login"), userApi.Login)
+		publicRouter.POST("login/2fa", middleware.RateLimit("login"), userApi.LoginTwoFactor)          // 登录第二步：两步验证
+		publicRouter.GET("captcha", userApi.GetCaptcha)                                                // 验证码（图片/算术/语音）
+		publicRouter.GET("challenge", userApi.GetChallenge)                                            // 查询是否需要人机验证
+		publicRouter.GET("email/code", middleware.RateLimit("send_email_code"), userApi.SendEmailCode) // 发送邮箱验证码
+		publicRouter.POST("forgot", middleware.RateLimit("forgot_password"), userApi.ForgotPassword)   // 忘记密码
+		publicRouter.POST("reset", middleware.RateLimit("reset_password"), userApi.ResetPassword)      // 重置密码
+		publicRouter.GET(":id", userApi.GetUserById)                                                   // 根据ID获取用户信息
 	}
 
 	// 需认证路由
@@ -34,5 +36,27 @@ func UserRouter(router *gin.RouterGroup) {
 		authRouter.PUT(":uuid/approve", userApi.ApproveUser) // 启用用户
 		authRouter.PUT(":uuid/reject", userApi.RejectUser)   // 禁用用户
 		authRouter.POST("create", userApi.CreateUser)        // 管理员创建用户
+
+		// 个人数据导出
+		authRouter.GET("export", middleware.RateLimit("data_export"), userApi.ExportUserData)
+
+		// 邮箱验证与修改
+		authRouter.POST("email/verify/send", middleware.RateLimit("send_email_code"), userApi.SendEmailVerification) // 发送邮箱验证码
+		authRouter.POST("email/verify", userApi.VerifyEmail)                                                         // 验证当前邮箱
+		authRouter.POST("email/change", middleware.RateLimit("send_email_code"), userApi.RequestEmailChange)         // 申请修改邮箱，验证码发往新邮箱
+		authRouter.POST("email/change/confirm", userApi.ConfirmEmailChange)                                          // 确认修改邮箱
+
+		// 两步验证
+		authRouter.GET("2fa/status", userApi.GetTwoFactorStatus)               // 两步验证状态
+		authRouter.POST("2fa/disable", userApi.DisableTwoFactor)               // 关闭两步验证
+		authRouter.POST("2fa/recovery-codes", userApi.RegenerateRecoveryCodes) // 重新生成恢复码
+		authRouter.DELETE(":uuid/2fa", userApi.ResetUserTwoFactor)             // 管理员重置用户两步验证
+	}
+
+	// 开启两步验证：同时接受角色强制开启时登录获得的受限令牌
+	twoFactorSetupRouter := router.Group("users/2fa").Use(middleware.InitTwoFactorSetupJWT())
+	{
+		twoFactorSetupRouter.POST("setup", userApi.SetupTwoFactor)   // 获取密钥和二维码地址
+		twoFactorSetupRouter.POST("enable", userApi.EnableTwoFactor) // 校验验证码并开启
 	}
 }
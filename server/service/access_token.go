@@ -0,0 +1,131 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"server/global"
+	"server/model/appType"
+	"server/model/database"
+	"server/model/request"
+	"strings"
+	"time"
+)
+
+// 个人访问令牌的格式和限制
+const (
+	AccessTokenPrefix        = "pat_"      // 令牌前缀，用于和JWT区分
+	maxAccessTokensPerUser   = 50          // 每个用户最多持有的令牌数
+	accessTokenTouchInterval = time.Minute // 最后使用时间的最小更新间隔，避免每个请求都写库
+)
+
+// AccessTokenService 个人访问令牌服务
+type AccessTokenService struct{}
+
+// hashAccessToken 计算令牌哈希
+func hashAccessToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAccessToken 判断 Bearer 凭证是否为个人访问令牌
+func IsAccessToken(raw string) bool {
+	return strings.HasPrefix(raw, AccessTokenPrefix)
+}
+
+// Create 创建令牌，返回的明文令牌只在创建时出现一次
+func (s *AccessTokenService) Create(userID uint, req request.AccessTokenCreateRequest) (token database.PersonalAccessToken, plain string, err error) {
+	scopes := make([]string, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		if !appType.TokenScope(scope).IsValid() {
+			return token, "", errors.New("无效的权限范围: " + scope)
+		}
+		if !containsString(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	var count int64
+	if err = global.DB.Model(&database.PersonalAccessToken{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return token, "", err
+	}
+	if count >= maxAccessTokensPerUser {
+		return token, "", errors.New("令牌数量已达上限，请先撤销不用的令牌")
+	}
+
+	random, err := randomToken(20)
+	if err != nil {
+		return token, "", err
+	}
+	plain = AccessTokenPrefix + random
+
+	token = database.PersonalAccessToken{
+		UserID:    userID,
+		Name:      req.Name,
+		Prefix:    plain[:len(AccessTokenPrefix)+6],
+		TokenHash: hashAccessToken(plain),
+		Scopes:    strings.Join(scopes, ","),
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err = global.DB.Create(&token).Error; err != nil {
+		return token, "", err
+	}
+	return token, plain, nil
+}
+
+// List 列出用户的令牌
+func (s *AccessTokenService) List(userID uint) (tokens []database.PersonalAccessToken, err error) {
+	err = global.DB.Where("user_id = ?", userID).Order("id DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+// Revoke 撤销令牌
+func (s *AccessTokenService) Revoke(userID, tokenID uint) error {
+	result := global.DB.Where("id = ? AND user_id = ?", tokenID, userID).Delete(&database.PersonalAccessToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("令牌不存在")
+	}
+	return nil
+}
+
+// Authenticate 校验令牌，成功时返回令牌和所属用户，并记录最后使用时间
+func (s *AccessTokenService) Authenticate(raw, ip string) (token database.PersonalAccessToken, user database.User, err error) {
+	if !IsAccessToken(raw) {
+		return token, user, errors.New("无效的访问令牌")
+	}
+	if err = global.DB.Where("token_hash = ?", hashAccessToken(raw)).First(&token).Error; err != nil {
+		return token, user, errors.New("无效的访问令牌")
+	}
+	if token.IsExpired() {
+		return token, user, errors.New("访问令牌已过期")
+	}
+	if err = global.DB.Where("id = ?", token.UserID).First(&user).Error; err != nil {
+		return token, user, errors.New("用户不存在")
+	}
+	if user.Status == 0 {
+		return token, user, errors.New("用户已被禁用")
+	}
+
+	now := time.Now()
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= accessTokenTouchInterval || token.LastUsedIP != ip {
+		global.DB.Model(&token).UpdateColumns(map[string]interface{}{"last_used_at": now, "last_used_ip": ip})
+	}
+	return token, user, nil
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,522 @@
+package service
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"server/global"
+	"server/model/appType"
+	"server/model/database"
+	"server/model/response"
+	"server/utils"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// accountDeletionStaleAfter 执行中的注销任务超过该时间未完成时视为中断，由定时任务重新执行
+const accountDeletionStaleAfter = time.Hour
+
+// AccountService 账户数据导出与注销服务
+type AccountService struct{}
+
+// exportProfile 导出的个人资料
+type exportProfile struct {
+	response.UserResponse
+	LoginMethod appType.LoginType `json:"login_method"`
+	OAuthLinks  []exportOAuthLink `json:"oauth_links"`
+}
+
+type exportOAuthLink struct {
+	Provider  string    `json:"provider"`
+	Nickname  string    `json:"nickname"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportComment struct {
+	ID           uint      `json:"id"`
+	ArticleID    uint      `json:"article_id"`
+	ArticleTitle string    `json:"article_title"`
+	ParentID     *uint     `json:"parent_id,omitempty"`
+	Content      string    `json:"content"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type exportArticleRef struct {
+	ArticleID uint      `json:"article_id"`
+	Title     string    `json:"title"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"` // 收藏或点赞的时间
+}
+
+type exportMedia struct {
+	ID        uint      `json:"id"`
+	Filename  string    `json:"filename"`
+	File      string    `json:"file"` // 压缩包中的路径，文件缺失时为空
+	FileType  string    `json:"file_type"`
+	FileSize  int64     `json:"file_size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Export 把用户的个人数据打包为zip写入 w：个人资料、文章（带头部元数据的Markdown）、评论、收藏、点赞和上传的图片
+func (s *AccountService) Export(userID uint, w io.Writer) error {
+	var user database.User
+	if err := global.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		return errors.New("用户不存在")
+	}
+
+	archive := zip.NewWriter(w)
+
+	// 个人资料
+	profile := exportProfile{UserResponse: response.ToUserResponse(user), LoginMethod: user.LoginMethod, OAuthLinks: []exportOAuthLink{}}
+	var links []database.UserOAuth
+	global.DB.Where("user_id = ?", userID).Find(&links)
+	for _, link := range links {
+		profile.OAuthLinks = append(profile.OAuthLinks, exportOAuthLink{Provider: link.Provider, Nickname: link.Nickname, CreatedAt: link.CreatedAt})
+	}
+	if err := writeZipJSON(archive, "profile.json", profile); err != nil {
+		return err
+	}
+
+	// 文章
+	var articles []database.Article
+	if err := global.DB.Preload("Category").Preload("Tags").Where("author_id = ?", userID).Order("id").Find(&articles).Error; err != nil {
+		return err
+	}
+	for _, article := range articles {
+		data, err := utils.RenderMarkdown(ArticleFrontMatter(article), article.Content)
+		if err != nil {
+			return err
+		}
+		if err := writeZipFile(archive, fmt.Sprintf("articles/%d-%s.md", article.ID, utils.SafeFileName(article.Slug)), data); err != nil {
+			return err
+		}
+	}
+
+	// 评论
+	var comments []database.Comment
+	if err := global.DB.Preload("Article").Where("user_id = ?", userID).Order("id").Find(&comments).Error; err != nil {
+		return err
+	}
+	exportComments := make([]exportComment, 0, len(comments))
+	for _, comment := range comments {
+		exportComments = append(exportComments, exportComment{
+			ID:           comment.ID,
+			ArticleID:    comment.ArticleID,
+			ArticleTitle: comment.Article.Title,
+			ParentID:     comment.ParentID,
+			Content:      comment.Content,
+			CreatedAt:    comment.CreatedAt,
+		})
+	}
+	if err := writeZipJSON(archive, "comments.json", exportComments); err != nil {
+		return err
+	}
+
+	// 收藏和点赞
+	var favorites []database.Favorite
+	if err := global.DB.Preload("Article").Where("user_id = ?", userID).Order("created_at").Find(&favorites).Error; err != nil {
+		return err
+	}
+	exportFavorites := make([]exportArticleRef, 0, len(favorites))
+	for _, favorite := range favorites {
+		exportFavorites = append(exportFavorites, exportArticleRef{ArticleID: favorite.ArticleID, Title: favorite.Article.Title, Slug: favorite.Article.Slug, CreatedAt: favorite.CreatedAt})
+	}
+	if err := writeZipJSON(archive, "favorites.json", exportFavorites); err != nil {
+		return err
+	}
+
+	var likes []struct {
+		database.Like
+		Title string
+		Slug  string
+	}
+	if err := global.DB.Model(&database.Like{}).
+		Select("likes.*, articles.title, articles.slug").
+		Joins("JOIN articles ON articles.id = likes.article_id").
+		Where("likes.user_id = ?", userID).Order("likes.id").Scan(&likes).Error; err != nil {
+		return err
+	}
+	exportLikes := make([]exportArticleRef, 0, len(likes))
+	for _, like := range likes {
+		exportLikes = append(exportLikes, exportArticleRef{ArticleID: like.ArticleID, Title: like.Title, Slug: like.Slug, CreatedAt: like.CreatedAt})
+	}
+	if err := writeZipJSON(archive, "likes.json", exportLikes); err != nil {
+		return err
+	}
+
+	// 上传的图片
+	var media []database.Media
+	if err := global.DB.Where("user_id = ?", userID).Order("id").Find(&media).Error; err != nil {
+		return err
+	}
+	exportFiles := make([]exportMedia, 0, len(media))
+	for _, m := range media {
+		item := exportMedia{ID: m.ID, Filename: m.Filename, FileType: m.FileType, FileSize: m.FileSize, CreatedAt: m.CreatedAt}
+		name := fmt.Sprintf("media/%d-%s", m.ID, utils.SafeFileName(filepath.Base(m.StoragePath)))
+		if err := copyFileToZip(archive, name, m.StoragePath); err == nil {
+			item.File = name
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		exportFiles = append(exportFiles, item)
+	}
+	if err := writeZipJSON(archive, "media.json", exportFiles); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+// ArticleFrontMatter 根据文章生成 Markdown 头部元数据
+func ArticleFrontMatter(article database.Article) utils.FrontMatter {
+	fm := utils.FrontMatter{
+		Title:    article.Title,
+		Slug:     article.Slug,
+		Date:     article.CreatedAt,
+		Updated:  article.UpdatedAt,
+		Category: article.Category.Name,
+		Summary:  article.Summary,
+		Cover:    article.CoverImage,
+		Draft:    article.Status != 1,
+	}
+	for _, tag := range article.Tags {
+		fm.Tags = append(fm.Tags, tag.Name)
+	}
+	return fm
+}
+
+// writeZipJSON 向压缩包写入格式化的JSON文件
+func writeZipJSON(archive *zip.Writer, name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeZipFile(archive, name, data)
+}
+
+// writeZipFile 向压缩包写入文件
+func writeZipFile(archive *zip.Writer, name string, data []byte) error {
+	f, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+// copyFileToZip 把磁盘上的文件复制到压缩包中
+func copyFileToZip(archive *zip.Writer, name, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	f, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, src)
+	return err
+}
+
+// RequestDeletion 提交账户注销任务：立即禁用账户并吊销访问令牌，内容在后台处理
+func (s *AccountService) RequestDeletion(requestedBy, userID uint, mode appType.AccountDeletionMode) (job database.AccountDeletion, err error) {
+	if !mode.IsValid() {
+		return job, errors.New("无效的注销方式")
+	}
+
+	var user database.User
+	if err = global.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		return job, errors.New("用户不存在")
+	}
+
+	var running int64
+	global.DB.Model(&database.AccountDeletion{}).
+		Where("user_id = ? AND status IN ?", userID, []appType.AccountDeletionStatus{appType.AccountDeletionPending, appType.AccountDeletionRunning}).
+		Count(&running)
+	if running > 0 {
+		return job, errors.New("该账户的注销任务正在处理中")
+	}
+
+	job = database.AccountDeletion{
+		UserID:      userID,
+		RequestedBy: requestedBy,
+		Mode:        mode,
+		Status:      appType.AccountDeletionPending,
+	}
+	err = global.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("status", 0).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&database.PersonalAccessToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&job).Error
+	})
+	return job, err
+}
+
+// ProcessDeletion 执行注销任务，同一任务只会被一个执行者领取
+func (s *AccountService) ProcessDeletion(jobID uint) {
+	now := time.Now()
+	claim := global.DB.Model(&database.AccountDeletion{}).
+		Where("id = ? AND (status = ? OR (status = ? AND started_at < ?))", jobID,
+			appType.AccountDeletionPending, appType.AccountDeletionRunning, now.Add(-accountDeletionStaleAfter)).
+		Updates(map[string]interface{}{"status": appType.AccountDeletionRunning, "started_at": now})
+	if claim.Error != nil || claim.RowsAffected == 0 {
+		return
+	}
+
+	var job database.AccountDeletion
+	if err := global.DB.Where("id = ?", jobID).First(&job).Error; err != nil {
+		return
+	}
+
+	err := s.deleteAccount(job)
+	finished := time.Now()
+	updates := map[string]interface{}{"status": appType.AccountDeletionCompleted, "finished_at": finished, "error": ""}
+	if err != nil {
+		updates["status"] = appType.AccountDeletionFailed
+		updates["error"] = err.Error()
+		global.ZapLog.Error("账户注销失败", zap.Uint("job_id", job.ID), zap.Uint("user_id", job.UserID), zap.Error(err))
+	} else {
+		global.ZapLog.Info("账户注销完成", zap.Uint("job_id", job.ID), zap.Uint("user_id", job.UserID), zap.String("mode", string(job.Mode)))
+	}
+	global.DB.Model(&job).Updates(updates)
+}
+
+// ProcessPendingDeletions 执行所有等待中或中断的注销任务
+func (s *AccountService) ProcessPendingDeletions() {
+	var ids []uint
+	global.DB.Model(&database.AccountDeletion{}).
+		Where("status = ? OR (status = ? AND started_at < ?)",
+			appType.AccountDeletionPending, appType.AccountDeletionRunning, time.Now().Add(-accountDeletionStaleAfter)).
+		Order("id").Pluck("id", &ids)
+	for _, id := range ids {
+		s.ProcessDeletion(id)
+	}
+}
+
+// deleteAccount 按注销方式处理用户数据，每一步都可重复执行
+func (s *AccountService) deleteAccount(job database.AccountDeletion) error {
+	var user database.User
+	if err := global.DB.Unscoped().Where("id = ?", job.UserID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if err := s.removePersonalData(user.ID); err != nil {
+		return err
+	}
+	// 头像属于个人信息，两种方式都要在清空 avatar 字段之前删除头像文件
+	if err := removeAvatarFile(user.Avatar); err != nil {
+		return err
+	}
+	if job.Mode == appType.AccountDeletionDelete {
+		return s.deleteContent(user)
+	}
+	return s.anonymize(user)
+}
+
+// removePersonalData 删除两种注销方式都需要清除的数据：点赞、收藏、第三方绑定、访问令牌和恢复码
+func (s *AccountService) removePersonalData(userID uint) error {
+	var likedIDs, favoriteIDs []uint
+	err := global.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&database.Like{}).Where("user_id = ?", userID).Pluck("article_id", &likedIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&database.Favorite{}).Where("user_id = ?", userID).Pluck("article_id", &favoriteIDs).Error; err != nil {
+			return err
+		}
+		if len(likedIDs) > 0 {
+			if err := tx.Model(&database.Article{}).Where("id IN ? AND like_count > 0", likedIDs).
+				Update("like_count", gorm.Expr("like_count - 1")).Error; err != nil {
+				return err
+			}
+		}
+		if len(favoriteIDs) > 0 {
+			if err := tx.Model(&database.Article{}).Where("id IN ? AND favorite_count > 0", favoriteIDs).
+				Update("favorite_count", gorm.Expr("favorite_count - 1")).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, model := range []interface{}{
+			&database.Like{},
+			&database.Favorite{},
+			&database.UserOAuth{},
+			&database.PersonalAccessToken{},
+			&database.TwoFactorRecoveryCode{},
+		} {
+			if err := tx.Unscoped().Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	(&ArticleCounterService{}).MarkChanged(append(likedIDs, favoriteIDs...)...)
+	return nil
+}
+
+// anonymize 保留用户的文章和评论，抹去账户上的个人信息
+func (s *AccountService) anonymize(user database.User) error {
+	password, err := randomToken(16)
+	if err != nil {
+		return err
+	}
+	// ES中保存了作者用户名，已发布的文章需要重新同步，变更事件与账户修改一起提交
+	var articleIDs []uint
+	err = global.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Model(&user).Updates(map[string]interface{}{
+			"username":              fmt.Sprintf("deleted_%d", user.ID),
+			"nickname":              "已注销用户",
+			"email":                 fmt.Sprintf("deleted_%d@deleted.invalid", user.ID),
+			"password":              utils.BcryptHash(password),
+			"avatar":                "",
+			"bio":                   "",
+			"address":               "",
+			"login_method":          appType.LoginTypePassword,
+			"email_verified":        nil,
+			"two_factor_secret":     "",
+			"two_factor_enabled_at": nil,
+			"status":                0,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&database.Article{}).Where("author_id = ? AND status = 1", user.ID).Pluck("id", &articleIDs).Error; err != nil {
+			return err
+		}
+		for _, id := range articleIDs {
+			if err := enqueueArticleSync(tx, id, appType.ESOutboxIndex); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 通过图片接口上传的旧头像有图片记录，一并彻底删除；当前头像文件已在 deleteAccount 中删除
+	var avatars []uint
+	global.DB.Unscoped().Model(&database.Media{}).Where("user_id = ? AND storage_path LIKE ?", user.ID, "uploads/avatars/%").Pluck("id", &avatars)
+	trashService := TrashService{}
+	for _, id := range avatars {
+		if err := trashService.purge(appType.TrashMedia, id); err != nil {
+			global.ZapLog.Warn("删除头像失败", zap.Uint("media_id", id), zap.Error(err))
+		}
+	}
+
+	// 立即投递文章变更事件，失败的由定时任务重试
+	outboxService := ESOutboxService{}
+	for _, id := range articleIDs {
+		outboxService.DeliverArticle(id)
+	}
+	return nil
+}
+
+// removeAvatarFile 按 users.avatar 删除头像文件，用户中心上传和第三方导入的头像只有文件，没有图片记录
+// 只处理 /uploads/avatars/ 下的文件名，外部地址和其他路径不处理，文件已不存在时视为成功
+func removeAvatarFile(avatar string) error {
+	name, ok := strings.CutPrefix(avatar, "/uploads/avatars/")
+	if !ok || name == "" || name != filepath.Base(name) || name == "." || name == ".." {
+		return nil
+	}
+	if err := os.Remove(filepath.Join("uploads/avatars", name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// deleteOwnComments 彻底删除用户的全部评论（包括回收站中的），并更新所在文章的评论数
+// 其他用户对这些评论的回复改挂到被删除评论的上级评论下，上级为空时成为顶层评论
+func (s *AccountService) deleteOwnComments(userID uint) error {
+	var ids []uint
+	if err := global.DB.Unscoped().Model(&database.Comment{}).Where("user_id = ?", userID).Order("id").Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	articleIDs := make(map[uint]bool)
+	err := global.DB.Transaction(func(tx *gorm.DB) error {
+		for _, id := range ids {
+			// 逐条重新读取：前面删除的评论可能已经改变了这条评论的上级
+			var comment database.Comment
+			if err := tx.Unscoped().Select("id", "article_id", "parent_id").Where("id = ?", id).First(&comment).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Model(&database.Comment{}).Where("parent_id = ?", id).
+				UpdateColumn("parent_id", comment.ParentID).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Delete(&database.Comment{}, id).Error; err != nil {
+				return err
+			}
+			articleIDs[comment.ArticleID] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	commentService := CommentService{}
+	for articleID := range articleIDs {
+		commentService.updateArticleCommentCount(articleID)
+	}
+	return nil
+}
+
+// deleteContent 彻底删除用户的文章、评论、图片和账户
+func (s *AccountService) deleteContent(user database.User) error {
+	articleService := ArticleService{}
+	trashService := TrashService{}
+
+	// 文章：未删除的先按正常流程删除（同步ES、清理标签），再连同回收站中的文章一起彻底删除
+	var liveIDs, articleIDs []uint
+	if err := global.DB.Model(&database.Article{}).Where("author_id = ?", user.ID).Pluck("id", &liveIDs).Error; err != nil {
+		return err
+	}
+	for _, id := range liveIDs {
+		if err := articleService.DeleteArticle(id, user.ID, true); err != nil {
+			return err
+		}
+	}
+	if err := global.DB.Unscoped().Model(&database.Article{}).Where("author_id = ?", user.ID).Pluck("id", &articleIDs).Error; err != nil {
+		return err
+	}
+	for _, id := range articleIDs {
+		if err := trashService.purge(appType.TrashArticles, id); err != nil {
+			return err
+		}
+	}
+
+	// 评论：只删除用户自己的评论，其他用户的回复保留
+	if err := s.deleteOwnComments(user.ID); err != nil {
+		return err
+	}
+
+	// 图片：删除记录和文件
+	var mediaIDs []uint
+	if err := global.DB.Unscoped().Model(&database.Media{}).Where("user_id = ?", user.ID).Pluck("id", &mediaIDs).Error; err != nil {
+		return err
+	}
+	for _, id := range mediaIDs {
+		if err := trashService.purge(appType.TrashMedia, id); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+
+	return global.DB.Unscoped().Delete(&user).Error
+}
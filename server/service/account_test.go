@@ -0,0 +1,52 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRemoveAvatarFile(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("获取工作目录失败: %v", err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("切换工作目录失败: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	if err := os.MkdirAll("uploads/avatars", os.ModePerm); err != nil {
+		t.Fatalf("创建目录失败: %v", err)
+	}
+	for _, name := range []string{"uploads/avatars/a.png", "uploads/other.png", "secret.txt"} {
+		if err := os.WriteFile(name, []byte("x"), 0o644); err != nil {
+			t.Fatalf("写入测试文件失败: %v", err)
+		}
+	}
+
+	cases := []struct {
+		avatar string
+		path   string
+		remove bool
+	}{
+		{"/uploads/avatars/a.png", "uploads/avatars/a.png", true},
+		{"/uploads/avatars/../other.png", "uploads/other.png", false},
+		{"/uploads/avatars/../../secret.txt", "secret.txt", false},
+		{"https://example.com/uploads/avatars/a.png", "", false},
+		{"/uploads/avatars/missing.png", "", false},
+		{"", "", false},
+	}
+	for _, c := range cases {
+		if err := removeAvatarFile(c.avatar); err != nil {
+			t.Errorf("%q: 删除失败: %v", c.avatar, err)
+		}
+		if c.path == "" {
+			continue
+		}
+		_, err := os.Stat(filepath.FromSlash(c.path))
+		if removed := os.IsNotExist(err); removed != c.remove {
+			t.Errorf("%q: 文件 %s 删除状态为 %v，期望 %v", c.avatar, c.path, removed, c.remove)
+		}
+	}
+}
@@ -13,11 +13,14 @@ import (
 	"server/model/database"
 	"server/model/es"
 	"server/model/request"
+	"server/utils"
 
 	"fmt"
 
 	"github.com/elastic/go-elasticsearch/v8/typedapi/core/search"
 	"github.com/elastic/go-elasticsearch/v8/typedapi/types"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types/enums/highlighterencoder"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types/enums/operator"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -104,23 +107,32 @@ func (s *ArticleService) CreateArticle(req request.ArticleCreateRequest) (databa
 		}
 	}
 
+	// 更新标签的文章数量
+	if err := s.recountArticleTags(tx, article.ID, nil); err != nil {
+		tx.Rollback()
+		return article, err
+	}
+
 	// 更新分类的文章数量统计
 	if err := s.updateCategoryArticleCount(tx, categoryID); err != nil {
 		tx.Rollback()
 		return article, err
 	}
 
+	// 写入ES变更事件，与文章一起提交
+	if err := enqueueArticleSync(tx, article.ID, appType.ESOutboxIndex); err != nil {
+		tx.Rollback()
+		return article, err
+	}
+
 	// 提交事务
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
 		return article, err
 	}
 
-	// 事务提交后再同步到ES
-	if article.Status == 1 {
-		// 异步同步到ES
-		go s.SyncArticleToES(article.ID)
-	}
+	// 事务提交后立即投递，失败时由定时任务重试
+	go (&ESOutboxService{}).DeliverArticle(article.ID)
 
 	// 重新查询文章以获取完整关联数据
 	if err := global.DB.Preload("Category").Preload("Tags").Preload("Author").Where("id = ?", article.ID).First(&article).Error; err != nil {
@@ -203,9 +215,6 @@ func (s *ArticleService) GetArticleByID(id uint, isAdmin bool, currentUserID uin
 		// 作者访问自己的草稿文章，允许访问
 	}
 
-	// 异步增加阅读量
-	go s.IncrementViewCount(id)
-
 	return article, nil
 }
 
@@ -265,11 +274,9 @@ func (s *ArticleService) UpdateArticle(req request.ArticleUpdateRequest, userID
 	}
 
 	// 如果状态变更，更新状态
-	statusChanged := false
 	if article.Status != req.Status {
 		updateData["Status"] = req.Status
 		article.Status = req.Status
-		statusChanged = true
 	}
 
 	if err := tx.Model(&article).Updates(updateData).Error; err != nil {
@@ -306,6 +313,12 @@ func (s *ArticleService) UpdateArticle(req request.ArticleUpdateRequest, userID
 		return article, err
 	}
 
+	// 更新新旧标签的文章数量
+	if err := s.recountArticleTags(tx, article.ID, oldTagIDs); err != nil {
+		tx.Rollback()
+		return article, err
+	}
+
 	// 更新分类的文章数量统计
 	// 如果分类ID发生了变化，需要更新旧分类和新分类的统计
 	if req.CategoryID > 0 && req.CategoryID != article.CategoryID {
@@ -331,9 +344,10 @@ func (s *ArticleService) UpdateArticle(req request.ArticleUpdateRequest, userID
 		}
 	}
 
-	// 如果变为发布状态，同步到ES
-	if statusChanged && article.Status == 1 {
-		go s.SyncArticleToES(article.ID)
+	// 写入ES变更事件：已发布的文章更新索引，改为草稿的文章从索引中移除
+	if err := enqueueArticleSync(tx, article.ID, appType.ESOutboxIndex); err != nil {
+		tx.Rollback()
+		return article, err
 	}
 
 	// 提交事务
@@ -341,6 +355,7 @@ func (s *ArticleService) UpdateArticle(req request.ArticleUpdateRequest, userID
 		tx.Rollback()
 		return article, err
 	}
+	go (&ESOutboxService{}).DeliverArticle(article.ID)
 
 	// 重新加载完整文章数据
 	global.DB.Preload("Category").Preload("Tags").First(&article, article.ID)
@@ -387,6 +402,12 @@ func (s *ArticleService) DeleteArticle(articleID uint, userID uint, isAdmin bool
 		return err
 	}
 
+	// 记录删除操作人，作者不能恢复被管理员删除的文章
+	if err := tx.Model(&article).Update("deleted_by", userID).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	// 删除文章记录
 	if err := tx.Delete(&article).Error; err != nil {
 		tx.Rollback()
@@ -405,14 +426,26 @@ func (s *ArticleService) DeleteArticle(articleID uint, userID uint, isAdmin bool
 		return err
 	}
 
+	// 更新标签的文章数量
+	if err := s.recountArticleTags(tx, articleID, tagIDs); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// 写入ES变更事件，与删除一起提交
+	if err := enqueueArticleSync(tx, articleID, appType.ESOutboxDelete); err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	// 提交事务
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	// 异步从ES删除文章
-	go s.DeleteArticleFromES(articleID)
+	// 事务提交后立即从ES删除，失败时由定时任务重试
+	go (&ESOutboxService{}).DeliverArticle(articleID)
 
 	return nil
 }
@@ -446,6 +479,27 @@ func (s *ArticleService) cleanupOrphanTags(tx *gorm.DB, tagIDs []uint) error {
 	return nil
 }
 
+// recountArticleTags 重新统计文章当前标签以及 extraTagIDs 中标签的文章数量
+// 只统计未删除的文章，回收站中的文章不计入
+func (s *ArticleService) recountArticleTags(tx *gorm.DB, articleID uint, extraTagIDs []uint) error {
+	var tagIDs []uint
+	if err := tx.Unscoped().Model(&database.ArticleTag{}).Where("article_id = ?", articleID).Pluck("tag_id", &tagIDs).Error; err != nil {
+		return err
+	}
+	tagIDs = append(tagIDs, extraTagIDs...)
+	if len(tagIDs) == 0 {
+		return nil
+	}
+
+	return tx.Exec(`
+		UPDATE tags SET count = (
+			SELECT COUNT(*) FROM article_tags at
+			JOIN articles a ON a.id = at.article_id
+			WHERE at.tag_id = tags.id AND at.deleted_at IS NULL AND a.deleted_at IS NULL
+		)
+		WHERE id IN ?`, tagIDs).Error
+}
+
 // handleArticleTags 处理文章与标签的关联关系（支持ID和名称）
 func (s *ArticleService) handleArticleTags(tx *gorm.DB, articleID uint, tagIDs []uint, tagNames []string) error {
 	// 合并标签ID和通过名称获取的ID
@@ -566,10 +620,7 @@ func (s *ArticleService) SyncArticleToES(articleID uint) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	_, err := global.ES.Index(esArticle.IndexName()).
-		Id(strconv.FormatUint(esArticle.ID, 10)).
-		Document(esArticle).
-		Do(ctx)
+	err := NewArticleESService().IndexDocument(ctx, strconv.FormatUint(esArticle.ID, 10), esArticle)
 
 	if err != nil {
 		global.ZapLog.Error("同步文章到ES失败", zap.Uint("articleID", articleID), zap.Error(err))
@@ -585,14 +636,8 @@ func (s *ArticleService) DeleteArticleFromES(articleID uint) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// 构建删除请求
-	deleteRequest := global.ES.Delete(
-		(&es.ArticleES{}).IndexName(),
-		strconv.FormatUint(uint64(articleID), 10),
-	)
-
-	// 执行删除操作
-	_, err := deleteRequest.Do(ctx)
+	// 执行删除操作，重建索引期间同时从新索引删除
+	err := NewArticleESService().DeleteDocument(ctx, strconv.FormatUint(uint64(articleID), 10))
 
 	if err != nil {
 		global.ZapLog.Error("从ES删除文章失败", zap.Uint("articleID", articleID), zap.Error(err))
@@ -603,28 +648,6 @@ func (s *ArticleService) DeleteArticleFromES(articleID uint) error {
 	return nil
 }
 
-// IncrementViewCount 增加阅读量
-func (s *ArticleService) IncrementViewCount(articleID uint) error {
-	// 使用上下文设置超时
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	// 使用乐观锁更新阅读量
-	result := global.DB.WithContext(ctx).Model(&database.Article{}).
-		Where("id = ?", articleID).
-		Update("view_count", gorm.Expr("view_count + ?", 1))
-
-	if result.Error != nil {
-		return result.Error
-	}
-
-	if result.RowsAffected == 0 {
-		return errors.New("更新阅读量失败，文章不存在或已被删除")
-	}
-
-	return nil
-}
-
 // generateSlug 基于标题生成唯一slug
 func generateSlug(title string) string {
 	// 基本slug生成（转换为小写，替换空格为连字符）
@@ -635,59 +658,6 @@ func generateSlug(title string) string {
 	return slug
 }
 
-// SyncArticleStatsToES 同步文章统计数据到ES
-func (s *ArticleService) SyncArticleStatsToES(articleID uint) error {
-	// 获取完整文章信息（包括统计数据）
-	var article database.Article
-	if err := global.DB.Preload("Category").Preload("Tags").Preload("Author").Where("id = ?", articleID).First(&article).Error; err != nil {
-		return err
-	}
-
-	// 转换为ES文章结构
-	esArticle := es.ArticleES{
-		ID:            uint64(article.ID),
-		Title:         article.Title,
-		Content:       article.Content,
-		Summary:       article.Summary,
-		CoverImage:    article.CoverImage,
-		CategoryID:    article.CategoryID,
-		UserID:        article.AuthorID,
-		Status:        appType.ArticleStatus(article.Status),
-		ViewCount:     article.ViewCount,
-		LikeCount:     article.LikeCount,
-		CommentCount:  article.CommentCount,
-		FavoriteCount: article.FavoriteCount,
-		CreatedAt:     article.CreatedAt,
-		UpdatedAt:     article.UpdatedAt,
-	}
-
-	// 设置作者信息
-	esArticle.AuthorName = article.Author.Username
-	esArticle.AuthorNickname = article.Author.Nickname
-	esArticle.AuthorAvatar = article.Author.Avatar
-
-	// 提取标签名称
-	for _, tag := range article.Tags {
-		esArticle.Tags = append(esArticle.Tags, tag.Name)
-	}
-
-	// 同步到ES（使用Index而不是Update，这样如果文档不存在会创建，存在会更新）
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	_, err := global.ES.Index(esArticle.IndexName()).
-		Id(strconv.FormatUint(uint64(article.ID), 10)).
-		Document(esArticle).
-		Do(ctx)
-
-	if err != nil {
-		global.ZapLog.Error("同步文章统计到ES失败", zap.Uint("articleID", articleID), zap.Error(err))
-		return err
-	}
-
-	return nil
-}
-
 // ToggleLike 切换文章点赞状态
 func (s *ArticleService) ToggleLike(articleID uint, userID uint) (bool, error) {
 	// 使用事务确保数据一致性
@@ -743,8 +713,8 @@ func (s *ArticleService) ToggleLike(articleID uint, userID uint) (bool, error) {
 		return false, err
 	}
 
-	// 异步同步到ES
-	go s.SyncArticleStatsToES(articleID)
+	// 标记计数变化，由定时任务更新索引
+	(&ArticleCounterService{}).MarkChanged(articleID)
 
 	return !exists, nil // 返回是否点赞成功
 }
@@ -804,8 +774,8 @@ func (s *ArticleService) ToggleFavorite(articleID uint, userID uint) (bool, erro
 		return false, err
 	}
 
-	// 异步同步到ES
-	go s.SyncArticleStatsToES(articleID)
+	// 标记计数变化，由定时任务更新索引
+	(&ArticleCounterService{}).MarkChanged(articleID)
 
 	return !exists, nil // 返回是否收藏成功
 }
@@ -920,13 +890,14 @@ func (s *ArticleService) RemoveFavorite(favoriteID uint, userID uint) error {
 		return err
 	}
 
-	// 异步同步到ES
-	go s.SyncArticleStatsToES(favorite.ArticleID)
+	// 标记计数变化，由定时任务更新索引
+	(&ArticleCounterService{}).MarkChanged(favorite.ArticleID)
 
 	return nil
 }
 
-func (s *ArticleService) SearchArticles(req request.SearchArticleRequest) (es.ArticleSearchResult, error) {
+// SearchArticles 搜索文章，visitor 用于热门搜索词的访客去重（如客户端IP）
+func (s *ArticleService) SearchArticles(req request.SearchArticleRequest, visitor string) (es.ArticleSearchResult, error) {
 	// 设置默认分页参数
 	if req.Page <= 0 {
 		req.Page = 1
@@ -936,12 +907,30 @@ func (s *ArticleService) SearchArticles(req request.SearchArticleRequest) (es.Ar
 	}
 
 	// 首先尝试ES搜索
+	start := time.Now()
+	fallback := false
 	result, err := s.searchFromES(req)
 	if err != nil {
 		global.ZapLog.Warn("ES搜索失败，降级到MySQL搜索", zap.Error(err))
 		// ES失败时降级到MySQL搜索
-		return s.searchFromMySQL(req)
+		fallback = true
+		if result, err = s.searchFromMySQL(req); err != nil {
+			return result, err
+		}
+	}
+
+	// 记录搜索统计
+	event := SearchEvent{Query: req.Keyword, Results: result.Total, Latency: time.Since(start), Fallback: fallback}
+	if filters, err := parseSearchFilters(req); err == nil {
+		event.Filters = filters.signature()
+	}
+	go (&SearchAnalyticsService{}).RecordSearch(event)
+
+	// 有结果的搜索记为热门搜索词，翻页不重复计数
+	if req.Page == 1 && req.Keyword != "" && result.Total > 0 {
+		s.RecordSearchQuery(req.Keyword, visitor)
 	}
+	result.SearchID = (&SearchAnalyticsService{}).IssueSearchID(req.Keyword, result.Articles)
 	return result, nil
 }
 
@@ -952,31 +941,19 @@ func (s *ArticleService) searchFromES(req request.SearchArticleRequest) (es.Arti
 
 	// 必须匹配已发布状态
 	boolQuery.Must = append(boolQuery.Must, types.Query{Term: map[string]types.TermQuery{"status": {Value: appType.StatusPublished}}})
-	boost1 := float32(3.0)
-	boost2 := float32(2.0)
-	boost3 := float32(1.5)
-	boost4 := float32(2.5)
-	// 关键词搜索 (多字段)
+	// 关键词搜索 (多字段)，同义词扩展出的查询权重稍低
 	if req.Keyword != "" {
-		boolQuery.Should = append(boolQuery.Should,
-			types.Query{Match: map[string]types.MatchQuery{"title": {Query: req.Keyword, Boost: &boost1}}},
-			types.Query{Match: map[string]types.MatchQuery{"content": {Query: req.Keyword}}},
-			types.Query{Match: map[string]types.MatchQuery{"summary": {Query: req.Keyword, Boost: &boost2}}},
-			types.Query{Match: map[string]types.MatchQuery{"tags": {Query: req.Keyword, Boost: &boost3}}},
-			types.Query{Match: map[string]types.MatchQuery{"author_name": {Query: req.Keyword, Boost: &boost4}}},
-			types.Query{Match: map[string]types.MatchQuery{"author_nickname": {Query: req.Keyword, Boost: &boost4}}},
-		)
+		boolQuery.Should = append(boolQuery.Should, keywordQueries(req.Keyword, 1)...)
+		for _, expanded := range (&SearchSynonymService{}).Expand(req.Keyword) {
+			boolQuery.Should = append(boolQuery.Should, keywordQueries(expanded, 0.8)...)
+		}
 		boolQuery.MinimumShouldMatch = "1"
 	}
 
-	// 分类筛选
-	if req.CategoryID > 0 {
-		boolQuery.Filter = append(boolQuery.Filter, types.Query{Term: map[string]types.TermQuery{"category_id": {Value: req.CategoryID}}})
-	}
-
-	// 标签筛选
-	if req.Tag != "" {
-		boolQuery.Filter = append(boolQuery.Filter, types.Query{Term: map[string]types.TermQuery{"tags": {Value: req.Tag}}})
+	// 分面筛选放在 post_filter 中，只影响返回的文章，不影响分面计数
+	filters, err := parseSearchFilters(req)
+	if err != nil {
+		return es.ArticleSearchResult{}, err
 	}
 
 	// 设置排序字段和顺序
@@ -1012,11 +989,18 @@ func (s *ArticleService) searchFromES(req request.SearchArticleRequest) (es.Arti
 	searchQuery := global.ES.Search().
 		Index((&es.ArticleES{}).IndexName()).
 		Request(&search.Request{
-			Query: &types.Query{Bool: &boolQuery}, // 使用types.Query类型
-			From:  &from,                          // 传递指针
-			Size:  &size,                          // 传递指针
+			Query:     &types.Query{Bool: &boolQuery}, // 使用types.Query类型
+			From:      &from,                          // 传递指针
+			Size:      &size,                          // 传递指针
+			Highlight: searchHighlight(),
+			PostFilter: &types.Query{Bool: &types.BoolQuery{
+				Filter: filters.esQueries(""),
+			}},
+			Aggregations: filters.esFacetAggregations(),
 		}).
-		SourceIncludes_("id", "title", "content", "summary", "cover_image", "category_id", "tags", "user_id", "author_name", "author_nickname", "author_avatar", "status", "view_count", "comment_count", "like_count", "favorite_count", "created_at", "updated_at")
+		TypedKeys(true).
+		// 不返回正文，正文以高亮片段的形式返回
+		SourceIncludes_("id", "title", "summary", "cover_image", "category_id", "tags", "user_id", "author_name", "author_nickname", "author_avatar", "status", "view_count", "comment_count", "like_count", "favorite_count", "created_at", "updated_at")
 
 	// 添加查询构建调试日志
 	global.ZapLog.Info("ES查询构建详情",
@@ -1069,10 +1053,15 @@ func (s *ArticleService) searchFromES(req request.SearchArticleRequest) (es.Arti
 		Page:      req.Page,
 		Size:      req.Size,
 		TotalPage: (int(resp.Hits.Total.Value) + req.Size - 1) / req.Size,
+		Facets:    buildSearchFacets(parseESFacets(resp.Aggregations), filters),
+	}
+	if result.Total == 0 && req.Keyword != "" {
+		result.Suggestion = s.didYouMean(req.Keyword)
 	}
 
 	// 处理查询结果
 	result.Articles = make([]es.ArticleES, 0, len(resp.Hits.Hits))
+	result.Highlights = make(map[uint64]es.ArticleHighlight)
 	for _, hit := range resp.Hits.Hits {
 		var article es.ArticleES
 		if err := json.Unmarshal(hit.Source_, &article); err != nil {
@@ -1080,6 +1069,16 @@ func (s *ArticleService) searchFromES(req request.SearchArticleRequest) (es.Arti
 			continue
 		}
 		result.Articles = append(result.Articles, article)
+		if len(hit.Highlight) > 0 {
+			highlight := es.ArticleHighlight{Content: hit.Highlight["content"]}
+			if fragments := hit.Highlight["title"]; len(fragments) > 0 {
+				highlight.Title = fragments[0]
+			}
+			if fragments := hit.Highlight["summary"]; len(fragments) > 0 {
+				highlight.Summary = fragments[0]
+			}
+			result.Highlights[article.ID] = highlight
+		}
 	}
 
 	return result, nil
@@ -1095,27 +1094,12 @@ func (s *ArticleService) searchFromMySQL(req request.SearchArticleRequest) (es.A
 		zap.Uint("categoryID", req.CategoryID),
 		zap.String("tag", req.Tag))
 
-	// 构建MySQL查询
-	query := global.DB.Model(&database.Article{}).Where("articles.status = ?", 1)
-
-	// 关键词搜索（多字段模糊查询，包括作者名称）
-	if req.Keyword != "" {
-		query = query.Joins("JOIN users ON articles.author_id = users.id").
-			Where("articles.title LIKE ? OR articles.content LIKE ? OR articles.summary LIKE ? OR users.username LIKE ? OR users.nickname LIKE ?",
-				"%"+req.Keyword+"%", "%"+req.Keyword+"%", "%"+req.Keyword+"%", "%"+req.Keyword+"%", "%"+req.Keyword+"%")
-	}
-
-	// 分类筛选
-	if req.CategoryID > 0 {
-		query = query.Where("category_id = ?", req.CategoryID)
-	}
-
-	// 标签筛选（通过关联表查询）
-	if req.Tag != "" {
-		query = query.Joins("JOIN article_tags ON articles.id = article_tags.article_id").
-			Joins("JOIN tags ON article_tags.tag_id = tags.id").
-			Where("tags.name = ?", req.Tag)
+	// 构建MySQL查询：关键词模糊匹配（包括作者名称）和分面筛选
+	filters, err := parseSearchFilters(req)
+	if err != nil {
+		return es.ArticleSearchResult{}, err
 	}
+	query := applyMySQLSearchFilters(mysqlSearchBase(req.Keyword), filters, "")
 
 	// 统计总数
 	if err := query.Count(&total).Error; err != nil {
@@ -1130,15 +1114,15 @@ func (s *ArticleService) searchFromMySQL(req request.SearchArticleRequest) (es.A
 	var orderClause string
 	switch req.Sort {
 	case "time":
-		orderClause = "created_at"
+		orderClause = "articles.created_at"
 	case "view":
-		orderClause = "view_count"
+		orderClause = "articles.view_count"
 	case "comment":
-		orderClause = "comment_count"
+		orderClause = "articles.comment_count"
 	case "like":
-		orderClause = "like_count"
+		orderClause = "articles.like_count"
 	default:
-		orderClause = "created_at"
+		orderClause = "articles.created_at"
 	}
 
 	if req.Order == "desc" {
@@ -1153,18 +1137,40 @@ func (s *ArticleService) searchFromMySQL(req request.SearchArticleRequest) (es.A
 		return es.ArticleSearchResult{}, err
 	}
 
+	counts, err := mysqlFacetCounts(req.Keyword, filters)
+	if err != nil {
+		global.ZapLog.Error("MySQL搜索统计分面失败", zap.Error(err))
+		return es.ArticleSearchResult{}, err
+	}
+
 	// 转换为ES结果格式
 	result := es.ArticleSearchResult{
 		Total:     total,
 		Page:      req.Page,
 		Size:      req.Size,
 		TotalPage: (int(total) + req.Size - 1) / req.Size,
+		Facets:    buildSearchFacets(counts, filters),
 	}
 
-	// 转换文章数据
+	// 转换文章数据，与ES搜索一样不返回正文，只返回高亮片段
 	result.Articles = make([]es.ArticleES, 0, len(articles))
+	result.Highlights = make(map[uint64]es.ArticleHighlight)
+	keywords := utils.SplitKeywords(req.Keyword)
+	pre, post := global.Config.ES.HighlightTags()
 	for _, article := range articles {
 		esArticle := s.convertToESArticle(article)
+		if len(keywords) > 0 {
+			highlight := es.ArticleHighlight{
+				Title:   utils.HighlightText(article.Title, keywords, pre, post),
+				Summary: utils.HighlightText(article.Summary, keywords, pre, post),
+				Content: utils.HighlightFragments(article.Content, keywords, pre, post,
+					global.Config.ES.FragmentSize(), global.Config.ES.FragmentCount()),
+			}
+			if highlight.Title != "" || highlight.Summary != "" || len(highlight.Content) > 0 {
+				result.Highlights[esArticle.ID] = highlight
+			}
+		}
+		esArticle.Content = ""
 		result.Articles = append(result.Articles, esArticle)
 	}
 
@@ -1175,6 +1181,52 @@ func (s *ArticleService) searchFromMySQL(req request.SearchArticleRequest) (es.A
 	return result, nil
 }
 
+// searchHighlight 搜索结果的高亮设置：标题和摘要整段高亮，正文截取片段；内容做 HTML 转义
+func searchHighlight() *types.Highlight {
+	config := global.Config.ES
+	pre, post := config.HighlightTags()
+	whole := 0
+	fragmentSize, fragments := config.FragmentSize(), config.FragmentCount()
+	encoder := highlighterencoder.Html
+	return &types.Highlight{
+		PreTags:  []string{pre},
+		PostTags: []string{post},
+		Encoder:  &encoder,
+		Fields: map[string]types.HighlightField{
+			"title":   {NumberOfFragments: &whole},
+			"summary": {NumberOfFragments: &whole},
+			"content": {FragmentSize: &fragmentSize, NumberOfFragments: &fragments},
+		},
+	}
+}
+
+// keywordQueries 构建关键词在各字段上的匹配查询，weight 用于降低同义词扩展查询的权重
+// 开启拼音时同时匹配拼音子字段，拼音输入也能搜到中文标题；索引没有拼音子字段时这些查询不会命中
+func keywordQueries(keyword string, weight float32) []types.Query {
+	boost := func(b float32) *float32 {
+		b *= weight
+		return &b
+	}
+	queries := []types.Query{
+		{Match: map[string]types.MatchQuery{"title": {Query: keyword, Boost: boost(3.0)}}},
+		{Match: map[string]types.MatchQuery{"content": {Query: keyword, Boost: boost(1.0)}}},
+		{Match: map[string]types.MatchQuery{"summary": {Query: keyword, Boost: boost(2.0)}}},
+		{Match: map[string]types.MatchQuery{"tags": {Query: keyword, Boost: boost(1.5)}}},
+		{Match: map[string]types.MatchQuery{"author_name": {Query: keyword, Boost: boost(2.5)}}},
+		{Match: map[string]types.MatchQuery{"author_nickname": {Query: keyword, Boost: boost(2.5)}}},
+	}
+	if global.Config.ES.Pinyin {
+		// 拼音按音节切分，要求全部音节命中，避免单个音节匹配大量同音字
+		and := operator.And
+		queries = append(queries,
+			types.Query{Match: map[string]types.MatchQuery{"title." + es.PinyinSubField: {Query: keyword, Operator: &and, Boost: boost(1.5)}}},
+			types.Query{Match: map[string]types.MatchQuery{"summary." + es.PinyinSubField: {Query: keyword, Operator: &and, Boost: boost(1.0)}}},
+			types.Query{Match: map[string]types.MatchQuery{"author_nickname." + es.PinyinSubField: {Query: keyword, Operator: &and, Boost: boost(1.0)}}},
+		)
+	}
+	return queries
+}
+
 // convertToESArticle 转换MySQL文章为ES文章格式
 func (s *ArticleService) convertToESArticle(article database.Article) es.ArticleES {
 	esArticle := es.ArticleES{
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"server/global"
+	"server/model/database"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 文章计数在 Redis 中的键：阅读量先累加在哈希中，计数变化的文章记在集合中，定时任务把它们整体改名后处理
+const (
+	articleViewsPendingKey  = "article:views:pending"  // 文章ID → 尚未写入数据库的阅读量
+	articleViewsFlushingKey = "article:views:flushing" // 写入中的阅读量，写入失败时下次继续写入
+	articleStatsDirtyKey    = "article:stats:dirty"    // 计数有变化、需要更新索引的文章ID
+	articleStatsSyncingKey  = "article:stats:syncing"  // 更新索引中的文章ID，失败时下次继续更新
+	articleCounterLockKey   = "article:counter:lock"   // 多个服务进程同时执行写入任务时只有一个生效
+	articleCounterLockTTL   = 5 * time.Minute
+	articleCounterBatch     = 500
+)
+
+// ArticleCounterService 文章计数服务
+// 阅读量在 Redis 中按访客去重后累加，由定时任务批量写入数据库；点赞、收藏和评论数仍在数据库中更新。
+// 计数有变化的文章由定时任务用部分更新把计数字段写入索引，不再重写整篇文档
+type ArticleCounterService struct{}
+
+// RecordView 记录一次阅读，visitor 用于去重（登录用户为用户ID，否则为客户端IP），同一访客在时间窗口内只计一次
+func (s *ArticleCounterService) RecordView(articleID uint, visitor string) {
+	id := strconv.FormatUint(uint64(articleID), 10)
+	if global.Redis == nil {
+		// 没有 Redis 时直接写入数据库，不去重
+		if err := global.DB.Model(&database.Article{}).Where("id = ?", articleID).
+			UpdateColumn("view_count", gorm.Expr("view_count + ?", 1)).Error; err != nil {
+			global.ZapLog.Warn("增加阅读量失败", zap.Uint("article_id", articleID), zap.Error(err))
+		}
+		return
+	}
+
+	sum := sha1.Sum([]byte(visitor + "\n" + id))
+	fresh, err := global.Redis.SetNX("article:view:"+hex.EncodeToString(sum[:]), 1, global.Config.Counter.ViewDedupeWindow()).Result()
+	if err != nil {
+		global.ZapLog.Warn("阅读量去重失败", zap.Uint("article_id", articleID), zap.Error(err))
+		return
+	}
+	if !fresh {
+		return
+	}
+	if err := global.Redis.HIncrBy(articleViewsPendingKey, id, 1).Err(); err != nil {
+		global.ZapLog.Warn("记录阅读量失败", zap.Uint("article_id", articleID), zap.Error(err))
+	}
+}
+
+// MarkChanged 在数据库中的计数修改后调用，标记文章需要更新索引中的计数
+// 没有 Redis 或标记失败时立即更新
+func (s *ArticleCounterService) MarkChanged(articleIDs ...uint) {
+	if len(articleIDs) == 0 {
+		return
+	}
+	if global.Redis != nil {
+		members := make([]interface{}, 0, len(articleIDs))
+		for _, id := range articleIDs {
+			members = append(members, strconv.FormatUint(uint64(id), 10))
+		}
+		err := global.Redis.SAdd(articleStatsDirtyKey, members...).Err()
+		if err == nil {
+			return
+		}
+		global.ZapLog.Warn("标记文章计数变化失败，立即更新索引", zap.Error(err))
+	}
+	go func() {
+		if err := s.syncCounters(articleIDs); err != nil {
+			global.ZapLog.Warn("更新索引中的文章计数失败", zap.Uints("article_ids", articleIDs), zap.Error(err))
+		}
+	}()
+}
+
+// Flush 把 Redis 中累计的阅读量写入数据库，再把计数有变化的文章的计数字段更新到索引
+// 返回写入阅读量的文章数和更新索引的文章数
+func (s *ArticleCounterService) Flush() (flushed, synced int, err error) {
+	if global.Redis == nil {
+		return 0, 0, nil
+	}
+	token, locked, err := acquireLock(articleCounterLockKey, articleCounterLockTTL)
+	if err != nil || !locked {
+		return 0, 0, err
+	}
+	defer releaseLock(articleCounterLockKey, token)
+
+	if flushed, err = s.flushViews(); err != nil {
+		return 0, 0, fmt.Errorf("写入阅读量失败: %v", err)
+	}
+	if synced, err = s.syncChanged(); err != nil {
+		return flushed, 0, fmt.Errorf("更新索引中的文章计数失败: %v", err)
+	}
+	return flushed, synced, nil
+}
+
+// releaseLockScript 锁的值仍是自己的令牌时才删除，避免删除锁过期后被其他进程获得的锁
+var releaseLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// acquireLock 获取写入任务的锁，返回写入锁中的随机令牌，释放时用于确认锁仍属于自己
+func acquireLock(key string, ttl time.Duration) (string, bool, error) {
+	token, err := randomToken(16)
+	if err != nil {
+		return "", false, err
+	}
+	locked, err := global.Redis.SetNX(key, token, ttl).Result()
+	return token, locked, err
+}
+
+// releaseLock 释放 acquireLock 获得的锁；任务超时、锁已过期时不做任何事
+func releaseLock(key, token string) {
+	if err := releaseLockScript.Run(global.Redis, []string{key}, token).Err(); err != nil {
+		global.ZapLog.Warn("释放锁失败", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// claimKey 把 pending 整体改名为 processing 后返回是否有待处理的数据；上次处理失败的数据还在时先处理这一批
+func claimKey(pending, processing string) (bool, error) {
+	exists, err := global.Redis.Exists(processing).Result()
+	if err != nil || exists > 0 {
+		return exists > 0, err
+	}
+	exists, err = global.Redis.Exists(pending).Result()
+	if err != nil || exists == 0 {
+		return false, err
+	}
+	return true, global.Redis.Rename(pending, processing).Err()
+}
+
+// flushViews 在一个事务中把累计的阅读量写入数据库，成功后删除 Redis 中的数据并标记这些文章
+// 数据库已提交但删除失败时，下次会重复累加这一批
+func (s *ArticleCounterService) flushViews() (int, error) {
+	ok, err := claimKey(articleViewsPendingKey, articleViewsFlushingKey)
+	if err != nil || !ok {
+		return 0, err
+	}
+	fields, err := global.Redis.HGetAll(articleViewsFlushingKey).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	deltas := make(map[uint]int64, len(fields))
+	ids := make([]uint, 0, len(fields))
+	for field, value := range fields {
+		id, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			continue
+		}
+		delta, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || delta <= 0 {
+			continue
+		}
+		deltas[uint(id)] = delta
+		ids = append(ids, uint(id))
+	}
+
+	err = global.DB.Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(ids); start += articleCounterBatch {
+			batch := ids[start:min(start+articleCounterBatch, len(ids))]
+			// 阅读量不影响文章的修改时间
+			var expr strings.Builder
+			args := make([]interface{}, 0, len(batch)*2)
+			expr.WriteString("view_count + CASE id")
+			for _, id := range batch {
+				expr.WriteString(" WHEN ? THEN ?")
+				args = append(args, id, deltas[id])
+			}
+			expr.WriteString(" ELSE 0 END")
+			if err := tx.Model(&database.Article{}).Where("id IN ?", batch).
+				UpdateColumn("view_count", gorm.Expr(expr.String(), args...)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	s.MarkChanged(ids...)
+	if err := global.Redis.Del(articleViewsFlushingKey).Err(); err != nil {
+		global.ZapLog.Warn("删除已写入的阅读量失败", zap.Error(err))
+	}
+	return len(ids), nil
+}
+
+// syncChanged 更新计数有变化的文章在索引中的计数字段，成功后删除 Redis 中的标记
+func (s *ArticleCounterService) syncChanged() (int, error) {
+	ok, err := claimKey(articleStatsDirtyKey, articleStatsSyncingKey)
+	if err != nil || !ok {
+		return 0, err
+	}
+	members, err := global.Redis.SMembers(articleStatsSyncingKey).Result()
+	if err != nil {
+		return 0, err
+	}
+	ids := make([]uint, 0, len(members))
+	for _, member := range members {
+		if id, err := strconv.ParseUint(member, 10, 64); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	if err := s.syncCounters(ids); err != nil {
+		return 0, err
+	}
+	if err := global.Redis.Del(articleStatsSyncingKey).Err(); err != nil {
+		global.ZapLog.Warn("删除已更新索引的文章标记失败", zap.Error(err))
+	}
+	return len(ids), nil
+}
+
+// articleCounters 索引中计数字段的部分更新，修改时间随点赞等操作变化，一起更新
+type articleCounters struct {
+	ViewCount     int       `json:"view_count"`
+	LikeCount     int       `json:"like_count"`
+	CommentCount  int       `json:"comment_count"`
+	FavoriteCount int       `json:"favorite_count"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// syncCounters 从数据库读取文章的计数，批量部分更新到索引
+// 只更新已发布的文章；不在索引中的文档跳过，由同步事件和一致性检查处理
+func (s *ArticleCounterService) syncCounters(ids []uint) error {
+	esService := NewArticleESService()
+	for start := 0; start < len(ids); start += articleCounterBatch {
+		batch := ids[start:min(start+articleCounterBatch, len(ids))]
+		var articles []database.Article
+		if err := global.DB.Select("id", "view_count", "like_count", "comment_count", "favorite_count", "updated_at").
+			Where("id IN ? AND status = ?", batch, 1).Find(&articles).Error; err != nil {
+			return err
+		}
+		if len(articles) == 0 {
+			continue
+		}
+
+		var body bytes.Buffer
+		for _, article := range articles {
+			doc, err := json.Marshal(map[string]articleCounters{"doc": {
+				ViewCount:     article.ViewCount,
+				LikeCount:     article.LikeCount,
+				CommentCount:  article.CommentCount,
+				FavoriteCount: article.FavoriteCount,
+				UpdatedAt:     article.UpdatedAt,
+			}})
+			if err != nil {
+				return err
+			}
+			writeBulkAction(&body, "update", article.ID)
+			body.Write(doc)
+			body.WriteByte('\n')
+		}
+		if err := esService.bulkIndex(esService.index, body.Bytes(), http.StatusNotFound); err != nil {
+			return err
+		}
+		// 重建索引期间同时更新新索引，否则切换别名后这段时间的计数变化会丢失
+		if target := esService.ReindexTarget(); target != "" {
+			if err := esService.bulkIndex(target, body.Bytes(), http.StatusNotFound); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"server/global"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"go.uber.org/zap"
+)
+
+func TestReleaseLockKeepsOtherHolder(t *testing.T) {
+	mr := miniredis.RunT(t)
+	global.Redis = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	global.ZapLog = zap.NewNop()
+	t.Cleanup(func() { global.Redis.Close() })
+
+	const key = "test:lock"
+	first, locked, err := acquireLock(key, time.Minute)
+	if err != nil || !locked {
+		t.Fatalf("获取锁失败: %v", err)
+	}
+	if _, locked, _ := acquireLock(key, time.Minute); locked {
+		t.Fatalf("锁被占用时不应再次获得")
+	}
+
+	// 第一个任务超时、锁过期后被其他进程获得，第一个任务结束时不能删除别人的锁
+	mr.FastForward(time.Minute)
+	second, locked, err := acquireLock(key, time.Minute)
+	if err != nil || !locked {
+		t.Fatalf("锁过期后获取失败: %v", err)
+	}
+	releaseLock(key, first)
+	if got, _ := mr.Get(key); got != second {
+		t.Errorf("其他进程持有的锁被删除了")
+	}
+	releaseLock(key, second)
+	if mr.Exists(key) {
+		t.Errorf("持有者释放后锁应被删除")
+	}
+}
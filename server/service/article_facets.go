@@ -0,0 +1,366 @@
+package service
+
+import (
+	"fmt"
+	"server/global"
+	"server/model/database"
+	"server/model/es"
+	"server/model/request"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types/enums/calendarinterval"
+	"gorm.io/gorm"
+)
+
+// searchFacetSize 每个分面最多返回的桶数
+const searchFacetSize = 30
+
+// 分面名称，也是 ES 聚合的名称
+const (
+	facetCategories = "categories"
+	facetTags       = "tags"
+	facetAuthors    = "authors"
+	facetMonths     = "months"
+)
+
+// searchFilters 搜索的分面筛选条件；同一分面内多选为“或”，不同分面之间为“且”
+type searchFilters struct {
+	categories []uint
+	tags       []string
+	authors    []uint
+	from, to   *time.Time // 发布时间范围 [from, to)
+}
+
+// parseSearchFilters 合并单选和多选参数，解析日期范围（结束日期当天包含在内）
+func parseSearchFilters(req request.SearchArticleRequest) (searchFilters, error) {
+	f := searchFilters{
+		categories: append([]uint{}, req.CategoryIDs...),
+		tags:       append([]string{}, req.Tags...),
+		authors:    append([]uint{}, req.AuthorIDs...),
+	}
+	if req.CategoryID > 0 {
+		f.categories = append(f.categories, req.CategoryID)
+	}
+	if req.Tag != "" {
+		f.tags = append(f.tags, req.Tag)
+	}
+	if req.DateFrom != "" {
+		from, err := time.ParseInLocation("2006-01-02", req.DateFrom, time.Local)
+		if err != nil {
+			return f, fmt.Errorf("开始日期格式错误: %v", err)
+		}
+		f.from = &from
+	}
+	if req.DateTo != "" {
+		to, err := time.ParseInLocation("2006-01-02", req.DateTo, time.Local)
+		if err != nil {
+			return f, fmt.Errorf("结束日期格式错误: %v", err)
+		}
+		to = to.AddDate(0, 0, 1)
+		f.to = &to
+	}
+	return f, nil
+}
+
+// signature 筛选条件的规范文本，用于搜索统计，如 "category=1,2;tag=go;from=2024-01-01"；没有筛选时为空字符串
+func (f searchFilters) signature() string {
+	var parts []string
+	join := func(name string, values []string) {
+		if len(values) > 0 {
+			sort.Strings(values)
+			parts = append(parts, name+"="+strings.Join(values, ","))
+		}
+	}
+	ids := func(list []uint) []string {
+		values := make([]string, 0, len(list))
+		for _, id := range list {
+			values = append(values, strconv.FormatUint(uint64(id), 10))
+		}
+		return values
+	}
+	join("category", ids(f.categories))
+	join("tag", append([]string{}, f.tags...))
+	join("author", ids(f.authors))
+	if f.from != nil {
+		parts = append(parts, "from="+f.from.Format("2006-01-02"))
+	}
+	if f.to != nil {
+		parts = append(parts, "to="+f.to.AddDate(0, 0, -1).Format("2006-01-02"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// esQueries 转换为 ES 筛选条件，exclude 指定的分面不参与筛选（用于计算该分面自身的计数）
+func (f searchFilters) esQueries(exclude string) []types.Query {
+	var queries []types.Query
+	if len(f.categories) > 0 && exclude != facetCategories {
+		queries = append(queries, types.Query{Terms: &types.TermsQuery{TermsQuery: map[string]types.TermsQueryField{"category_id": f.categories}}})
+	}
+	if len(f.tags) > 0 && exclude != facetTags {
+		queries = append(queries, types.Query{Terms: &types.TermsQuery{TermsQuery: map[string]types.TermsQueryField{"tags": f.tags}}})
+	}
+	if len(f.authors) > 0 && exclude != facetAuthors {
+		queries = append(queries, types.Query{Terms: &types.TermsQuery{TermsQuery: map[string]types.TermsQueryField{"user_id": f.authors}}})
+	}
+	if (f.from != nil || f.to != nil) && exclude != facetMonths {
+		dateRange := types.DateRangeQuery{}
+		if f.from != nil {
+			from := f.from.Format(time.RFC3339)
+			dateRange.Gte = &from
+		}
+		if f.to != nil {
+			to := f.to.Format(time.RFC3339)
+			dateRange.Lt = &to
+		}
+		queries = append(queries, types.Query{Range: map[string]types.RangeQuery{"created_at": dateRange}})
+	}
+	return queries
+}
+
+// esFacetAggregations 分面聚合：每个分面在除自身以外的筛选条件下计数，多选时其他选项的计数不会变成0
+func (f searchFilters) esFacetAggregations() map[string]types.Aggregations {
+	size := searchFacetSize
+	minDocCount := 1
+	categoryField, tagField, authorField, dateField := "category_id", "tags", "user_id", "created_at"
+	month := calendarinterval.Month
+	monthFormat := "yyyy-MM"
+	timeZone := time.Now().Format("-07:00")
+
+	inner := map[string]types.Aggregations{
+		facetCategories: {Terms: &types.TermsAggregation{Field: &categoryField, Size: &size}},
+		facetTags:       {Terms: &types.TermsAggregation{Field: &tagField, Size: &size}},
+		facetAuthors:    {Terms: &types.TermsAggregation{Field: &authorField, Size: &size}},
+		facetMonths: {DateHistogram: &types.DateHistogramAggregation{
+			Field:            &dateField,
+			CalendarInterval: &month,
+			Format:           &monthFormat,
+			TimeZone:         &timeZone,
+			MinDocCount:      &minDocCount,
+		}},
+	}
+	aggregations := make(map[string]types.Aggregations, len(inner))
+	for name, aggregation := range inner {
+		aggregations[name] = types.Aggregations{
+			Filter:       &types.Query{Bool: &types.BoolQuery{Filter: f.esQueries(name)}},
+			Aggregations: map[string]types.Aggregations{name: aggregation},
+		}
+	}
+	return aggregations
+}
+
+// facetCounts 分面名称 -> 桶的键 -> 文档数
+type facetCounts map[string]map[string]int64
+
+// parseESFacets 解析分面聚合结果
+func parseESFacets(aggregations map[string]types.Aggregate) facetCounts {
+	counts := make(facetCounts)
+	for name, aggregate := range aggregations {
+		filter, ok := aggregate.(*types.FilterAggregate)
+		if !ok {
+			continue
+		}
+		buckets := make(map[string]int64)
+		switch inner := filter.Aggregations[name].(type) {
+		case *types.LongTermsAggregate:
+			if list, ok := inner.Buckets.([]types.LongTermsBucket); ok {
+				for _, bucket := range list {
+					buckets[strconv.FormatInt(bucket.Key, 10)] = bucket.DocCount
+				}
+			}
+		case *types.StringTermsAggregate:
+			if list, ok := inner.Buckets.([]types.StringTermsBucket); ok {
+				for _, bucket := range list {
+					buckets[fmt.Sprint(bucket.Key)] = bucket.DocCount
+				}
+			}
+		case *types.DateHistogramAggregate:
+			if list, ok := inner.Buckets.([]types.DateHistogramBucket); ok {
+				for _, bucket := range list {
+					if bucket.KeyAsString != nil {
+						buckets[*bucket.KeyAsString] = bucket.DocCount
+					}
+				}
+			}
+		}
+		counts[name] = buckets
+	}
+	return counts
+}
+
+// mysqlFacetCounts MySQL 降级搜索的分面计数，与 ES 一样每个分面排除自身的筛选条件
+func mysqlFacetCounts(keyword string, f searchFilters) (facetCounts, error) {
+	type row struct {
+		Key   string
+		Count int64
+	}
+	facets := []struct {
+		name  string
+		query func(db *gorm.DB) *gorm.DB
+	}{
+		{facetCategories, func(db *gorm.DB) *gorm.DB {
+			return db.Select("articles.category_id AS `key`, COUNT(*) AS count").Group("articles.category_id")
+		}},
+		{facetTags, func(db *gorm.DB) *gorm.DB {
+			return db.Joins("JOIN article_tags ON article_tags.article_id = articles.id").
+				Joins("JOIN tags ON tags.id = article_tags.tag_id").
+				Select("tags.name AS `key`, COUNT(DISTINCT articles.id) AS count").Group("tags.name")
+		}},
+		{facetAuthors, func(db *gorm.DB) *gorm.DB {
+			return db.Select("articles.author_id AS `key`, COUNT(*) AS count").Group("articles.author_id")
+		}},
+		{facetMonths, func(db *gorm.DB) *gorm.DB {
+			return db.Select("DATE_FORMAT(articles.created_at, '%Y-%m') AS `key`, COUNT(*) AS count").Group("`key`")
+		}},
+	}
+
+	counts := make(facetCounts)
+	for _, facet := range facets {
+		var rows []row
+		query := facet.query(applyMySQLSearchFilters(mysqlSearchBase(keyword), f, facet.name))
+		// 月份和ES的日期直方图一样返回全部，年份由月份汇总，截断后年份计数会偏少
+		if facet.name != facetMonths {
+			query = query.Order("count DESC").Limit(searchFacetSize)
+		}
+		if err := query.Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		buckets := make(map[string]int64, len(rows))
+		for _, r := range rows {
+			buckets[r.Key] = r.Count
+		}
+		counts[facet.name] = buckets
+	}
+	return counts, nil
+}
+
+// mysqlSearchBase 已发布文章的关键词模糊查询
+func mysqlSearchBase(keyword string) *gorm.DB {
+	query := global.DB.Model(&database.Article{}).Where("articles.status = ?", 1)
+	if keyword != "" {
+		like := "%" + keyword + "%"
+		query = query.Joins("JOIN users ON articles.author_id = users.id").
+			Where("articles.title LIKE ? OR articles.content LIKE ? OR articles.summary LIKE ? OR users.username LIKE ? OR users.nickname LIKE ?",
+				like, like, like, like, like)
+	}
+	return query
+}
+
+// applyMySQLSearchFilters 添加分面筛选条件，exclude 指定的分面不参与筛选
+func applyMySQLSearchFilters(query *gorm.DB, f searchFilters, exclude string) *gorm.DB {
+	if len(f.categories) > 0 && exclude != facetCategories {
+		query = query.Where("articles.category_id IN ?", f.categories)
+	}
+	if len(f.tags) > 0 && exclude != facetTags {
+		query = query.Where("articles.id IN (?)", global.DB.Table("article_tags").
+			Select("article_tags.article_id").
+			Joins("JOIN tags ON tags.id = article_tags.tag_id").
+			Where("tags.name IN ?", f.tags))
+	}
+	if len(f.authors) > 0 && exclude != facetAuthors {
+		query = query.Where("articles.author_id IN ?", f.authors)
+	}
+	if exclude != facetMonths {
+		if f.from != nil {
+			query = query.Where("articles.created_at >= ?", *f.from)
+		}
+		if f.to != nil {
+			query = query.Where("articles.created_at < ?", *f.to)
+		}
+	}
+	return query
+}
+
+// buildSearchFacets 把计数转换为带名称和选中状态的分面，年份由月份汇总得到
+func buildSearchFacets(counts facetCounts, f searchFilters) *es.SearchFacets {
+	facets := &es.SearchFacets{
+		Categories: []es.FacetBucket{},
+		Tags:       []es.FacetBucket{},
+		Authors:    []es.FacetBucket{},
+		Years:      []es.FacetBucket{},
+		Months:     []es.FacetBucket{},
+	}
+
+	selectedIDs := func(ids []uint) map[string]bool {
+		selected := make(map[string]bool, len(ids))
+		for _, id := range ids {
+			selected[strconv.FormatUint(uint64(id), 10)] = true
+		}
+		return selected
+	}
+
+	// 分类和作者名称
+	categoryNames := make(map[string]string)
+	if ids := facetKeys(counts[facetCategories]); len(ids) > 0 {
+		var categories []database.Category
+		global.DB.Select("id", "name").Where("id IN ?", ids).Find(&categories)
+		for _, category := range categories {
+			categoryNames[strconv.FormatUint(uint64(category.ID), 10)] = category.Name
+		}
+	}
+	authorNames := make(map[string]string)
+	if ids := facetKeys(counts[facetAuthors]); len(ids) > 0 {
+		var users []database.User
+		global.DB.Select("id", "username", "nickname").Where("id IN ?", ids).Find(&users)
+		for _, user := range users {
+			name := user.Nickname
+			if name == "" {
+				name = user.Username
+			}
+			authorNames[strconv.FormatUint(uint64(user.ID), 10)] = name
+		}
+	}
+
+	selectedCategories := selectedIDs(f.categories)
+	for key, count := range counts[facetCategories] {
+		facets.Categories = append(facets.Categories, es.FacetBucket{Key: key, Label: categoryNames[key], Count: count, Selected: selectedCategories[key]})
+	}
+	selectedTags := make(map[string]bool)
+	for _, tag := range f.tags {
+		selectedTags[tag] = true
+	}
+	for key, count := range counts[facetTags] {
+		facets.Tags = append(facets.Tags, es.FacetBucket{Key: key, Label: key, Count: count, Selected: selectedTags[key]})
+	}
+	selectedAuthors := selectedIDs(f.authors)
+	for key, count := range counts[facetAuthors] {
+		facets.Authors = append(facets.Authors, es.FacetBucket{Key: key, Label: authorNames[key], Count: count, Selected: selectedAuthors[key]})
+	}
+
+	years := make(map[string]int64)
+	for key, count := range counts[facetMonths] {
+		facets.Months = append(facets.Months, es.FacetBucket{Key: key, Label: key, Count: count})
+		if len(key) >= 4 {
+			years[key[:4]] += count
+		}
+	}
+	for key, count := range years {
+		facets.Years = append(facets.Years, es.FacetBucket{Key: key, Label: key, Count: count})
+	}
+
+	// 分类、标签、作者按数量降序，时间按先后倒序
+	for _, buckets := range [][]es.FacetBucket{facets.Categories, facets.Tags, facets.Authors} {
+		sort.Slice(buckets, func(i, j int) bool {
+			if buckets[i].Count != buckets[j].Count {
+				return buckets[i].Count > buckets[j].Count
+			}
+			return buckets[i].Key < buckets[j].Key
+		})
+	}
+	for _, buckets := range [][]es.FacetBucket{facets.Years, facets.Months} {
+		sort.Slice(buckets, func(i, j int) bool { return buckets[i].Key > buckets[j].Key })
+	}
+	return facets
+}
+
+// facetKeys 分面中全部桶的键
+func facetKeys(buckets map[string]int64) []string {
+	keys := make([]string, 0, len(buckets))
+	for key := range buckets {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,456 @@
+package service
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"path"
+	"server/global"
+	"server/model/appType"
+	"server/model/database"
+	"server/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// defaultImportCategory 导入时未指定分类的文章归入的分类
+const defaultImportCategory = "未分类"
+
+// 导入单篇文章的处理结果
+const (
+	MarkdownImportCreate = "create"
+	MarkdownImportUpdate = "update"
+	MarkdownImportError  = "error"
+)
+
+// ArticleMarkdownService 文章 Markdown 导入导出服务，文件格式为 YAML 头部元数据加正文
+type ArticleMarkdownService struct{}
+
+// MarkdownImportOptions 导入选项
+type MarkdownImportOptions struct {
+	AuthorID uint // 新建文章的作者，也是上传图片的所有者
+	DryRun   bool // 只检查和统计，不写入数据库和文件
+}
+
+// MarkdownImportItem 单个文件的导入结果
+type MarkdownImportItem struct {
+	File          string   `json:"file"`
+	Slug          string   `json:"slug,omitempty"`
+	Title         string   `json:"title,omitempty"`
+	Action        string   `json:"action"`
+	ArticleID     uint     `json:"article_id,omitempty"`
+	Images        int      `json:"images"`                   // 上传（预演时为待上传）的本地图片数
+	MissingImages []string `json:"missing_images,omitempty"` // 引用了但找不到的本地图片
+	Error         string   `json:"error,omitempty"`
+}
+
+// MarkdownImportReport 导入报告
+type MarkdownImportReport struct {
+	DryRun            bool                 `json:"dry_run"`
+	Created           int                  `json:"created"`
+	Updated           int                  `json:"updated"`
+	Failed            int                  `json:"failed"`
+	CreatedTags       []string             `json:"created_tags"`
+	CreatedCategories []string             `json:"created_categories"`
+	Items             []MarkdownImportItem `json:"items"`
+}
+
+// Export 导出全部文章（包括草稿），每篇文章生成一个以 slug 命名的 Markdown 文件，通过 write 写出
+func (s *ArticleMarkdownService) Export(write func(name string, data []byte) error) (count int, err error) {
+	var articles []database.Article
+	result := global.DB.Preload("Category").Preload("Tags").Order("id").FindInBatches(&articles, 100, func(tx *gorm.DB, batch int) error {
+		for _, article := range articles {
+			data, err := utils.RenderMarkdown(ArticleFrontMatter(article), article.Content)
+			if err != nil {
+				return err
+			}
+			name := utils.SafeFileName(article.Slug)
+			if name == "" {
+				name = fmt.Sprintf("article-%d", article.ID)
+			}
+			if err := write(name+".md", data); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, result.Error
+}
+
+// ExportZip 把全部文章导出为 zip 压缩包
+func (s *ArticleMarkdownService) ExportZip(w io.Writer) (int, error) {
+	archive := zip.NewWriter(w)
+	count, err := s.Export(func(name string, data []byte) error {
+		return writeZipFile(archive, name, data)
+	})
+	if err != nil {
+		archive.Close()
+		return count, err
+	}
+	return count, archive.Close()
+}
+
+// markdownImporter 一次导入过程的状态
+type markdownImporter struct {
+	fsys       fs.FS
+	opts       MarkdownImportOptions
+	report     *MarkdownImportReport
+	uploaded   map[string]string // 已上传的本地图片路径 -> 站内地址，同一图片只上传一次
+	planned    map[string]bool   // 预演时已计划新建的标签和分类，避免重复计入
+	articleSvc ArticleService
+}
+
+// Import 从文件系统导入全部 .md 文件，按 slug 新建或更新文章
+// 单个文件出错不影响其他文件，错误记录在报告中
+func (s *ArticleMarkdownService) Import(fsys fs.FS, opts MarkdownImportOptions) (MarkdownImportReport, error) {
+	report := MarkdownImportReport{DryRun: opts.DryRun, CreatedTags: []string{}, CreatedCategories: []string{}, Items: []MarkdownImportItem{}}
+	if opts.AuthorID == 0 {
+		return report, errors.New("未指定文章作者")
+	}
+
+	var files []string
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		base := d.Name()
+		if d.IsDir() {
+			// 跳过隐藏目录和 macOS 压缩包附带的元数据目录
+			if name != "." && (strings.HasPrefix(base, ".") || base == "__MACOSX") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasPrefix(base, ".") && strings.EqualFold(path.Ext(base), ".md") {
+			files = append(files, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	im := &markdownImporter{
+		fsys:     fsys,
+		opts:     opts,
+		report:   &report,
+		uploaded: make(map[string]string),
+		planned:  make(map[string]bool),
+	}
+	for _, name := range files {
+		item := im.importFile(name)
+		switch item.Action {
+		case MarkdownImportCreate:
+			report.Created++
+		case MarkdownImportUpdate:
+			report.Updated++
+		default:
+			report.Failed++
+		}
+		report.Items = append(report.Items, item)
+	}
+	return report, nil
+}
+
+// importFile 导入单个文件
+func (im *markdownImporter) importFile(name string) (item MarkdownImportItem) {
+	item = MarkdownImportItem{File: name, Action: MarkdownImportError}
+
+	data, err := fs.ReadFile(im.fsys, name)
+	if err != nil {
+		item.Error = err.Error()
+		return item
+	}
+	fm, body, err := utils.ParseMarkdown(data)
+	if err != nil {
+		item.Error = "解析头部元数据失败: " + err.Error()
+		return item
+	}
+
+	// 缺省时用文件名作为 slug 和标题，便于导入没有完整元数据的文件
+	fileBase := strings.TrimSuffix(path.Base(name), path.Ext(name))
+	if fm.Slug == "" {
+		fm.Slug = fileBase
+	}
+	if fm.Title == "" {
+		fm.Title = fileBase
+	}
+	item.Slug, item.Title = fm.Slug, fm.Title
+
+	var existing database.Article
+	err = global.DB.Unscoped().Where("slug = ?", fm.Slug).First(&existing).Error
+	switch {
+	case err == nil && existing.DeletedAt.Valid:
+		item.Error = "同 slug 的文章在回收站中，请先恢复或彻底删除"
+		return item
+	case err == nil:
+		item.Action = MarkdownImportUpdate
+		item.ArticleID = existing.ID
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		item.Action = MarkdownImportCreate
+	default:
+		item.Error = err.Error()
+		item.Action = MarkdownImportError
+		return item
+	}
+
+	body, fm.Cover, err = im.rewriteImages(name, body, fm.Cover, &item)
+	if err != nil {
+		item.Error = err.Error()
+		item.Action = MarkdownImportError
+		return item
+	}
+
+	if im.opts.DryRun {
+		im.planCategory(fm.Category, item.Action == MarkdownImportCreate)
+		for _, tag := range fm.Tags {
+			im.planTag(tag)
+		}
+		return item
+	}
+
+	article, err := im.save(fm, body, existing, item.Action == MarkdownImportCreate)
+	if err != nil {
+		item.Error = err.Error()
+		item.Action = MarkdownImportError
+		return item
+	}
+	item.ArticleID = article.ID
+
+	// 在导入过程中投递，命令行导入结束后进程可能直接退出；失败的事件由定时任务重试
+	(&ESOutboxService{}).DeliverArticle(article.ID)
+	return item
+}
+
+// rewriteImages 上传正文和封面引用的本地图片，并把引用改写为站内地址
+func (im *markdownImporter) rewriteImages(name, body, cover string, item *MarkdownImportItem) (string, string, error) {
+	refs := utils.MarkdownImageRefs(body)
+	if cover != "" {
+		refs = append(refs, cover)
+	}
+
+	replacements := make(map[string]string)
+	for _, ref := range refs {
+		if _, done := replacements[ref]; done || !utils.IsLocalImageRef(ref) {
+			continue
+		}
+		local := resolveImportPath(name, ref)
+		if _, err := fs.Stat(im.fsys, local); err != nil {
+			item.MissingImages = append(item.MissingImages, ref)
+			continue
+		}
+		if im.opts.DryRun {
+			replacements[ref] = ref
+			item.Images++
+			continue
+		}
+
+		target, ok := im.uploaded[local]
+		if !ok {
+			file, err := im.fsys.Open(local)
+			if err != nil {
+				return body, cover, err
+			}
+			media, err := SaveImageFile(file, local, im.opts.AuthorID)
+			file.Close()
+			if err != nil {
+				return body, cover, fmt.Errorf("上传图片 %s 失败: %v", ref, err)
+			}
+			target = fmt.Sprintf("/api/image/show/%d", media.ID)
+			im.uploaded[local] = target
+		}
+		replacements[ref] = target
+		item.Images++
+	}
+
+	if target, ok := replacements[cover]; ok {
+		cover = target
+	}
+	return utils.ReplaceMarkdownImageRefs(body, replacements), cover, nil
+}
+
+// resolveImportPath 把图片的相对引用解析为导入目录中的路径
+func resolveImportPath(name, ref string) string {
+	if i := strings.IndexAny(ref, "?#"); i >= 0 {
+		ref = ref[:i]
+	}
+	if unescaped, err := url.PathUnescape(ref); err == nil {
+		ref = unescaped
+	}
+	return path.Join(path.Dir(name), ref)
+}
+
+// planCategory 预演时记录将要新建的分类
+func (im *markdownImporter) planCategory(name string, creating bool) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		// 更新时保留原分类，新建时归入默认分类
+		if !creating {
+			return
+		}
+		name = defaultImportCategory
+	}
+	key := "category:" + name
+	if im.planned[key] {
+		return
+	}
+	im.planned[key] = true
+	var count int64
+	global.DB.Model(&database.Category{}).Where("name = ?", name).Count(&count)
+	if count == 0 {
+		im.report.CreatedCategories = append(im.report.CreatedCategories, name)
+	}
+}
+
+// planTag 预演时记录将要新建的标签
+func (im *markdownImporter) planTag(name string) {
+	name = strings.TrimSpace(name)
+	key := "tag:" + name
+	if name == "" || im.planned[key] {
+		return
+	}
+	im.planned[key] = true
+	var count int64
+	global.DB.Model(&database.Tag{}).Where("name = ?", name).Count(&count)
+	if count == 0 {
+		im.report.CreatedTags = append(im.report.CreatedTags, name)
+	}
+}
+
+// findOrCreateCategory 按名称查找分类，不存在时新建，已软删除的分类会被恢复
+func (im *markdownImporter) findOrCreateCategory(tx *gorm.DB, name string) (uint, error) {
+	var category database.Category
+	err := tx.Unscoped().Where("name = ?", name).First(&category).Error
+	if err == nil {
+		if category.DeletedAt.Valid {
+			if err := tx.Unscoped().Model(&category).Update("deleted_at", nil).Error; err != nil {
+				return 0, err
+			}
+		}
+		return category.ID, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+
+	category = database.Category{Name: name, Slug: generateSlug(name)}
+	if err := tx.Create(&category).Error; err != nil {
+		return 0, errors.New("创建分类失败: " + err.Error())
+	}
+	im.report.CreatedCategories = append(im.report.CreatedCategories, name)
+	return category.ID, nil
+}
+
+// save 在事务中新建或更新文章，并重建标签关联
+func (im *markdownImporter) save(fm utils.FrontMatter, body string, article database.Article, creating bool) (database.Article, error) {
+	status := uint8(1)
+	if fm.Draft {
+		status = 0
+	}
+
+	err := global.DB.Transaction(func(tx *gorm.DB) error {
+		categoryID := article.CategoryID
+		if name := strings.TrimSpace(fm.Category); name != "" || creating {
+			if name == "" {
+				name = defaultImportCategory
+			}
+			id, err := im.findOrCreateCategory(tx, name)
+			if err != nil {
+				return err
+			}
+			categoryID = id
+		}
+
+		updatedAt := fm.Updated
+		if updatedAt.IsZero() {
+			updatedAt = time.Now()
+		}
+
+		if creating {
+			article = database.Article{
+				BaseModelWithStatus: database.BaseModelWithStatus{Status: status},
+				Title:               fm.Title,
+				Slug:                fm.Slug,
+				Content:             body,
+				Summary:             fm.Summary,
+				CoverImage:          fm.Cover,
+				AuthorID:            im.opts.AuthorID,
+				CategoryID:          categoryID,
+			}
+			article.CreatedAt = fm.Date
+			article.UpdatedAt = updatedAt
+			if err := tx.Create(&article).Error; err != nil {
+				return err
+			}
+			// 状态为0时显式更新，避免被字段默认值覆盖
+			if status == 0 {
+				if err := tx.Model(&article).UpdateColumn("status", 0).Error; err != nil {
+					return err
+				}
+			}
+		} else {
+			updates := map[string]interface{}{
+				"title":       fm.Title,
+				"content":     body,
+				"summary":     fm.Summary,
+				"cover_image": fm.Cover,
+				"category_id": categoryID,
+				"status":      status,
+				"updated_at":  updatedAt,
+			}
+			if !fm.Date.IsZero() {
+				updates["created_at"] = fm.Date
+			}
+			if err := tx.Model(&article).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := im.replaceTags(tx, article.ID, fm.Tags); err != nil {
+			return err
+		}
+		return enqueueArticleSync(tx, article.ID, appType.ESOutboxIndex)
+	})
+	article.Status = status
+	return article, err
+}
+
+// replaceTags 用导入文件中的标签替换文章原有标签，记录新建的标签
+func (im *markdownImporter) replaceTags(tx *gorm.DB, articleID uint, names []string) error {
+	var oldTagIDs []uint
+	if err := tx.Model(&database.ArticleTag{}).Where("article_id = ?", articleID).Pluck("tag_id", &oldTagIDs).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("article_id = ?", articleID).Delete(&database.ArticleTag{}).Error; err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || im.planned["tag:"+name] {
+			continue
+		}
+		im.planned["tag:"+name] = true
+		var count int64
+		if err := tx.Model(&database.Tag{}).Where("name = ?", name).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			im.report.CreatedTags = append(im.report.CreatedTags, name)
+		}
+	}
+
+	if err := im.articleSvc.handleArticleTags(tx, articleID, nil, names); err != nil {
+		return err
+	}
+	if err := im.articleSvc.cleanupOrphanTags(tx, oldTagIDs); err != nil {
+		return err
+	}
+	return im.articleSvc.recountArticleTags(tx, articleID, oldTagIDs)
+}
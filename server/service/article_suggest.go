@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"server/global"
+	"server/model/appType"
+	"server/model/database"
+	"server/model/es"
+	"server/model/request"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types/enums/suggestmode"
+	"github.com/go-redis/redis"
+	"go.uber.org/zap"
+)
+
+// 自动补全与热门搜索词的参数
+const (
+	suggestDefaultSize    = 8
+	popularQueryKey       = "search:popular_queries" // 热门搜索词的有序集合，分数为去重后的搜索人次
+	popularQueryMax       = 1000                     // 保留的热门搜索词数量，超过两倍时裁剪
+	popularQueryScan      = 200                      // 按前缀匹配热门搜索词时查看的条数
+	popularQueryMaxLength = 50                       // 超过该长度的搜索词不记录
+)
+
+// normalizeQuery 规范化搜索词：去掉首尾空白、合并连续空白并转为小写
+func normalizeQuery(keyword string) string {
+	return strings.ToLower(strings.Join(strings.Fields(keyword), " "))
+}
+
+// RecordSearchQuery 记录一次有结果的搜索，用于热门搜索词建议
+// 同一访客（如客户端IP）在去重时间内重复搜索同一个词只计一次，避免个别访客刷出热门搜索词
+func (s *ArticleService) RecordSearchQuery(keyword, visitor string) {
+	query := normalizeQuery(keyword)
+	if query == "" || utf8.RuneCountInString(query) > popularQueryMaxLength || global.Redis == nil {
+		return
+	}
+	sum := sha1.Sum([]byte(visitor + "\n" + query))
+	fresh, err := global.Redis.SetNX("search:popular:seen:"+hex.EncodeToString(sum[:]), 1, global.Config.Search.PopularDedupeWindow()).Result()
+	if err != nil || !fresh {
+		return
+	}
+	if err := global.Redis.ZIncrBy(popularQueryKey, 1, query).Err(); err != nil {
+		global.ZapLog.Warn("记录热门搜索词失败", zap.Error(err))
+		return
+	}
+	// 只在数量明显超出时裁剪，避免新搜索词刚写入就被裁掉
+	if count, err := global.Redis.ZCard(popularQueryKey).Result(); err == nil && count > popularQueryMax*2 {
+		global.Redis.ZRemRangeByRank(popularQueryKey, 0, -popularQueryMax-1)
+	}
+}
+
+// popularQueries 返回以 prefix 开头的热门搜索词，prefix 为空时返回最热门的搜索词；搜索人次不足的搜索词不返回
+func (s *ArticleService) popularQueries(prefix string, size int) []string {
+	if global.Redis == nil {
+		return nil
+	}
+	scan := int64(size)
+	if prefix != "" {
+		scan = popularQueryScan
+	}
+	queries, err := global.Redis.ZRevRangeByScore(popularQueryKey, redis.ZRangeBy{
+		Min:   strconv.Itoa(global.Config.Search.PopularMinVisitors()),
+		Max:   "+inf",
+		Count: scan,
+	}).Result()
+	if err != nil {
+		global.ZapLog.Warn("读取热门搜索词失败", zap.Error(err))
+		return nil
+	}
+	prefix = normalizeQuery(prefix)
+	var result []string
+	for _, query := range queries {
+		if strings.HasPrefix(query, prefix) {
+			result = append(result, query)
+			if len(result) >= size {
+				break
+			}
+		}
+	}
+	return result
+}
+
+// SuggestArticles 搜索框自动补全：前缀为空时返回热门搜索词，否则返回匹配的文章标题、标签和热门搜索词
+// 标题和标签的匹配允许少量输入错误；ES 不可用时降级到 MySQL 前缀匹配
+func (s *ArticleService) SuggestArticles(req request.SuggestArticleRequest) ([]es.ArticleSuggestion, error) {
+	if req.Size <= 0 {
+		req.Size = suggestDefaultSize
+	}
+	prefix := strings.TrimSpace(req.Prefix)
+
+	suggestions := []es.ArticleSuggestion{}
+	seen := make(map[string]bool)
+	add := func(text, kind string, articleID uint64) {
+		key := strings.ToLower(text)
+		if text == "" || seen[key] || len(suggestions) >= req.Size {
+			return
+		}
+		seen[key] = true
+		suggestions = append(suggestions, es.ArticleSuggestion{Text: text, Type: kind, ArticleID: articleID})
+	}
+
+	if prefix != "" {
+		matched, err := s.suggestFromES(prefix, req.Size)
+		if err != nil {
+			global.ZapLog.Warn("ES自动补全失败，降级到MySQL", zap.Error(err))
+			if matched, err = s.suggestFromMySQL(prefix, req.Size); err != nil {
+				return nil, err
+			}
+		}
+		for _, suggestion := range matched {
+			add(suggestion.Text, suggestion.Type, suggestion.ArticleID)
+		}
+	}
+	for _, query := range s.popularQueries(prefix, req.Size) {
+		add(query, es.SuggestTypeQuery, 0)
+	}
+	return suggestions, nil
+}
+
+// suggestFromES 用补全建议器匹配标题和标签的前缀，只保留已发布文章的结果
+func (s *ArticleService) suggestFromES(prefix string, size int) ([]es.ArticleSuggestion, error) {
+	// 补全建议器不支持筛选条件，多取一些再过滤掉未发布的文章
+	candidates := size * 3
+	skipDuplicates, unicodeAware := true, true
+	prefixLength := 1
+	fuzzy := &types.SuggestFuzziness{Fuzziness: "AUTO", PrefixLength: &prefixLength, UnicodeAware: &unicodeAware}
+	completion := func(field string) types.FieldSuggester {
+		return types.FieldSuggester{
+			Prefix: &prefix,
+			Completion: &types.CompletionSuggester{
+				Field:          field,
+				Size:           &candidates,
+				SkipDuplicates: &skipDuplicates,
+				Fuzzy:          fuzzy,
+			},
+		}
+	}
+
+	index := (&es.ArticleES{}).IndexName()
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	resp, err := global.ES.Search().
+		Index(index).
+		Size(0).
+		Suggest(&types.Suggester{Suggesters: map[string]types.FieldSuggester{
+			es.SuggestTypeTitle: completion("title." + es.SuggestSubField),
+			es.SuggestTypeTag:   completion("tags." + es.SuggestSubField),
+		}}).
+		TypedKeys(true).
+		SourceIncludes_("status").
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var suggestions []es.ArticleSuggestion
+	for _, kind := range []string{es.SuggestTypeTitle, es.SuggestTypeTag} {
+		for _, entry := range resp.Suggest[kind] {
+			completion, ok := entry.(*types.CompletionSuggest)
+			if !ok {
+				continue
+			}
+			for _, option := range completion.Options {
+				var source struct {
+					Status appType.ArticleStatus `json:"status"`
+				}
+				if err := json.Unmarshal(option.Source_, &source); err != nil || source.Status != appType.StatusPublished {
+					continue
+				}
+				suggestion := es.ArticleSuggestion{Text: option.Text, Type: kind}
+				if kind == es.SuggestTypeTitle && option.Id_ != nil {
+					suggestion.ArticleID, _ = strconv.ParseUint(*option.Id_, 10, 64)
+				}
+				suggestions = append(suggestions, suggestion)
+			}
+		}
+	}
+	return suggestions, nil
+}
+
+// suggestFromMySQL ES 不可用时按前缀匹配已发布文章的标题和标签名
+func (s *ArticleService) suggestFromMySQL(prefix string, size int) ([]es.ArticleSuggestion, error) {
+	like := strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(prefix) + "%"
+
+	var articles []database.Article
+	if err := global.DB.Select("id", "title").
+		Where("status = ? AND title LIKE ?", 1, like).
+		Order("view_count DESC").Limit(size).Find(&articles).Error; err != nil {
+		return nil, err
+	}
+	var tags []string
+	if err := global.DB.Model(&database.Tag{}).
+		Where("name LIKE ?", like).
+		Order("name").Limit(size).Pluck("name", &tags).Error; err != nil {
+		return nil, err
+	}
+
+	var suggestions []es.ArticleSuggestion
+	for _, article := range articles {
+		suggestions = append(suggestions, es.ArticleSuggestion{Text: article.Title, Type: es.SuggestTypeTitle, ArticleID: uint64(article.ID)})
+	}
+	for _, tag := range tags {
+		suggestions = append(suggestions, es.ArticleSuggestion{Text: tag, Type: es.SuggestTypeTag})
+	}
+	return suggestions, nil
+}
+
+// didYouMean 用标题的纠错子字段为没有结果的搜索词生成一条短语建议，并确认建议的短语能搜到文章
+func (s *ArticleService) didYouMean(keyword string) string {
+	field := "title." + es.ShingleSubField
+	size, maxEdits := 1, 2
+	maxErrors := types.Float64(2)
+	mode := suggestmode.Always
+	prune := false
+	collate := `{"bool":{"filter":[{"term":{"status":` + strconv.Itoa(int(appType.StatusPublished)) + `}},{"match":{"title":{"query":"{{suggestion}}","operator":"and"}}}]}}`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	resp, err := global.ES.Search().
+		Index((&es.ArticleES{}).IndexName()).
+		Size(0).
+		Suggest(&types.Suggester{Suggesters: map[string]types.FieldSuggester{
+			"did_you_mean": {
+				Text: &keyword,
+				Phrase: &types.PhraseSuggester{
+					Field:     field,
+					Size:      &size,
+					MaxErrors: &maxErrors,
+					DirectGenerator: []types.DirectGenerator{{
+						Field:       field,
+						SuggestMode: &mode,
+						MaxEdits:    &maxEdits,
+					}},
+					Collate: &types.PhraseSuggestCollate{
+						Query: types.PhraseSuggestCollateQuery{Source: &collate},
+						Prune: &prune,
+					},
+				},
+			},
+		}}).
+		TypedKeys(true).
+		Do(ctx)
+	if err != nil {
+		global.ZapLog.Warn("生成搜索纠错建议失败", zap.String("keyword", keyword), zap.Error(err))
+		return ""
+	}
+	for _, entry := range resp.Suggest["did_you_mean"] {
+		phrase, ok := entry.(*types.PhraseSuggest)
+		if !ok {
+			continue
+		}
+		for _, option := range phrase.Options {
+			if text := joinCJK(option.Text); !strings.EqualFold(text, keyword) {
+				return text
+			}
+		}
+	}
+	return ""
+}
+
+// joinCJK 去掉短语建议中汉字之间的空格：短语建议用空格连接各个词，中文不需要分隔
+func joinCJK(text string) string {
+	runes := []rune(text)
+	var b strings.Builder
+	for i, r := range runes {
+		if r == ' ' && i > 0 && i < len(runes)-1 && unicode.Is(unicode.Han, runes[i-1]) && unicode.Is(unicode.Han, runes[i+1]) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
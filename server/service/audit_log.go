@@ -0,0 +1,246 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"reflect"
+	"server/global"
+	"server/model/appType"
+	"server/model/database"
+	"server/model/request"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 审计日志导出和清理的批量大小
+const (
+	auditExportBatchSize  = 500
+	auditExportMaxRows    = 100000
+	auditCleanupBatchSize = 1000
+)
+
+// auditIgnoredFields 计算变更时忽略的字段：时间戳、关联对象以及由系统维护的计数
+var auditIgnoredFields = map[string]bool{
+	"updated_at":     true,
+	"deleted_at":     true,
+	"author":         true,
+	"user":           true,
+	"article":        true,
+	"category":       true,
+	"replies":        true,
+	"children":       true,
+	"view_count":     true,
+	"comment_count":  true,
+	"like_count":     true,
+	"favorite_count": true,
+}
+
+// AuditService 审计日志服务
+type AuditService struct{}
+
+// AuditEntry 一条待记录的审计日志
+type AuditEntry struct {
+	ActorID    uint
+	ActorName  string
+	Action     appType.AuditAction
+	TargetType appType.AuditTargetType
+	TargetID   string
+	Before     interface{} // 修改前的对象，创建时为空
+	After      interface{} // 修改后的对象，删除时为空
+	IP         string
+	UserAgent  string
+}
+
+// AuditChange 单个字段的变更
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Record 写入审计日志。审计失败只记录错误日志，不影响业务操作
+func (s *AuditService) Record(entry AuditEntry) {
+	diff, err := AuditDiff(entry.Before, entry.After)
+	if err != nil {
+		global.ZapLog.Error("计算审计日志变更失败", zap.String("action", string(entry.Action)), zap.Error(err))
+	}
+	data, _ := json.Marshal(diff)
+
+	userAgent := entry.UserAgent
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	log := database.AuditLog{
+		ActorID:    entry.ActorID,
+		ActorName:  entry.ActorName,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Diff:       string(data),
+		IP:         entry.IP,
+		UserAgent:  userAgent,
+	}
+	if err := global.DB.Create(&log).Error; err != nil {
+		global.ZapLog.Error("写入审计日志失败",
+			zap.String("action", string(entry.Action)),
+			zap.String("target_type", string(entry.TargetType)),
+			zap.String("target_id", entry.TargetID),
+			zap.Error(err))
+	}
+}
+
+// AuditDiff 比较修改前后的对象，返回发生变化的字段。对象按JSON字段名比较，json:"-" 的敏感字段不会出现
+func AuditDiff(before, after interface{}) (map[string]AuditChange, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := make(map[string]AuditChange)
+	for key, value := range beforeFields {
+		if next, ok := afterFields[key]; !ok || !reflect.DeepEqual(value, next) {
+			diff[key] = AuditChange{Before: value, After: afterFields[key]}
+		}
+	}
+	for key, value := range afterFields {
+		if _, ok := beforeFields[key]; !ok {
+			diff[key] = AuditChange{After: value}
+		}
+	}
+	return diff, nil
+}
+
+// auditFields 把对象转换为字段表，并去掉不需要审计的字段
+func auditFields(v interface{}) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	if v == nil {
+		return fields, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for key := range fields {
+		if auditIgnoredFields[key] {
+			delete(fields, key)
+		}
+	}
+	return fields, nil
+}
+
+// auditQuery 根据查询条件构建查询
+func (s *AuditService) auditQuery(req request.AuditLogQueryRequest) *gorm.DB {
+	query := global.DB.Model(&database.AuditLog{})
+	if req.ActorID != 0 {
+		query = query.Where("actor_id = ?", req.ActorID)
+	}
+	if req.Action != "" {
+		query = query.Where("action = ?", req.Action)
+	}
+	if req.TargetType != "" {
+		query = query.Where("target_type = ?", req.TargetType)
+	}
+	if req.TargetID != "" {
+		query = query.Where("target_id = ?", req.TargetID)
+	}
+	if !req.StartDate.IsZero() {
+		query = query.Where("created_at >= ?", req.StartDate)
+	}
+	if !req.EndDate.IsZero() {
+		query = query.Where("created_at < ?", req.EndDate.AddDate(0, 0, 1))
+	}
+	if req.Keyword != "" {
+		keyword := "%" + req.Keyword + "%"
+		query = query.Where("actor_name LIKE ? OR diff LIKE ?", keyword, keyword)
+	}
+	return query
+}
+
+// GetAuditLogList 分页查询审计日志，按时间倒序
+func (s *AuditService) GetAuditLogList(req request.AuditLogQueryRequest) (list []database.AuditLog, total int64, err error) {
+	query := s.auditQuery(req)
+	if err = query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = query.Order("id DESC").Offset((req.Page - 1) * req.Size).Limit(req.Size).Find(&list).Error
+	return list, total, err
+}
+
+// ExportAuditLogs 按查询条件把审计日志以CSV格式写入 w，分批读取避免一次性加载
+func (s *AuditService) ExportAuditLogs(req request.AuditLogQueryRequest, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"ID", "时间", "操作人ID", "操作人", "操作", "对象类型", "对象ID", "变更内容", "IP", "User-Agent"}); err != nil {
+		return err
+	}
+
+	// FindInBatches 按主键升序翻页，和倒序导出冲突，这里按 id 倒序手动翻页
+	written := 0
+	var lastID uint
+	for written < auditExportMaxRows {
+		query := s.auditQuery(req)
+		if lastID > 0 {
+			query = query.Where("id < ?", lastID)
+		}
+		var logs []database.AuditLog
+		if err := query.Order("id DESC").Limit(min(auditExportBatchSize, auditExportMaxRows-written)).Find(&logs).Error; err != nil {
+			return err
+		}
+		for _, log := range logs {
+			if err := writer.Write([]string{
+				strconv.FormatUint(uint64(log.ID), 10),
+				log.CreatedAt.Format("2006-01-02 15:04:05"),
+				strconv.FormatUint(uint64(log.ActorID), 10),
+				log.ActorName,
+				string(log.Action),
+				string(log.TargetType),
+				log.TargetID,
+				log.Diff,
+				log.IP,
+				log.UserAgent,
+			}); err != nil {
+				return err
+			}
+		}
+		written += len(logs)
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+		if len(logs) < auditExportBatchSize {
+			break
+		}
+		lastID = logs[len(logs)-1].ID
+	}
+	return nil
+}
+
+// CleanupExpiredAuditLogs 删除超过保留期的审计日志，retentionDays 为0时不清理
+func (s *AuditService) CleanupExpiredAuditLogs(retentionDays int) (int64, error) {
+	if retentionDays <= 0 {
+		return 0, nil
+	}
+	cutoff := time.Now().AddDate(0, 0, -retentionDays)
+
+	// 分批删除，避免长时间锁表
+	var deleted int64
+	for {
+		result := global.DB.Where("created_at < ?", cutoff).Limit(auditCleanupBatchSize).Delete(&database.AuditLog{})
+		if result.Error != nil {
+			return deleted, result.Error
+		}
+		deleted += result.RowsAffected
+		if result.RowsAffected < auditCleanupBatchSize {
+			return deleted, nil
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"server/global"
+	"server/model/database"
+	"server/model/request"
+	"strconv"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestExportAuditLogsPagesDescending(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	if err := db.AutoMigrate(&database.AuditLog{}); err != nil {
+		t.Fatalf("建表失败: %v", err)
+	}
+	global.DB = db
+
+	// 超过两批的数据，偶数条的操作人为 alice，用于检查带筛选条件时的翻页
+	total := auditExportBatchSize*2 + 37
+	logs := make([]database.AuditLog, 0, total)
+	for i := 1; i <= total; i++ {
+		name := "bob"
+		if i%2 == 0 {
+			name = "alice"
+		}
+		logs = append(logs, database.AuditLog{ActorID: uint(i), ActorName: name, Diff: fmt.Sprintf(`{"n":%d}`, i)})
+	}
+	if err := db.CreateInBatches(&logs, 200).Error; err != nil {
+		t.Fatalf("写入测试数据失败: %v", err)
+	}
+
+	cases := []struct {
+		name string
+		req  request.AuditLogQueryRequest
+		want int
+	}{
+		{"全部", request.AuditLogQueryRequest{}, total},
+		{"关键词筛选", request.AuditLogQueryRequest{Keyword: "alice"}, total / 2},
+		{"无结果", request.AuditLogQueryRequest{Keyword: "nobody"}, 0},
+	}
+	for _, c := range cases {
+		var buf bytes.Buffer
+		if err := (&AuditService{}).ExportAuditLogs(c.req, &buf); err != nil {
+			t.Fatalf("%s: 导出失败: %v", c.name, err)
+		}
+		records, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatalf("%s: 解析导出结果失败: %v", c.name, err)
+		}
+		rows := records[1:]
+		if len(rows) != c.want {
+			t.Errorf("%s: 导出 %d 行，期望 %d 行", c.name, len(rows), c.want)
+		}
+		// ID 严格递减，说明没有重复也没有乱序
+		last := total + 1
+		for _, row := range rows {
+			id, _ := strconv.Atoi(row[0])
+			if id >= last {
+				t.Errorf("%s: ID %d 出现在 %d 之后", c.name, id, last)
+				break
+			}
+			last = id
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package service
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"server/global"
+	"server/model/database"
+	"server/utils/backup"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// 备份文件的命名规则
+const (
+	backupFilePrefix = "backup_"
+	backupFileSuffix = ".json.gz"
+)
+
+// restoreBatchSize 恢复时每条 INSERT 语句写入的最大行数
+const restoreBatchSize = 500
+
+// BackupService 数据库备份与恢复服务，通过 global.DB 读写全部模型表，不依赖 mysqldump
+type BackupService struct{}
+
+// BackupResult 备份结果
+type BackupResult struct {
+	SchemaVersion string           `json:"schema_version"`
+	Counts        map[string]int64 `json:"counts"`
+}
+
+// modelTables 解析全部模型，返回表名及其列名（按表名排序）
+func modelTables() (map[string][]string, []string, error) {
+	cache := &sync.Map{}
+	tables := make(map[string][]string)
+	var names []string
+	for _, model := range database.AllModels() {
+		s, err := schema.Parse(model, cache, global.DB.NamingStrategy)
+		if err != nil {
+			return nil, nil, err
+		}
+		columns := append([]string{}, s.DBNames...)
+		sort.Strings(columns)
+		tables[s.Table] = columns
+		names = append(names, s.Table)
+	}
+	sort.Strings(names)
+	return tables, names, nil
+}
+
+// SchemaVersion 当前程序的表结构版本：全部模型的表名和列名的摘要，模型字段变化时随之变化
+func (s *BackupService) SchemaVersion() (string, error) {
+	tables, names, err := modelTables()
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%s(%s)\n", name, strings.Join(tables[name], ","))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
+
+// Backup 把指定的表（为空时为全部模型表）写入备份归档，在同一个只读事务中读取以保证数据一致
+func (s *BackupService) Backup(w io.Writer, only []string) (BackupResult, error) {
+	var result BackupResult
+	tables, names, err := modelTables()
+	if err != nil {
+		return result, err
+	}
+	if len(only) > 0 {
+		for _, name := range only {
+			if _, ok := tables[name]; !ok {
+				return result, fmt.Errorf("未知的数据表: %s", name)
+			}
+		}
+		names = only
+	}
+	if result.SchemaVersion, err = s.SchemaVersion(); err != nil {
+		return result, err
+	}
+
+	archive, err := backup.NewWriter(w, backup.Header{
+		SchemaVersion: result.SchemaVersion,
+		CreatedAt:     time.Now(),
+		Tables:        names,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	tx := global.DB.Begin(&sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if tx.Error != nil {
+		return result, tx.Error
+	}
+	defer tx.Rollback()
+
+	for _, name := range names {
+		if err := s.backupTable(tx, archive, name, tables[name]); err != nil {
+			return result, fmt.Errorf("备份表 %s 失败: %v", name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return result, err
+	}
+	result.Counts = archive.Counts()
+	return result, nil
+}
+
+// backupTable 逐行读取一张表写入归档
+func (s *BackupService) backupTable(tx *gorm.DB, archive *backup.Writer, table string, columns []string) error {
+	rows, err := tx.Table(table).Select(columns).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if err := archive.BeginTable(table, columns); err != nil {
+		return err
+	}
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+		if err := archive.WriteRow(values); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Restore 从备份归档恢复数据：在一个事务中清空归档包含的表并写入备份数据，任何错误都会整体回滚
+// 备份的表结构版本必须与当前程序一致
+func (s *BackupService) Restore(r io.Reader) (map[string]int64, error) {
+	archive, err := backup.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	if header := archive.Header(); header.SchemaVersion != current {
+		return nil, fmt.Errorf("备份的表结构版本 %s 与当前版本 %s 不一致，请使用对应版本的程序恢复", header.SchemaVersion, current)
+	}
+	tables, _, err := modelTables()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64)
+	err = global.DB.Transaction(func(tx *gorm.DB) error {
+		// 恢复期间关闭外键检查，表可以按任意顺序写入；同一连接上执行，结束前恢复
+		if err := tx.Exec("SET FOREIGN_KEY_CHECKS = 0").Error; err != nil {
+			return err
+		}
+		defer tx.Exec("SET FOREIGN_KEY_CHECKS = 1")
+
+		var table string
+		var columns []string
+		var batch [][]interface{}
+		flush := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+			err := insertRows(tx, table, columns, batch)
+			counts[table] += int64(len(batch))
+			batch = batch[:0]
+			return err
+		}
+
+		for {
+			entry, err := archive.Next()
+			if errors.Is(err, io.EOF) {
+				return flush()
+			}
+			if err != nil {
+				return err
+			}
+
+			switch entry.Kind {
+			case backup.EntryTable:
+				if err := flush(); err != nil {
+					return err
+				}
+				if _, ok := tables[entry.Table]; !ok {
+					return fmt.Errorf("备份中包含未知的数据表: %s", entry.Table)
+				}
+				table, columns = entry.Table, entry.Columns
+				counts[table] = 0
+				// 使用 DELETE 而不是 TRUNCATE，TRUNCATE 会隐式提交事务
+				if err := tx.Exec(fmt.Sprintf("DELETE FROM %s", quoteIdentifier(table))).Error; err != nil {
+					return err
+				}
+			case backup.EntryRow:
+				batch = append(batch, entry.Row)
+				if len(batch) >= restoreBatchSize {
+					if err := flush(); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// insertRows 批量写入数据行
+func insertRows(tx *gorm.DB, table string, columns []string, rows [][]interface{}) error {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = quoteIdentifier(column)
+	}
+	placeholder := "(" + strings.TrimSuffix(strings.Repeat("?,", len(columns)), ",") + ")"
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "INSERT INTO %s (%s) VALUES ", quoteIdentifier(table), strings.Join(quoted, ","))
+	args := make([]interface{}, 0, len(rows)*len(columns))
+	for i, row := range rows {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(placeholder)
+		args = append(args, row...)
+	}
+	return tx.Exec(sb.String(), args...).Error
+}
+
+// quoteIdentifier 给表名和列名加反引号
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// BackupToDir 在目录中创建一个以时间命名的备份文件，写入失败时删除不完整的文件
+func (s *BackupService) BackupToDir(dir string) (string, BackupResult, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return "", BackupResult{}, err
+	}
+	path := filepath.Join(dir, backupFilePrefix+time.Now().Format("20060102_150405")+backupFileSuffix)
+	result, err := s.BackupToFile(path, nil)
+	return path, result, err
+}
+
+// BackupToFile 备份到指定文件，先写临时文件再重命名，避免留下不完整的备份
+func (s *BackupService) BackupToFile(path string, only []string) (BackupResult, error) {
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return BackupResult{}, err
+	}
+	result, err := s.Backup(file, only)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return result, err
+	}
+	return result, os.Rename(tmp, path)
+}
+
+// RestoreFromFile 从备份文件恢复
+func (s *BackupService) RestoreFromFile(path string) (map[string]int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return s.Restore(file)
+}
+
+// RotateBackups 只保留目录中最新的 keep 个自动备份文件，返回删除的文件
+func (s *BackupService) RotateBackups(dir string, keep int) ([]string, error) {
+	if keep <= 0 {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, backupFilePrefix) && strings.HasSuffix(name, backupFileSuffix) {
+			files = append(files, name)
+		}
+	}
+	if len(files) <= keep {
+		return nil, nil
+	}
+
+	// 文件名包含时间，按名称排序即按时间排序
+	sort.Strings(files)
+	var removed []string
+	for _, name := range files[:len(files)-keep] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return removed, err
+		}
+		removed = append(removed, name)
+	}
+	return removed, nil
+}
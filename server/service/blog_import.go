@@ -0,0 +1,414 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"server/global"
+	"server/model/appType"
+	"server/model/database"
+	"server/utils"
+	"server/utils/importer"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// importGuestUsername 导入评论时使用的占位账户
+const importGuestUsername = "import_guest"
+
+// BlogImportService 从其他博客系统（WordPress、Hexo、Hugo）导入文章、页面、分类、标签和评论
+type BlogImportService struct{}
+
+// BlogImportItem 单篇文章或页面的导入结果
+type BlogImportItem struct {
+	Source   string `json:"source"`
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Slug     string `json:"slug"`
+	ID       uint   `json:"id"`
+	Comments int    `json:"comments"`
+}
+
+// BlogImportReport 导入报告
+type BlogImportReport struct {
+	Source     string             `json:"source"`
+	Articles   int                `json:"articles"`
+	Pages      int                `json:"pages"`
+	Categories int                `json:"categories"`
+	Comments   int                `json:"comments"`
+	Redirects  int                `json:"redirects"`
+	Imported   []BlogImportItem   `json:"imported"`
+	Skipped    []importer.Skipped `json:"skipped"`
+}
+
+// BlogImportOptions 导入选项
+type BlogImportOptions struct {
+	AuthorID uint // 导入文章的作者
+	// 按评论者邮箱把评论归属到本站用户；旧站的评论邮箱未经验证，任何人都能冒用，只在确认旧站数据可信时开启
+	MatchCommentEmail bool
+}
+
+// blogImporter 一次导入过程的状态
+type blogImporter struct {
+	site       *importer.Site
+	authorID   uint
+	matchEmail bool
+	report     *BlogImportReport
+	categories map[string]uint // 分类名称 -> ID
+	guestID    uint
+	articleSvc ArticleService
+}
+
+// Import 把解析出的内容写入数据库；已存在相同 slug 的文章和页面会跳过，但仍为其旧地址建立重定向
+func (s *BlogImportService) Import(site *importer.Site, opts BlogImportOptions) (BlogImportReport, error) {
+	report := BlogImportReport{
+		Source:   site.Source,
+		Imported: []BlogImportItem{},
+		Skipped:  append([]importer.Skipped{}, site.Skipped...),
+	}
+	if opts.AuthorID == 0 {
+		return report, errors.New("未指定文章作者")
+	}
+
+	im := &blogImporter{
+		site:       site,
+		authorID:   opts.AuthorID,
+		matchEmail: opts.MatchCommentEmail,
+		report:     &report,
+		categories: make(map[string]uint),
+	}
+	if err := im.importCategories(); err != nil {
+		return report, err
+	}
+
+	for _, post := range site.Posts {
+		var err error
+		if post.Type == importer.TypePage {
+			err = im.importPage(post)
+		} else {
+			err = im.importArticle(post)
+		}
+		if err != nil {
+			im.skip(post.Source, err.Error())
+		}
+	}
+	return report, nil
+}
+
+// skip 记录跳过的内容
+func (im *blogImporter) skip(source, reason string) {
+	im.report.Skipped = append(im.report.Skipped, importer.Skipped{Source: source, Reason: reason})
+}
+
+// importCategories 按层级创建分类，上级分类先于下级分类创建
+func (im *blogImporter) importCategories() error {
+	parents := make(map[string]string)
+	for _, c := range im.site.Categories {
+		parents[c.Name] = c.Parent
+	}
+	for _, c := range im.site.Categories {
+		if _, err := im.category(c.Name, parents, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// category 查找或创建分类，depth 用于防止循环的上级关系
+func (im *blogImporter) category(name string, parents map[string]string, depth int) (uint, error) {
+	name = strings.TrimSpace(name)
+	if id, ok := im.categories[name]; ok {
+		return id, nil
+	}
+
+	var parentID *uint
+	if parent := parents[name]; parent != "" && depth < 10 {
+		id, err := im.category(parent, parents, depth+1)
+		if err != nil {
+			return 0, err
+		}
+		parentID = &id
+	}
+
+	var category database.Category
+	err := global.DB.Unscoped().Where("name = ?", name).First(&category).Error
+	switch {
+	case err == nil:
+		if category.DeletedAt.Valid {
+			if err := global.DB.Unscoped().Model(&category).Update("deleted_at", nil).Error; err != nil {
+				return 0, err
+			}
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		category = database.Category{Name: name, Slug: generateSlug(name), ParentID: parentID}
+		if err := global.DB.Create(&category).Error; err != nil {
+			return 0, fmt.Errorf("创建分类 %s 失败: %v", name, err)
+		}
+		im.report.Categories++
+	default:
+		return 0, err
+	}
+
+	im.categories[name] = category.ID
+	return category.ID, nil
+}
+
+// saveRedirects 为旧地址建立到新地址的重定向
+func (im *blogImporter) saveRedirects(tx *gorm.DB, post importer.Post, toPath string) error {
+	redirectService := RedirectService{}
+	for _, from := range post.Permalinks {
+		if from == toPath {
+			continue
+		}
+		if err := redirectService.Save(tx, from, toPath, im.site.Source); err != nil {
+			return err
+		}
+		im.report.Redirects++
+	}
+	return nil
+}
+
+// importArticle 导入文章及其评论
+func (im *blogImporter) importArticle(post importer.Post) error {
+	var existing database.Article
+	err := global.DB.Unscoped().Where("slug = ?", post.Slug).First(&existing).Error
+	if err == nil {
+		if err := im.saveRedirects(global.DB, post, fmt.Sprintf("/article/%d", existing.ID)); err != nil {
+			return err
+		}
+		return errors.New("已存在相同 slug 的文章，只更新了旧地址重定向")
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	categoryName := defaultImportCategory
+	if len(post.Categories) > 0 {
+		categoryName = post.Categories[0]
+	}
+	categoryID, err := im.category(categoryName, nil, 0)
+	if err != nil {
+		return err
+	}
+
+	status := uint8(1)
+	if post.Draft {
+		status = 0
+	}
+	article := database.Article{
+		BaseModelWithStatus: database.BaseModelWithStatus{Status: status},
+		Title:               post.Title,
+		Slug:                post.Slug,
+		Content:             post.Content,
+		Summary:             post.Summary,
+		AuthorID:            im.authorID,
+		CategoryID:          categoryID,
+	}
+	article.CreatedAt = post.Date
+	article.UpdatedAt = post.Updated
+	if article.UpdatedAt.IsZero() {
+		article.UpdatedAt = post.Date
+	}
+
+	var comments int
+	err = global.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&article).Error; err != nil {
+			return err
+		}
+		// 状态为0时显式更新，避免被字段默认值覆盖
+		if status == 0 {
+			if err := tx.Model(&article).UpdateColumn("status", 0).Error; err != nil {
+				return err
+			}
+		}
+		if err := im.articleSvc.handleArticleTags(tx, article.ID, nil, post.Tags); err != nil {
+			return err
+		}
+		if err := im.articleSvc.recountArticleTags(tx, article.ID, nil); err != nil {
+			return err
+		}
+		if err := im.saveRedirects(tx, post, fmt.Sprintf("/article/%d", article.ID)); err != nil {
+			return err
+		}
+		var err error
+		if comments, err = im.importComments(tx, article.ID, post); err != nil {
+			return err
+		}
+		return enqueueArticleSync(tx, article.ID, appType.ESOutboxIndex)
+	})
+	if err != nil {
+		// 占位账户可能是在回滚的事务中创建的
+		im.guestID = 0
+		return err
+	}
+
+	im.report.Articles++
+	im.report.Comments += comments
+	im.report.Imported = append(im.report.Imported, BlogImportItem{
+		Source:   post.Source,
+		Type:     importer.TypePost,
+		Title:    post.Title,
+		Slug:     post.Slug,
+		ID:       article.ID,
+		Comments: comments,
+	})
+
+	// 在导入过程中投递，命令行导入结束后进程可能直接退出；失败的事件由定时任务重试
+	(&ESOutboxService{}).DeliverArticle(article.ID)
+	return nil
+}
+
+// importComments 导入文章评论，上级评论未导入的回复作为顶级评论
+func (im *blogImporter) importComments(tx *gorm.DB, articleID uint, post importer.Post) (int, error) {
+	if len(post.Comments) == 0 {
+		return 0, nil
+	}
+
+	ids := make(map[string]uint)
+	for _, c := range post.Comments {
+		if strings.TrimSpace(c.Content) == "" {
+			im.skip(fmt.Sprintf("%s 的评论 #%s", post.Source, c.ID), "评论内容为空")
+			continue
+		}
+		userID, content, err := im.commentAuthor(tx, c)
+		if err != nil {
+			return 0, err
+		}
+
+		comment := database.Comment{
+			ArticleID: articleID,
+			UserID:    userID,
+			Content:   content,
+		}
+		if parentID, ok := ids[c.ParentID]; ok && c.ParentID != "" {
+			comment.ParentID = &parentID
+		}
+		if c.Approved {
+			comment.CommentStatus = appType.CommentStatusApproved
+		}
+		comment.CreatedAt = c.Date
+		comment.UpdatedAt = c.Date
+		if err := tx.Create(&comment).Error; err != nil {
+			return 0, err
+		}
+		ids[c.ID] = comment.ID
+	}
+
+	if err := tx.Model(&database.Article{}).Where("id = ?", articleID).
+		Update("comment_count", tx.Model(&database.Comment{}).Select("COUNT(*)").Where("article_id = ?", articleID)).Error; err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// commentAuthor 评论归属占位账户并在正文前注明原评论者；开启邮箱匹配且评论者邮箱属于本站用户时归属该用户
+func (im *blogImporter) commentAuthor(tx *gorm.DB, c importer.Comment) (uint, string, error) {
+	if im.matchEmail && c.AuthorEmail != "" {
+		var user database.User
+		if err := tx.Where("email = ?", c.AuthorEmail).First(&user).Error; err == nil {
+			return user.ID, c.Content, nil
+		}
+	}
+
+	if im.guestID == 0 {
+		id, err := importGuestUser(tx)
+		if err != nil {
+			return 0, "", err
+		}
+		im.guestID = id
+	}
+	name := c.AuthorName
+	if name == "" {
+		name = "匿名"
+	}
+	return im.guestID, fmt.Sprintf("**%s**：%s", name, c.Content), nil
+}
+
+// importGuestUser 获取导入评论用的占位账户，不存在时创建（禁用状态，不能登录）
+func importGuestUser(tx *gorm.DB) (uint, error) {
+	var user database.User
+	err := tx.Where("username = ?", importGuestUsername).First(&user).Error
+	if err == nil {
+		return user.ID, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+
+	password, err := randomToken(16)
+	if err != nil {
+		return 0, err
+	}
+	user = database.User{
+		Username: importGuestUsername,
+		Email:    importGuestUsername + "@import.invalid",
+		Password: utils.BcryptHash(password),
+		Nickname: "导入的访客",
+		Role:     appType.RoleUser,
+	}
+	if err := tx.Create(&user).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&user).UpdateColumn("status", 0).Error; err != nil {
+		return 0, err
+	}
+	return user.ID, nil
+}
+
+// importPage 导入独立页面
+func (im *blogImporter) importPage(post importer.Post) error {
+	// 前端没有独立页面的路由，旧地址重定向到首页，避免指向不存在的地址
+	toPath := "/"
+
+	var count int64
+	if err := global.DB.Unscoped().Model(&database.Page{}).Where("slug = ?", post.Slug).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		if err := im.saveRedirects(global.DB, post, toPath); err != nil {
+			return err
+		}
+		return errors.New("已存在相同 slug 的页面，只更新了旧地址重定向")
+	}
+	if len(post.Comments) > 0 {
+		im.skip(post.Source, fmt.Sprintf("页面不支持评论，忽略 %d 条评论", len(post.Comments)))
+	}
+
+	status := uint8(1)
+	if post.Draft {
+		status = 0
+	}
+	page := database.Page{
+		BaseModelWithStatus: database.BaseModelWithStatus{Status: status},
+		Title:               post.Title,
+		Slug:                post.Slug,
+		Content:             post.Content,
+	}
+	page.CreatedAt = post.Date
+	page.UpdatedAt = post.Updated
+
+	err := global.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&page).Error; err != nil {
+			return err
+		}
+		if status == 0 {
+			if err := tx.Model(&page).UpdateColumn("status", 0).Error; err != nil {
+				return err
+			}
+		}
+		return im.saveRedirects(tx, post, toPath)
+	})
+	if err != nil {
+		return err
+	}
+
+	im.report.Pages++
+	im.report.Imported = append(im.report.Imported, BlogImportItem{
+		Source: post.Source,
+		Type:   importer.TypePage,
+		Title:  post.Title,
+		Slug:   post.Slug,
+		ID:     page.ID,
+	})
+	return nil
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"server/config"
+	"server/global"
+	"server/utils"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// 需要人机验证的接口名，对应配置 captcha.policies 的键
+const (
+	ChallengeLogin          = "login"
+	ChallengeRegister       = "register"
+	ChallengeForgotPassword = "forgot_password"
+	ChallengeSendEmailCode  = "send_email_code"
+)
+
+// knownDeviceTTL 已知设备记录的保留时间
+const knownDeviceTTL = 90 * 24 * time.Hour
+
+// deviceHistoryEndpoints 会记录已知设备的接口，只有这些接口参与新设备评分
+var deviceHistoryEndpoints = map[string]bool{
+	ChallengeLogin: true,
+}
+
+// emailSendEndpoints 会发送邮件的接口，成功发送的次数计入风险分，避免被用来批量发送邮件
+var emailSendEndpoints = map[string]bool{
+	ChallengeForgotPassword: true,
+	ChallengeSendEmailCode:  true,
+}
+
+// ChallengeService 基于风险评分的人机验证服务
+type ChallengeService struct{}
+
+// ChallengeSubject 一次待评估的请求
+type ChallengeSubject struct {
+	Endpoint   string // 接口名
+	IP         string // 客户端IP
+	UserAgent  string // 客户端 User-Agent
+	DeviceID   string // 客户端上报的设备标识（X-Device-ID），为空时使用 User-Agent 代替
+	Identifier string // 账户标识（用户名或邮箱），用于统计账户维度的失败次数和设备
+}
+
+// RiskAssessment 风险评估结果
+type RiskAssessment struct {
+	Score    int      `json:"score"`
+	Required bool     `json:"required"`
+	Reasons  []string `json:"reasons,omitempty"`
+	Types    []string `json:"types,omitempty"` // 可选的验证方式
+}
+
+// Assess 评估请求风险并根据接口策略判断是否需要人机验证
+func (s *ChallengeService) Assess(subject ChallengeSubject) RiskAssessment {
+	policy := global.Config.Captcha.Policy(subject.Endpoint)
+	assessment := RiskAssessment{}
+
+	switch policy.Mode {
+	case config.CaptchaModeNever:
+		return assessment
+	case config.CaptchaModeAdaptive:
+		assessment.Score, assessment.Reasons = s.score(subject)
+		assessment.Required = assessment.Score >= policy.Threshold
+	default:
+		assessment.Required = true
+		assessment.Reasons = []string{"policy"}
+	}
+
+	if assessment.Required {
+		assessment.Types = []string{utils.CaptchaTypeImage, utils.CaptchaTypeMath, utils.CaptchaTypeAudio}
+	}
+	return assessment
+}
+
+// score 计算请求的风险分
+func (s *ChallengeService) score(subject ChallengeSubject) (int, []string) {
+	risk := global.Config.Captcha.Risk
+	score := 0
+	var reasons []string
+
+	trusted := ipInList(subject.IP, risk.TrustedIPs)
+	if !trusted && ipInList(subject.IP, risk.BlockedIPs) {
+		// 黑名单IP无论阈值多少都必须验证
+		return 1 << 20, []string{"ip_blocked"}
+	}
+
+	// IP信誉：近期来自该IP的失败次数
+	if !trusted && risk.IPFailureScore > 0 {
+		if failures, err := global.Redis.Get(ipFailureKey(subject.IP)).Int64(); err == nil && failures > 0 {
+			score += int(failures) * risk.IPFailureScore
+			reasons = append(reasons, "ip_failures")
+		}
+	}
+
+	// 发送邮件的接口：来源IP近期成功发送的次数，成功发送不算失败，不计入的话自适应模式永远不会要求验证
+	if !trusted && risk.EmailSendScore > 0 && emailSendEndpoints[subject.Endpoint] {
+		if sends, err := global.Redis.Get(ipEmailSendKey(subject.IP)).Int64(); err == nil && sends > 0 {
+			score += int(sends) * risk.EmailSendScore
+			reasons = append(reasons, "ip_email_sends")
+		}
+	}
+
+	if subject.Identifier != "" {
+		// 同一邮箱近期收到的邮件数
+		if risk.EmailSendScore > 0 && emailSendEndpoints[subject.Endpoint] {
+			if sends, err := global.Redis.Get(accountEmailSendKey(subject.Identifier)).Int64(); err == nil && sends > 0 {
+				score += int(sends) * risk.EmailSendScore
+				reasons = append(reasons, "account_email_sends")
+			}
+		}
+
+		// 账户维度的近期失败次数
+		if risk.FailedAttemptScore > 0 {
+			if failures, err := global.Redis.Get(accountFailureKey(subject.Identifier)).Int64(); err == nil && failures > 0 {
+				score += int(failures) * risk.FailedAttemptScore
+				reasons = append(reasons, "account_failures")
+			}
+		}
+
+		// 新设备：账户从未在该设备上成功登录过
+		// 只有登录成功时才记录已知设备，注册、发送验证码、找回密码没有设备历史，评估新设备只会让每次请求都被判为高风险
+		if risk.NewDeviceScore > 0 && deviceHistoryEndpoints[subject.Endpoint] {
+			known, err := global.Redis.SIsMember(knownDevicesKey(subject.Identifier), deviceFingerprint(subject)).Result()
+			if err == nil && !known {
+				score += risk.NewDeviceScore
+				reasons = append(reasons, "new_device")
+			}
+		}
+	}
+
+	if subject.UserAgent == "" && risk.MissingUAScore > 0 {
+		score += risk.MissingUAScore
+		reasons = append(reasons, "missing_user_agent")
+	}
+
+	return score, reasons
+}
+
+// Verify 在需要验证时校验验证码，不需要验证时直接通过
+func (s *ChallengeService) Verify(subject ChallengeSubject, captchaID, captchaCode string) (RiskAssessment, bool) {
+	assessment := s.Assess(subject)
+	if !assessment.Required {
+		return assessment, true
+	}
+	if utils.VerifyCaptcha(captchaID, captchaCode) {
+		return assessment, true
+	}
+	s.RecordFailure(subject)
+	return assessment, false
+}
+
+// RecordFailure 记录一次失败，用于后续的IP信誉和账户风险评估
+func (s *ChallengeService) RecordFailure(subject ChallengeSubject) {
+	window := riskWindow()
+	if subject.IP != "" {
+		if _, _, err := utils.IncrWindowCounter(ipFailureKey(subject.IP), window); err != nil {
+			global.ZapLog.Warn("记录IP失败次数失败", zap.Error(err))
+		}
+	}
+	if subject.Identifier != "" {
+		if _, _, err := utils.IncrWindowCounter(accountFailureKey(subject.Identifier), window); err != nil {
+			global.ZapLog.Warn("记录账户失败次数失败", zap.Error(err))
+		}
+	}
+}
+
+// RecordEmailSent 邮件发送成功后按来源IP和目标邮箱计数，统计周期与失败次数相同
+func (s *ChallengeService) RecordEmailSent(subject ChallengeSubject) {
+	window := riskWindow()
+	if subject.IP != "" {
+		if _, _, err := utils.IncrWindowCounter(ipEmailSendKey(subject.IP), window); err != nil {
+			global.ZapLog.Warn("记录IP发送邮件次数失败", zap.Error(err))
+		}
+	}
+	if subject.Identifier != "" {
+		if _, _, err := utils.IncrWindowCounter(accountEmailSendKey(subject.Identifier), window); err != nil {
+			global.ZapLog.Warn("记录邮箱发送邮件次数失败", zap.Error(err))
+		}
+	}
+}
+
+// riskWindow 失败和发送次数的统计周期
+func riskWindow() time.Duration {
+	if window := global.Config.Captcha.Risk.IPFailureWindow; window > 0 {
+		return window
+	}
+	return time.Hour
+}
+
+// RecordSuccess 操作成功后清除账户失败记录，并把当前设备记为已知设备
+func (s *ChallengeService) RecordSuccess(subject ChallengeSubject) {
+	if subject.Identifier == "" {
+		return
+	}
+	global.Redis.Del(accountFailureKey(subject.Identifier))
+	key := knownDevicesKey(subject.Identifier)
+	global.Redis.SAdd(key, deviceFingerprint(subject))
+	global.Redis.Expire(key, knownDeviceTTL)
+}
+
+// ipFailureKey IP失败次数的Redis键
+func ipFailureKey(ip string) string {
+	return "risk:ip_fail:" + ip
+}
+
+// accountFailureKey 账户失败次数的Redis键
+func accountFailureKey(identifier string) string {
+	return "risk:account_fail:" + strings.ToLower(identifier)
+}
+
+// ipEmailSendKey IP发送邮件次数的Redis键
+func ipEmailSendKey(ip string) string {
+	return "risk:ip_send:" + ip
+}
+
+// accountEmailSendKey 邮箱收到邮件次数的Redis键
+func accountEmailSendKey(identifier string) string {
+	return "risk:account_send:" + strings.ToLower(identifier)
+}
+
+// knownDevicesKey 账户已知设备集合的Redis键
+func knownDevicesKey(identifier string) string {
+	return "risk:known_devices:" + strings.ToLower(identifier)
+}
+
+// deviceFingerprint 生成设备指纹，只保存哈希值避免存储原始标识
+func deviceFingerprint(subject ChallengeSubject) string {
+	raw := subject.DeviceID
+	if raw == "" {
+		raw = subject.UserAgent
+	}
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:16])
+}
+
+// ipInList 判断IP是否命中列表中的地址或CIDR网段
+func ipInList(ip string, list []string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, item := range list {
+		if strings.Contains(item, "/") {
+			if _, network, err := net.ParseCIDR(item); err == nil && network.Contains(parsed) {
+				return true
+			}
+			continue
+		}
+		if other := net.ParseIP(item); other != nil && other.Equal(parsed) {
+			return true
+		}
+	}
+	return false
+}
@@ -7,6 +7,7 @@ import (
 	"server/model/request"
 
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 // CommentService 评论服务结构体
@@ -153,8 +154,14 @@ func (s *CommentService) DeleteComment(id, userID uint) error {
 	commentIDsToDelete := s.getAllChildCommentIDs(id)
 	commentIDsToDelete = append(commentIDsToDelete, id)
 
-	// 删除所有相关评论
-	if err := global.DB.Where("id IN ?", commentIDsToDelete).Delete(&database.Comment{}).Error; err != nil {
+	// 删除所有相关评论，并记录删除操作人，评论作者不能恢复被他人删除的评论
+	err := global.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&database.Comment{}).Where("id IN ?", commentIDsToDelete).Update("deleted_by", userID).Error; err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", commentIDsToDelete).Delete(&database.Comment{}).Error
+	})
+	if err != nil {
 		global.ZapLog.Error("删除评论失败", zap.Error(err))
 		return errors.New("删除评论失败")
 	}
@@ -227,5 +234,7 @@ func (s *CommentService) updateArticleCommentCount(articleID uint) {
 	// 更新文章的评论数
 	if err := global.DB.Model(&database.Article{}).Where("id = ?", articleID).Update("comment_count", commentCount).Error; err != nil {
 		global.ZapLog.Error("更新文章评论数失败", zap.Error(err))
+		return
 	}
+	(&ArticleCounterService{}).MarkChanged(articleID)
 }
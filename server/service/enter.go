@@ -8,6 +8,23 @@ type ServiceGroup struct {
 	PageService
 	CategoryService
 	TagService
+	ChallengeService
+	TwoFactorService
+	OAuthService
+	AccessTokenService
+	AuditService
+	TrashService
+	AccountService
+	ArticleMarkdownService
+	RedirectService
+	BlogImportService
+	BackupService
+	SiteBackupService
+	SearchSynonymService
+	SearchAnalyticsService
+	ESOutboxService
+	ArticleCounterService
+	PageViewService
 }
 
 var ServiceGroups = new(ServiceGroup)
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"server/global"
+	"server/model/es"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// installedPlugins 返回已安装的插件，多节点集群中要求列出的每个节点都已安装
+func (s *ArticleESService) installedPlugins() (map[string]bool, error) {
+	res, err := s.client.Cat.Plugins().Do(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	nodes := make(map[string]bool)
+	counts := make(map[string]int)
+	for _, record := range res {
+		if record.Name != nil {
+			nodes[*record.Name] = true
+		}
+		if record.Component != nil {
+			counts[*record.Component]++
+		}
+	}
+	plugins := make(map[string]bool)
+	for plugin, count := range counts {
+		if count >= len(nodes) {
+			plugins[plugin] = true
+		}
+	}
+	return plugins, nil
+}
+
+// ResolveAnalysis 根据配置和已安装的插件确定新建索引使用的分词方式；配置的插件未安装时回退到 ngram
+func (s *ArticleESService) ResolveAnalysis() es.Analysis {
+	config := global.Config.ES
+	plugins, err := s.installedPlugins()
+	if err != nil {
+		global.ZapLog.Warn("查询Elasticsearch插件失败，按未安装插件处理", zap.Error(err))
+		plugins = map[string]bool{}
+	}
+
+	analysis := es.Analysis{Pinyin: config.Pinyin && plugins[es.PluginPinyin]}
+	switch wanted := strings.ToLower(config.Analyzer); wanted {
+	case es.TokenizerIK, es.TokenizerSmartCN:
+		plugin := es.PluginIK
+		if wanted == es.TokenizerSmartCN {
+			plugin = es.PluginSmartCN
+		}
+		analysis.Tokenizer = wanted
+		if !plugins[plugin] {
+			global.ZapLog.Warn("未安装分词插件，使用 ngram 分词", zap.String("plugin", plugin))
+			analysis.Tokenizer = es.TokenizerNGram
+		}
+	case es.TokenizerNGram, es.TokenizerStandard:
+		analysis.Tokenizer = wanted
+	default:
+		switch {
+		case plugins[es.PluginIK]:
+			analysis.Tokenizer = es.TokenizerIK
+		case plugins[es.PluginSmartCN]:
+			analysis.Tokenizer = es.TokenizerSmartCN
+		default:
+			analysis.Tokenizer = es.TokenizerNGram
+		}
+	}
+	if config.Pinyin && !analysis.Pinyin {
+		global.ZapLog.Warn("未安装拼音插件，不添加拼音子字段", zap.String("plugin", es.PluginPinyin))
+	}
+	return analysis
+}
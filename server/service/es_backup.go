@@ -0,0 +1,265 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"server/global"
+	"slices"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types"
+	"go.uber.org/zap"
+)
+
+// ES 文档导出导入的默认参数
+const (
+	esExportPageSize  = 1000
+	esImportBatch     = 500
+	esImportRetries   = 3
+	esPitKeepAlive    = "2m"
+	esRetryBaseDelay  = time.Second
+	esMaxDocumentSize = 64 << 20
+)
+
+// ESDocument 导出文件中的一行：文档ID和原始 _source
+type ESDocument struct {
+	ID     string          `json:"_id"`
+	Source json.RawMessage `json:"_source"`
+}
+
+// ESExportOptions 导出选项
+type ESExportOptions struct {
+	PageSize int                     // 每页文档数，默认 1000
+	Progress func(done, total int64) // 每写完一页调用一次
+}
+
+// ESImportOptions 导入选项
+type ESImportOptions struct {
+	BatchSize  int                    // 每次批量写入的文档数，默认 500
+	Retries    int                    // 批量写入失败时的重试次数，为 0 时默认 3 次，小于 0 时不重试
+	Skip       int64                  // 跳过前 Skip 篇文档，用于从检查点继续
+	Checkpoint func(done int64) error // 每批写入成功后调用，done 包含跳过的文档
+	Progress   func(done int64)       // 每批写入成功后调用
+}
+
+// ExportDocuments 在时间点（PIT）快照上用 search_after 分页读取索引中的全部文档，以 JSON Lines 格式写出
+// 导出期间索引的写入不影响结果，结束时校验导出的文档数与快照中的文档数一致
+func (s *ArticleESService) ExportDocuments(w io.Writer, opts ESExportOptions) (int64, error) {
+	enc := json.NewEncoder(w)
+	return s.scanDocuments(opts.PageSize, nil, func(doc ESDocument) error {
+		return enc.Encode(doc)
+	}, opts.Progress)
+}
+
+// scanDocuments 在时间点（PIT）快照上用 search_after 分页遍历索引中的全部文档，fields 不为空时只读取这些字段
+// 每页处理完后调用 progress，结束时校验遍历的文档数与快照中的文档数一致
+func (s *ArticleESService) scanDocuments(pageSize int, fields []string, visit func(ESDocument) error, progress func(done, total int64)) (int64, error) {
+	if pageSize <= 0 {
+		pageSize = esExportPageSize
+	}
+	ctx := context.Background()
+	pit, err := s.client.OpenPointInTime(s.index).KeepAlive(esPitKeepAlive).Do(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("打开时间点快照失败: %v", err)
+	}
+	pitID := pit.Id
+	defer func() {
+		if _, err := s.client.ClosePointInTime().Id(pitID).Do(ctx); err != nil {
+			global.ZapLog.Warn("关闭时间点快照失败", zap.Error(err))
+		}
+	}()
+
+	var count, total int64
+	var searchAfter []types.FieldValue
+	for {
+		req := s.client.Search().
+			Pit(&types.PointInTimeReference{Id: pitID, KeepAlive: esPitKeepAlive}).
+			Query(&types.Query{MatchAll: &types.MatchAllQuery{}}).
+			Sort("_shard_doc").
+			Size(pageSize).
+			TrackTotalHits(searchAfter == nil)
+		if len(fields) > 0 {
+			req.SourceIncludes_(fields...)
+		}
+		if searchAfter != nil {
+			req.SearchAfter(searchAfter...)
+		}
+		res, err := req.Do(ctx)
+		if err != nil {
+			return count, fmt.Errorf("分页查询失败: %v", err)
+		}
+		if searchAfter == nil && res.Hits.Total != nil {
+			total = res.Hits.Total.Value
+		}
+		if res.PitId != nil {
+			pitID = *res.PitId
+		}
+
+		hits := res.Hits.Hits
+		if len(hits) == 0 {
+			break
+		}
+		for _, hit := range hits {
+			if hit.Id_ == nil {
+				continue
+			}
+			if err := visit(ESDocument{ID: *hit.Id_, Source: hit.Source_}); err != nil {
+				return count, err
+			}
+			count++
+		}
+		if progress != nil {
+			progress(count, total)
+		}
+		searchAfter = hits[len(hits)-1].Sort
+		if len(hits) < pageSize || len(searchAfter) == 0 {
+			break
+		}
+	}
+
+	if count != total {
+		return count, fmt.Errorf("读取的文档数 %d 与索引快照中的文档数 %d 不一致", count, total)
+	}
+	return count, nil
+}
+
+// ImportDocuments 从 JSON Lines 格式逐批写入文档，已存在的同ID文档会被覆盖，返回处理到的文档数（包含跳过的文档）
+// 批量写入失败时按指数退避重试，每批成功后调用检查点回调，中断后可以用 Skip 从检查点继续
+func (s *ArticleESService) ImportDocuments(r io.Reader, opts ESImportOptions) (int64, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = esImportBatch
+	}
+	if opts.Retries < 0 {
+		opts.Retries = 0
+	} else if opts.Retries == 0 {
+		opts.Retries = esImportRetries
+	}
+
+	scanner := bufio.NewScanner(r)
+	// 单篇文章正文可能很长
+	scanner.Buffer(make([]byte, 0, 1<<20), esMaxDocumentSize)
+
+	var body bytes.Buffer
+	var line, batch int64
+	flush := func() error {
+		if batch == 0 {
+			return nil
+		}
+		if err := s.bulkWithRetry(body.Bytes(), opts.Retries); err != nil {
+			return fmt.Errorf("写入第 %d-%d 篇文档失败: %v", line-batch+1, line, err)
+		}
+		batch = 0
+		body.Reset()
+		if opts.Checkpoint != nil {
+			if err := opts.Checkpoint(line); err != nil {
+				return err
+			}
+		}
+		if opts.Progress != nil {
+			opts.Progress(line)
+		}
+		return nil
+	}
+
+	for scanner.Scan() {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		line++
+		if line <= opts.Skip {
+			continue
+		}
+		var doc ESDocument
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return line - batch - 1, fmt.Errorf("第 %d 篇文档格式错误: %v", line, err)
+		}
+		if doc.ID == "" || len(doc.Source) == 0 {
+			return line - batch - 1, fmt.Errorf("第 %d 篇文档缺少 _id 或 _source", line)
+		}
+
+		action, _ := json.Marshal(map[string]map[string]string{"index": {"_id": doc.ID}})
+		body.Write(action)
+		body.WriteByte('\n')
+		body.Write(doc.Source)
+		body.WriteByte('\n')
+		batch++
+		if batch >= int64(opts.BatchSize) {
+			if err := flush(); err != nil {
+				return line - batch, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return line - batch, err
+	}
+	if err := flush(); err != nil {
+		return line - batch, err
+	}
+	if line < opts.Skip {
+		return line, fmt.Errorf("文件只有 %d 篇文档，少于检查点记录的 %d 篇", line, opts.Skip)
+	}
+	return line, nil
+}
+
+// bulkWithRetry 执行批量写入，失败时按 1s、2s、4s… 退避重试；写入按文档ID覆盖，重试不会产生重复文档
+func (s *ArticleESService) bulkWithRetry(body []byte, retries int) error {
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			delay := esRetryBaseDelay << (attempt - 1)
+			global.ZapLog.Warn("批量写入失败，稍后重试", zap.Int("attempt", attempt), zap.Duration("delay", delay), zap.Error(err))
+			time.Sleep(delay)
+		}
+		if err = s.bulk(body); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// bulk 执行一次批量写入，任何一篇文档失败都返回错误
+func (s *ArticleESService) bulk(body []byte) error {
+	return s.bulkIndex(s.index, body)
+}
+
+// bulkIndex 向指定索引批量写入，ignoreStatus 中的状态码不视为失败，如 create 操作遇到已存在文档时的 409
+func (s *ArticleESService) bulkIndex(index string, body []byte, ignoreStatus ...int) error {
+	res, err := s.client.Bulk().Index(index).Raw(bytes.NewReader(body)).Do(context.Background())
+	if err != nil {
+		return fmt.Errorf("批量写入失败: %v", err)
+	}
+	if !res.Errors {
+		return nil
+	}
+	for _, item := range res.Items {
+		for _, result := range item {
+			if result.Error == nil || slices.Contains(ignoreStatus, result.Status) {
+				continue
+			}
+			if result.Error.Reason != nil {
+				return errors.New("批量写入失败: " + *result.Error.Reason)
+			}
+			return errors.New("批量写入失败")
+		}
+	}
+	return nil
+}
+
+// CountDocuments 刷新索引后返回文档数，用于导入后的校验
+func (s *ArticleESService) CountDocuments() (int64, error) {
+	ctx := context.Background()
+	if _, err := s.client.Indices.Refresh().Index(s.index).Do(ctx); err != nil {
+		return 0, fmt.Errorf("刷新索引失败: %v", err)
+	}
+	res, err := s.client.Count().Index(s.index).Do(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("统计文档数失败: %v", err)
+	}
+	return res.Count, nil
+}
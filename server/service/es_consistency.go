@@ -0,0 +1,299 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"server/global"
+	"server/model/appType"
+	"server/model/database"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 一致性检查的参数
+const (
+	esConsistencyBatch       = 500
+	esConsistencySampleLimit = 1000 // 报告中最多列出的不一致文章数
+)
+
+// 索引与数据库不一致的类型
+const (
+	ESDriftMissing = "missing" // 已发布的文章不在索引中
+	ESDriftOrphan  = "orphan"  // 索引中的文档对应的文章已删除或未发布
+	ESDriftStale   = "stale"   // 索引中的文档与数据库中的文章不一致
+)
+
+// ESDrift 一篇不一致的文章
+type ESDrift struct {
+	ArticleID uint     `json:"article_id"`
+	Reason    string   `json:"reason"`           // 见 ESDrift* 常量
+	Fields    []string `json:"fields,omitempty"` // stale 时不一致的字段
+}
+
+// ESConsistencyReport 一致性检查报告
+type ESConsistencyReport struct {
+	CheckedAt     time.Time        `json:"checked_at"`
+	DatabaseCount int64            `json:"database_count"` // 数据库中已发布的文章数
+	IndexCount    int64            `json:"index_count"`    // 索引中的文档数
+	InFlight      int              `json:"in_flight"`      // 有待投递同步事件的文章数，这些文章不参与比较
+	Missing       int              `json:"missing"`
+	Orphan        int              `json:"orphan"`
+	Stale         int              `json:"stale"`
+	Drift         []ESDrift        `json:"drift"`     // 不一致的文章，最多列出 1000 篇
+	Truncated     bool             `json:"truncated"` // Drift 是否被截断
+	Repair        *ESRepairResult  `json:"repair,omitempty"`
+	reindexIDs    []uint           // 需要重新写入的文章：missing 和 stale
+	deleteIDs     []uint           // 需要从索引删除的文档：orphan
+	reasons       map[uint]ESDrift // 全部不一致的文章
+}
+
+// ESRepairResult 修复结果
+type ESRepairResult struct {
+	Indexed int `json:"indexed"` // 重新写入的文档数
+	Deleted int `json:"deleted"` // 删除的文档数
+}
+
+// Consistent 索引与数据库是否一致
+func (r *ESConsistencyReport) Consistent() bool {
+	return r.Missing == 0 && r.Orphan == 0 && r.Stale == 0
+}
+
+// articleState 参与比较的字段，数据库和索引两边都转换为这个结构
+type articleState struct {
+	Status        appType.ArticleStatus `json:"status"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+	ViewCount     int                   `json:"view_count"`
+	LikeCount     int                   `json:"like_count"`
+	CommentCount  int                   `json:"comment_count"`
+	FavoriteCount int                   `json:"favorite_count"`
+}
+
+// diff 返回 doc（索引中的文档）与 want（数据库中的文章）不一致的字段
+func (want articleState) diff(doc articleState) []string {
+	var fields []string
+	if doc.Status != want.Status {
+		fields = append(fields, "status")
+	}
+	// 数据库只保存到毫秒
+	if !doc.UpdatedAt.Truncate(time.Millisecond).Equal(want.UpdatedAt.Truncate(time.Millisecond)) {
+		fields = append(fields, "updated_at")
+	}
+	if doc.ViewCount != want.ViewCount {
+		fields = append(fields, "view_count")
+	}
+	if doc.LikeCount != want.LikeCount {
+		fields = append(fields, "like_count")
+	}
+	if doc.CommentCount != want.CommentCount {
+		fields = append(fields, "comment_count")
+	}
+	if doc.FavoriteCount != want.FavoriteCount {
+		fields = append(fields, "favorite_count")
+	}
+	return fields
+}
+
+// CheckConsistency 比较数据库中已发布的文章和索引中的文档，找出缺失、多余和内容不一致的文章
+// 有待投递同步事件的文章正在同步中，不参与比较
+func (s *ArticleESService) CheckConsistency() (*ESConsistencyReport, error) {
+	report := &ESConsistencyReport{CheckedAt: time.Now(), Drift: []ESDrift{}, reasons: make(map[uint]ESDrift)}
+
+	var pending []uint
+	if err := global.DB.Model(&database.ESOutboxEvent{}).Where("status = ?", appType.ESOutboxPending).
+		Distinct("article_id").Pluck("article_id", &pending).Error; err != nil {
+		return nil, fmt.Errorf("查询待投递的同步事件失败: %v", err)
+	}
+	inFlight := make(map[uint]bool, len(pending))
+	for _, id := range pending {
+		inFlight[id] = true
+	}
+	report.InFlight = len(inFlight)
+
+	// 数据库中已发布的文章
+	want := make(map[uint]articleState)
+	var articles []database.Article
+	err := global.DB.Select("id", "status", "updated_at", "view_count", "like_count", "comment_count", "favorite_count").
+		Where("status = ?", 1).
+		FindInBatches(&articles, esConsistencyBatch, func(tx *gorm.DB, batch int) error {
+			for _, article := range articles {
+				want[article.ID] = articleState{
+					Status:        appType.ArticleStatus(article.Status),
+					UpdatedAt:     article.UpdatedAt,
+					ViewCount:     article.ViewCount,
+					LikeCount:     article.LikeCount,
+					CommentCount:  article.CommentCount,
+					FavoriteCount: article.FavoriteCount,
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return nil, fmt.Errorf("读取文章失败: %v", err)
+	}
+	report.DatabaseCount = int64(len(want))
+
+	// 索引中的文档
+	seen := make(map[uint]bool, len(want))
+	fields := []string{"status", "updated_at", "view_count", "like_count", "comment_count", "favorite_count"}
+	report.IndexCount, err = s.scanDocuments(esExportPageSize, fields, func(doc ESDocument) error {
+		id64, err := strconv.ParseUint(doc.ID, 10, 64)
+		if err != nil {
+			global.ZapLog.Warn("索引中有无法识别的文档ID", zap.String("id", doc.ID))
+			return nil
+		}
+		id := uint(id64)
+		seen[id] = true
+		if inFlight[id] {
+			return nil
+		}
+		expected, ok := want[id]
+		if !ok {
+			report.add(ESDrift{ArticleID: id, Reason: ESDriftOrphan})
+			return nil
+		}
+		var state articleState
+		if err := json.Unmarshal(doc.Source, &state); err != nil {
+			report.add(ESDrift{ArticleID: id, Reason: ESDriftStale, Fields: []string{"_source"}})
+			return nil
+		}
+		if changed := expected.diff(state); len(changed) > 0 {
+			report.add(ESDrift{ArticleID: id, Reason: ESDriftStale, Fields: changed})
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("读取索引失败: %v", err)
+	}
+
+	for id := range want {
+		if !seen[id] && !inFlight[id] {
+			report.add(ESDrift{ArticleID: id, Reason: ESDriftMissing})
+		}
+	}
+	report.finish()
+	return report, nil
+}
+
+// add 记录一篇不一致的文章
+func (r *ESConsistencyReport) add(drift ESDrift) {
+	r.reasons[drift.ArticleID] = drift
+	switch drift.Reason {
+	case ESDriftMissing:
+		r.Missing++
+		r.reindexIDs = append(r.reindexIDs, drift.ArticleID)
+	case ESDriftOrphan:
+		r.Orphan++
+		r.deleteIDs = append(r.deleteIDs, drift.ArticleID)
+	case ESDriftStale:
+		r.Stale++
+		r.reindexIDs = append(r.reindexIDs, drift.ArticleID)
+	}
+}
+
+// finish 按文章ID排序，生成报告中列出的不一致文章
+func (r *ESConsistencyReport) finish() {
+	ids := make([]uint, 0, len(r.reasons))
+	for id := range r.reasons {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	if len(ids) > esConsistencySampleLimit {
+		ids = ids[:esConsistencySampleLimit]
+		r.Truncated = true
+	}
+	for _, id := range ids {
+		r.Drift = append(r.Drift, r.reasons[id])
+	}
+	sort.Slice(r.reindexIDs, func(i, j int) bool { return r.reindexIDs[i] < r.reindexIDs[j] })
+	sort.Slice(r.deleteIDs, func(i, j int) bool { return r.deleteIDs[i] < r.deleteIDs[j] })
+}
+
+// RepairConsistency 按检查报告批量修复：缺失和不一致的文章从数据库重新写入，多余的文档删除
+// 写入时重新读取文章，检查之后发生的修改也会被写入；检查之后被取消发布的文章会改为删除
+// 删除前同样重新读取，检查之后重新发布或正在同步的文章跳过
+func (s *ArticleESService) RepairConsistency(report *ESConsistencyReport) (*ESRepairResult, error) {
+	result := &ESRepairResult{}
+	articleService := ArticleService{}
+
+	for start := 0; start < len(report.reindexIDs); start += esConsistencyBatch {
+		ids := report.reindexIDs[start:min(start+esConsistencyBatch, len(report.reindexIDs))]
+		var articles []database.Article
+		if err := global.DB.Preload("Tags").Preload("Author").Where("id IN ? AND status = ?", ids, 1).Find(&articles).Error; err != nil {
+			return result, err
+		}
+		published := make(map[uint]bool, len(articles))
+		var body bytes.Buffer
+		for _, article := range articles {
+			published[article.ID] = true
+			doc, err := json.Marshal(articleService.convertToESArticle(article))
+			if err != nil {
+				return result, err
+			}
+			writeBulkAction(&body, "index", article.ID)
+			body.Write(doc)
+			body.WriteByte('\n')
+		}
+		for _, id := range ids {
+			if !published[id] {
+				report.deleteIDs = append(report.deleteIDs, id)
+			}
+		}
+		if body.Len() > 0 {
+			if err := s.bulkWithRetry(body.Bytes(), esImportRetries); err != nil {
+				return result, fmt.Errorf("重新写入文档失败: %v", err)
+			}
+		}
+		result.Indexed += len(articles)
+	}
+
+	for start := 0; start < len(report.deleteIDs); start += esConsistencyBatch {
+		ids := report.deleteIDs[start:min(start+esConsistencyBatch, len(report.deleteIDs))]
+		// 删除前重新读取数据库：检查之后重新发布、或有待投递同步事件的文章不能按检查时的状态删除
+		var skip []uint
+		if err := global.DB.Model(&database.Article{}).Where("id IN ? AND status = ?", ids, 1).Pluck("id", &skip).Error; err != nil {
+			return result, err
+		}
+		var pending []uint
+		if err := global.DB.Model(&database.ESOutboxEvent{}).Where("article_id IN ? AND status = ?", ids, appType.ESOutboxPending).
+			Distinct("article_id").Pluck("article_id", &pending).Error; err != nil {
+			return result, err
+		}
+		keep := make(map[uint]bool, len(skip)+len(pending))
+		for _, id := range append(skip, pending...) {
+			keep[id] = true
+		}
+
+		var body bytes.Buffer
+		deleted := 0
+		for _, id := range ids {
+			if keep[id] {
+				continue
+			}
+			writeBulkAction(&body, "delete", id)
+			deleted++
+		}
+		if deleted == 0 {
+			continue
+		}
+		if err := s.bulkWithRetry(body.Bytes(), esImportRetries); err != nil {
+			return result, fmt.Errorf("删除文档失败: %v", err)
+		}
+		result.Deleted += deleted
+	}
+
+	report.Repair = result
+	global.ZapLog.Info("索引一致性修复完成", zap.Int("indexed", result.Indexed), zap.Int("deleted", result.Deleted))
+	return result, nil
+}
+
+// writeBulkAction 写入批量请求的操作行
+func writeBulkAction(body *bytes.Buffer, action string, id uint) {
+	line, _ := json.Marshal(map[string]map[string]string{action: {"_id": strconv.FormatUint(uint64(id), 10)}})
+	body.Write(line)
+	body.WriteByte('\n')
+}
@@ -1,15 +1,33 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"server/global"
+	"server/model/database"
 	"server/model/es"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
+// 重建索引的参数
+const (
+	esReindexBatch     = 500
+	esReindexTargetTTL = 2 * time.Hour // 重建进程异常退出时，双写标记自动失效
+)
+
+// ArticleESService 文章索引管理；s.index 是别名，实际数据在 articles_v2、articles_v3… 等带版本号的索引中
 type ArticleESService struct {
 	client *elasticsearch.TypedClient
 	index  string
@@ -22,51 +40,331 @@ func NewArticleESService() *ArticleESService {
 	}
 }
 
-func (s *ArticleESService) DeleteIndex() error {
-	global.ZapLog.Info("开始删除索引", zap.String("index", s.index))
+// esReindexKey 记录正在重建的新索引名的 Redis 键，服务进程据此把文章写入同时写到新索引
+func esReindexKey(alias string) string {
+	return "es:reindex:" + alias
+}
+
+// esReindexDeletedKey 重建期间被删除的文章ID集合，切换别名前据此清理被分批写入“复活”的文档
+func esReindexDeletedKey(alias string) string {
+	return "es:reindex:deleted:" + alias
+}
+
+// ReindexTarget 返回正在重建的新索引名，没有重建时返回空字符串
+func (s *ArticleESService) ReindexTarget() string {
+	if global.Redis == nil {
+		return ""
+	}
+	target, err := global.Redis.Get(esReindexKey(s.index)).Result()
+	if err != nil {
+		return ""
+	}
+	return target
+}
+
+// AliasIndices 返回别名当前指向的索引
+func (s *ArticleESService) AliasIndices() ([]string, error) {
+	ctx := context.Background()
+	isAlias, err := s.client.Indices.ExistsAlias(s.index).Do(ctx)
+	if err != nil || !isAlias {
+		return nil, err
+	}
+	res, err := s.client.Indices.GetAlias().Name(s.index).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var indices []string
+	for index := range res {
+		indices = append(indices, index)
+	}
+	sort.Strings(indices)
+	return indices, nil
+}
 
-	res, err := s.client.Indices.Delete(s.index).Do(context.Background())
+// isLegacyIndex 别名同名的位置是否是升级前直接创建的普通索引
+func (s *ArticleESService) isLegacyIndex() (bool, error) {
+	ctx := context.Background()
+	isAlias, err := s.client.Indices.ExistsAlias(s.index).Do(ctx)
+	if err != nil || isAlias {
+		return false, err
+	}
+	return s.client.Indices.Exists(s.index).Do(ctx)
+}
+
+// nextIndexName 下一个版本的索引名，升级前的普通索引视为第1版
+func (s *ArticleESService) nextIndexName() (string, error) {
+	res, err := s.client.Indices.Get(s.index + "_v*").Do(context.Background())
 	if err != nil {
-		return fmt.Errorf("删除索引请求失败: %v", err)
+		return "", err
+	}
+	version := 1
+	for index := range res {
+		if v, err := strconv.Atoi(strings.TrimPrefix(index, s.index+"_v")); err == nil && v > version {
+			version = v
+		}
 	}
+	return fmt.Sprintf("%s_v%d", s.index, version+1), nil
+}
 
+// createVersionedIndex 用当前映射和分词配置创建下一个版本的空索引，分词配置变更后需要 Reindex 才能生效
+func (s *ArticleESService) createVersionedIndex() (string, error) {
+	name, err := s.nextIndexName()
+	if err != nil {
+		return "", fmt.Errorf("获取索引版本失败: %v", err)
+	}
+	analysis := s.ResolveAnalysis()
+	res, err := s.client.Indices.Create(name).
+		Settings(es.GetSettings(analysis)).
+		Mappings(es.GetMapping(analysis)).
+		Do(context.Background())
+	if err != nil {
+		return "", fmt.Errorf("创建索引请求失败: %v", err)
+	}
 	if !res.Acknowledged {
-		return fmt.Errorf("删除索引未被确认")
+		return "", fmt.Errorf("索引创建未被确认")
+	}
+	global.ZapLog.Info("索引创建成功", zap.String("index", name), zap.String("tokenizer", analysis.Tokenizer), zap.Bool("pinyin", analysis.Pinyin))
+	return name, nil
+}
+
+// switchAlias 在一次请求中把别名从旧索引切换到新索引，搜索不会中断；升级前的同名普通索引在同一请求中删除
+// 返回切换前别名指向的索引
+func (s *ArticleESService) switchAlias(newIndex string) ([]string, error) {
+	old, err := s.AliasIndices()
+	if err != nil {
+		return nil, err
+	}
+	legacy, err := s.isLegacyIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var actions []types.IndicesAction
+	if legacy {
+		actions = append(actions, types.IndicesAction{RemoveIndex: &types.RemoveIndexAction{Index: &s.index}})
+	}
+	for i := range old {
+		if old[i] != newIndex {
+			actions = append(actions, types.IndicesAction{Remove: &types.RemoveAction{Index: &old[i], Alias: &s.index}})
+		}
+	}
+	actions = append(actions, types.IndicesAction{Add: &types.AddAction{Index: &newIndex, Alias: &s.index}})
+
+	res, err := s.client.Indices.UpdateAliases().Actions(actions...).Do(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("切换索引别名失败: %v", err)
+	}
+	if !res.Acknowledged {
+		return nil, errors.New("切换索引别名未被确认")
+	}
+	global.ZapLog.Info("索引别名已切换", zap.String("alias", s.index), zap.String("index", newIndex), zap.Strings("old", old))
+	return old, nil
+}
+
+// deleteIndices 删除不再使用的旧索引
+func (s *ArticleESService) deleteIndices(indices []string) {
+	for _, index := range indices {
+		if _, err := s.client.Indices.Delete(index).Do(context.Background()); err != nil {
+			global.ZapLog.Warn("删除旧索引失败", zap.String("index", index), zap.Error(err))
+		}
+	}
+}
+
+// DeleteIndex 删除别名及其指向的全部索引
+func (s *ArticleESService) DeleteIndex() error {
+	global.ZapLog.Info("开始删除索引", zap.String("index", s.index))
+
+	indices, err := s.AliasIndices()
+	if err != nil {
+		return fmt.Errorf("获取索引别名失败: %v", err)
+	}
+	if legacy, err := s.isLegacyIndex(); err != nil {
+		return err
+	} else if legacy {
+		indices = append(indices, s.index)
+	}
+	for _, index := range indices {
+		res, err := s.client.Indices.Delete(index).Do(context.Background())
+		if err != nil {
+			return fmt.Errorf("删除索引请求失败: %v", err)
+		}
+		if !res.Acknowledged {
+			return fmt.Errorf("删除索引未被确认")
+		}
 	}
 
-	global.ZapLog.Info("索引删除成功", zap.String("index", s.index))
+	global.ZapLog.Info("索引删除成功", zap.String("index", s.index), zap.Strings("indices", indices))
 	return nil
 }
 
+// CreateIndex 创建一个新版本的空索引并把别名切换过去，再删除旧索引；用于初始化和清空后重新导入
+// 需要保留数据的映射变更请使用 Reindex
 func (s *ArticleESService) CreateIndex() error {
-	exists, err := s.IndexExists()
+	name, err := s.createVersionedIndex()
 	if err != nil {
-		return fmt.Errorf("检查索引存在性失败: %v", err)
+		return err
 	}
-	if exists {
-		global.ZapLog.Info("索引已存在，自动删除并重新创建", zap.String("index", s.index))
+	old, err := s.switchAlias(name)
+	if err != nil {
+		s.deleteIndices([]string{name})
+		return err
+	}
+	s.deleteIndices(old)
+	return nil
+}
+
+// IndexExists 别名（或升级前的同名普通索引）是否存在
+func (s *ArticleESService) IndexExists() (bool, error) {
+	return s.client.Indices.Exists(s.index).Do(context.Background())
+}
 
-		// 调用独立的删除索引方法
-		if err := s.DeleteIndex(); err != nil {
-			return fmt.Errorf("删除索引失败: %v", err)
+// Reindex 零停机重建索引：用当前映射创建新版本索引，从 MySQL 写入全部已发布文章，再原子地切换别名并删除旧索引
+// 重建期间服务进程的文章写入会同时写到新索引（见 IndexDocument），重建时只创建新索引中还不存在的文档，不会覆盖这些更新
+// 分批读取的数据可能早于同期的删除，切换别名前会按删除记录清理新索引（见 applyTombstones）
+func (s *ArticleESService) Reindex() (string, int64, error) {
+	name, err := s.createVersionedIndex()
+	if err != nil {
+		return "", 0, err
+	}
+	if global.Redis != nil {
+		if err := global.Redis.Set(esReindexKey(s.index), name, esReindexTargetTTL).Err(); err != nil {
+			s.deleteIndices([]string{name})
+			return "", 0, fmt.Errorf("记录重建状态失败: %v", err)
 		}
+		defer global.Redis.Del(esReindexKey(s.index), esReindexDeletedKey(s.index))
 	}
 
-	mapping := es.GetMapping()
+	count, err := s.buildFromDatabase(name)
+	if err == nil {
+		err = s.applyTombstones(name)
+	}
+	if err == nil {
+		_, err = s.client.Indices.Refresh().Index(name).Do(context.Background())
+	}
+	if err != nil {
+		s.deleteIndices([]string{name})
+		return "", count, fmt.Errorf("写入新索引失败: %v", err)
+	}
 
-	res, err := s.client.Indices.Create(s.index).Mappings(mapping).Do(context.Background())
+	old, err := s.switchAlias(name)
 	if err != nil {
-		return fmt.Errorf("创建索引请求失败: %v", err)
+		s.deleteIndices([]string{name})
+		return "", count, err
 	}
+	s.deleteIndices(old)
+	return name, count, nil
+}
 
-	if !res.Acknowledged {
-		return fmt.Errorf("索引创建未被确认")
+// buildFromDatabase 分批读取已发布文章写入指定索引
+func (s *ArticleESService) buildFromDatabase(index string) (int64, error) {
+	var count int64
+	var articles []database.Article
+	err := global.DB.Preload("Tags").Preload("Author").Where("status = ?", 1).
+		FindInBatches(&articles, esReindexBatch, func(tx *gorm.DB, batch int) error {
+			var body bytes.Buffer
+			articleService := ArticleService{}
+			for _, article := range articles {
+				doc, err := json.Marshal(articleService.convertToESArticle(article))
+				if err != nil {
+					return err
+				}
+				action, _ := json.Marshal(map[string]map[string]string{"create": {"_id": strconv.FormatUint(uint64(article.ID), 10)}})
+				body.Write(action)
+				body.WriteByte('\n')
+				body.Write(doc)
+				body.WriteByte('\n')
+			}
+			if err := s.bulkIndex(index, body.Bytes(), http.StatusConflict); err != nil {
+				return err
+			}
+			count += int64(len(articles))
+			global.ZapLog.Info("重建索引进度", zap.String("index", index), zap.Int64("count", count))
+			return nil
+		}).Error
+	return count, err
+}
+
+// applyTombstones 从新索引删除重建期间被删除、且在 MySQL 中仍未发布的文章
+// 分批写入用的是读取时的快照，删除发生在读取之后、写入之前时，文档会被重新创建
+func (s *ArticleESService) applyTombstones(index string) error {
+	if global.Redis == nil {
+		return nil
+	}
+	members, err := global.Redis.SMembers(esReindexDeletedKey(s.index)).Result()
+	if err != nil {
+		return fmt.Errorf("读取删除记录失败: %v", err)
+	}
+	var ids []uint
+	for _, member := range members {
+		if id, err := strconv.ParseUint(member, 10, 64); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	// 删除后又重新发布的文章已经由双写写入新索引，不能删除
+	var published []uint
+	if err := global.DB.Model(&database.Article{}).Where("id IN ? AND status = ?", ids, 1).Pluck("id", &published).Error; err != nil {
+		return err
+	}
+	keep := make(map[uint]bool, len(published))
+	for _, id := range published {
+		keep[id] = true
 	}
 
-	global.ZapLog.Info("索引创建成功", zap.String("index", s.index))
+	var body bytes.Buffer
+	var deleted int
+	for _, id := range ids {
+		if keep[id] {
+			continue
+		}
+		writeBulkAction(&body, "delete", id)
+		deleted++
+	}
+	if deleted == 0 {
+		return nil
+	}
+	if err := s.bulkIndex(index, body.Bytes(), http.StatusNotFound); err != nil {
+		return err
+	}
+	global.ZapLog.Info("已清理重建期间删除的文章", zap.String("index", index), zap.Int("count", deleted))
 	return nil
 }
 
-func (s *ArticleESService) IndexExists() (bool, error) {
-	return s.client.Indices.Exists(s.index).Do(context.Background())
+// IndexDocument 写入文档；重建索引期间同时写入新索引，写入新索引失败也返回错误，由发件箱重试
+func (s *ArticleESService) IndexDocument(ctx context.Context, id string, doc interface{}) error {
+	if _, err := s.client.Index(s.index).Id(id).Document(doc).Do(ctx); err != nil {
+		return err
+	}
+	if target := s.ReindexTarget(); target != "" {
+		if _, err := s.client.Index(target).Id(id).Document(doc).Do(ctx); err != nil {
+			return fmt.Errorf("写入重建中的索引 %s 失败: %w", target, err)
+		}
+	}
+	return nil
+}
+
+// DeleteDocument 删除文档；重建索引期间先记录删除，再同时从新索引删除，失败时返回错误由发件箱重试
+func (s *ArticleESService) DeleteDocument(ctx context.Context, id string) error {
+	target := s.ReindexTarget()
+	if target != "" {
+		key := esReindexDeletedKey(s.index)
+		pipe := global.Redis.TxPipeline()
+		pipe.SAdd(key, id)
+		pipe.Expire(key, esReindexTargetTTL)
+		if _, err := pipe.Exec(); err != nil {
+			return fmt.Errorf("记录重建期间的删除失败: %w", err)
+		}
+	}
+	if _, err := s.client.Delete(s.index, id).Do(ctx); err != nil {
+		return err
+	}
+	if target != "" {
+		if _, err := s.client.Delete(target, id).Do(ctx); err != nil {
+			return fmt.Errorf("从重建中的索引 %s 删除失败: %w", target, err)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"errors"
+	"server/global"
+	"server/model/appType"
+	"server/model/database"
+	"server/model/response"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 文章变更事件的投递参数
+const (
+	esOutboxBatch       = 100
+	esOutboxLease       = time.Minute // 投递中的事件在租约期内不会被其他进程重复投递
+	esOutboxBaseBackoff = 5 * time.Second
+	esOutboxMaxBackoff  = time.Hour
+	esOutboxFailedLimit = 50
+)
+
+// ESOutboxService 文章变更到 Elasticsearch 的事务性发件箱
+// 文章的修改和变更事件在同一事务中提交，ES 不可用时事件保留在数据库中，由定时任务按退避策略重试
+type ESOutboxService struct{}
+
+// enqueueArticleSync 在文章修改所在的事务中写入变更事件
+func enqueueArticleSync(tx *gorm.DB, articleID uint, action appType.ESOutboxAction) error {
+	return tx.Create(&database.ESOutboxEvent{
+		ArticleID:     articleID,
+		Action:        action,
+		Status:        appType.ESOutboxPending,
+		NextAttemptAt: time.Now(),
+	}).Error
+}
+
+// esOutboxBackoff 第 attempts 次投递失败后的等待时间：5s、10s、20s… 最长1小时
+func esOutboxBackoff(attempts int) time.Duration {
+	delay := esOutboxBaseBackoff
+	for i := 1; i < attempts && delay < esOutboxMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > esOutboxMaxBackoff {
+		delay = esOutboxMaxBackoff
+	}
+	return delay
+}
+
+// DeliverArticle 立即投递某篇文章待处理的事件，在事务提交后调用以减少索引延迟；失败的事件留给定时任务重试
+func (s *ESOutboxService) DeliverArticle(articleID uint) {
+	var events []database.ESOutboxEvent
+	if err := global.DB.Where("article_id = ? AND status = ? AND next_attempt_at <= ?", articleID, appType.ESOutboxPending, time.Now()).
+		Order("id").Find(&events).Error; err != nil {
+		global.ZapLog.Warn("查询文章变更事件失败", zap.Uint("article_id", articleID), zap.Error(err))
+		return
+	}
+	s.deliverEvents(articleID, events)
+}
+
+// DeliverDue 投递已到期的事件，返回投递成功和失败的事件数
+func (s *ESOutboxService) DeliverDue() (delivered, failed int, err error) {
+	var events []database.ESOutboxEvent
+	if err := global.DB.Where("status = ? AND next_attempt_at <= ?", appType.ESOutboxPending, time.Now()).
+		Order("id").Limit(esOutboxBatch).Find(&events).Error; err != nil {
+		return 0, 0, err
+	}
+
+	// 同一篇文章的多个事件只需要投递一次
+	byArticle := make(map[uint][]database.ESOutboxEvent)
+	var order []uint
+	for _, event := range events {
+		if _, ok := byArticle[event.ArticleID]; !ok {
+			order = append(order, event.ArticleID)
+		}
+		byArticle[event.ArticleID] = append(byArticle[event.ArticleID], event)
+	}
+	for _, articleID := range order {
+		ok, count := s.deliverEvents(articleID, byArticle[articleID])
+		if ok {
+			delivered += count
+		} else {
+			failed += count
+		}
+	}
+	return delivered, failed, nil
+}
+
+// claim 把事件的下次投递时间推迟到租约到期，只返回本进程成功领取的事件
+func (s *ESOutboxService) claim(events []database.ESOutboxEvent) []database.ESOutboxEvent {
+	leaseUntil := time.Now().Add(esOutboxLease)
+	var claimed []database.ESOutboxEvent
+	for _, event := range events {
+		result := global.DB.Model(&database.ESOutboxEvent{}).
+			Where("id = ? AND status = ? AND next_attempt_at = ?", event.ID, appType.ESOutboxPending, event.NextAttemptAt).
+			Update("next_attempt_at", leaseUntil)
+		if result.Error == nil && result.RowsAffected == 1 {
+			claimed = append(claimed, event)
+		}
+	}
+	return claimed
+}
+
+// deliverEvents 领取并投递同一篇文章的事件，返回是否成功和处理的事件数
+// 投递按文章的当前状态进行：已发布则写入索引，未发布或已删除则从索引删除，因此重复投递和乱序投递都不影响结果
+func (s *ESOutboxService) deliverEvents(articleID uint, events []database.ESOutboxEvent) (bool, int) {
+	events = s.claim(events)
+	if len(events) == 0 {
+		return true, 0
+	}
+	ids := make([]uint, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+
+	err := s.syncArticle(articleID)
+	if err == nil {
+		if err := global.DB.Where("id IN ?", ids).Delete(&database.ESOutboxEvent{}).Error; err != nil {
+			global.ZapLog.Warn("删除已投递的文章变更事件失败", zap.Uint("article_id", articleID), zap.Error(err))
+		}
+		return true, len(events)
+	}
+
+	maxAttempts := global.Config.ES.OutboxAttempts()
+	message := truncateRunes(err.Error(), 1000)
+	for _, event := range events {
+		attempts := event.Attempts + 1
+		updates := map[string]interface{}{
+			"attempts":        attempts,
+			"last_error":      message,
+			"next_attempt_at": time.Now().Add(esOutboxBackoff(attempts)),
+		}
+		if attempts >= maxAttempts {
+			updates["status"] = appType.ESOutboxFailed
+		}
+		if err := global.DB.Model(&database.ESOutboxEvent{}).Where("id = ?", event.ID).Updates(updates).Error; err != nil {
+			global.ZapLog.Warn("更新文章变更事件失败", zap.Uint("event_id", event.ID), zap.Error(err))
+		}
+	}
+	global.ZapLog.Warn("投递文章变更事件失败", zap.Uint("article_id", articleID), zap.Int("attempts", events[0].Attempts+1), zap.Error(err))
+	return false, len(events)
+}
+
+// syncArticle 按文章的当前状态同步到 ES
+func (s *ESOutboxService) syncArticle(articleID uint) error {
+	articleService := ArticleService{}
+	var article database.Article
+	err := global.DB.Select("id", "status").Where("id = ?", articleID).First(&article).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return articleService.DeleteArticleFromES(articleID)
+	case err != nil:
+		return err
+	case article.Status == 1:
+		return articleService.SyncArticleToES(articleID)
+	default:
+		return articleService.DeleteArticleFromES(articleID)
+	}
+}
+
+// Status 发件箱的积压情况和最近失败的事件
+func (s *ESOutboxService) Status() (response.ESOutboxStatus, error) {
+	status := response.ESOutboxStatus{FailedEvents: []database.ESOutboxEvent{}}
+	if err := global.DB.Model(&database.ESOutboxEvent{}).Where("status = ?", appType.ESOutboxPending).Count(&status.Pending).Error; err != nil {
+		return status, err
+	}
+	if err := global.DB.Model(&database.ESOutboxEvent{}).Where("status = ?", appType.ESOutboxFailed).Count(&status.Failed).Error; err != nil {
+		return status, err
+	}
+
+	var oldest database.ESOutboxEvent
+	err := global.DB.Where("status = ?", appType.ESOutboxPending).Order("id").First(&oldest).Error
+	if err == nil {
+		status.OldestPendingAt = &oldest.CreatedAt
+		status.LagSeconds = int64(time.Since(oldest.CreatedAt).Seconds())
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return status, err
+	}
+
+	err = global.DB.Where("status = ?", appType.ESOutboxFailed).Order("id DESC").Limit(esOutboxFailedLimit).Find(&status.FailedEvents).Error
+	return status, err
+}
+
+// Retry 把失败的事件重新放回队列，ids 为空时重试全部失败事件，返回重试的事件数
+func (s *ESOutboxService) Retry(ids []uint) (int64, error) {
+	query := global.DB.Model(&database.ESOutboxEvent{}).Where("status = ?", appType.ESOutboxFailed)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	result := query.Updates(map[string]interface{}{
+		"status":          appType.ESOutboxPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+	})
+	return result.RowsAffected, result.Error
+}
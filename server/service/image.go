@@ -4,12 +4,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"os"
 	"path/filepath"
 	"server/global"
 	"server/model/database"
 	"server/model/request"
 	"server/utils"
+	"strings"
 
 	"mime/multipart"
 	"time"
@@ -130,6 +132,49 @@ func UploadImage(file io.Reader, header *multipart.FileHeader, userID uint) (str
 	return imageURL, fmt.Sprintf("%d", media.ID), media, nil
 }
 
+// SaveImageFile 把图片内容保存到 images 目录并创建媒体记录，文件名使用UUID避免批量导入时重名
+func SaveImageFile(file io.Reader, originalName string, userID uint) (database.Media, error) {
+	fileType := mime.TypeByExtension(strings.ToLower(filepath.Ext(originalName)))
+	if !utils.IsImageType(fileType) {
+		return database.Media{}, fmt.Errorf("不支持的图片类型: %s", originalName)
+	}
+
+	uploadPath := "uploads/images"
+	if err := os.MkdirAll(uploadPath, os.ModePerm); err != nil {
+		return database.Media{}, fmt.Errorf("创建目录失败: %v", err)
+	}
+
+	filePath := filepath.Join(uploadPath, utils.GenerateUUID()+strings.ToLower(filepath.Ext(originalName)))
+	out, err := os.Create(filePath)
+	if err != nil {
+		return database.Media{}, fmt.Errorf("创建文件失败: %v", err)
+	}
+	size, err := io.Copy(out, file)
+	out.Close()
+	if err != nil {
+		os.Remove(filePath)
+		return database.Media{}, fmt.Errorf("保存文件失败: %v", err)
+	}
+
+	media := database.Media{
+		Filename:    filepath.Base(originalName),
+		StoragePath: filePath,
+		FileSize:    size,
+		FileType:    fileType,
+		UserID:      userID,
+	}
+	if width, height, err := utils.GetImageDimensions(filePath); err == nil {
+		media.Width = width
+		media.Height = height
+	}
+
+	if err := global.DB.Create(&media).Error; err != nil {
+		os.Remove(filePath)
+		return database.Media{}, fmt.Errorf("保存媒体信息失败: %v", err)
+	}
+	return media, nil
+}
+
 // GetImageByID 根据ID获取图片信息
 func GetImageByID(id uint) (database.Media, error) {
 	var media database.Media
@@ -199,18 +244,21 @@ func DeleteImage(id uint, userID uint) error {
 		return errors.New("没有权限删除此图片")
 	}
 
-	// 删除数据库记录
-	if err := global.DB.Delete(&database.Media{}, "id = ?", id).Error; err != nil {
-		return err
-	}
+	// 删除数据库记录（软删除）并记录删除操作人，文件保留到从回收站彻底删除时再清理
+	return global.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&database.Media{}).Where("id = ?", id).Update("deleted_by", userID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&database.Media{}, "id = ?", id).Error
+	})
+}
 
-	// 删除文件
-	if err := os.Remove(media.StoragePath); err != nil {
+// removeMediaFile 删除图片文件，文件不存在时忽略
+func removeMediaFile(media database.Media) {
+	if err := os.Remove(media.StoragePath); err != nil && !os.IsNotExist(err) {
 		// 记录日志但不阻止删除操作
-		global.ZapLog.Error("删除图片文件失败", zap.Error(err))
+		global.ZapLog.Error("删除图片文件失败", zap.String("path", media.StoragePath), zap.Error(err))
 	}
-
-	return nil
 }
 
 // UpdateImage 更新图片信息
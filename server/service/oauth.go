@@ -0,0 +1,404 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"server/global"
+	"server/model/appType"
+	"server/model/database"
+	"server/utils"
+	"server/utils/oauth"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 第三方登录流程的模式
+const (
+	OAuthModeLogin  = "login"  // 登录或注册
+	OAuthModeLink   = "link"   // 已登录用户绑定第三方账户
+	OAuthModeReauth = "reauth" // 已登录用户通过已绑定的第三方账户重新验证身份
+)
+
+// oauthStateTTL 授权流程的有效期
+const oauthStateTTL = 10 * time.Minute
+
+// OAuthService 第三方登录服务
+type OAuthService struct{}
+
+// oauthState 授权流程状态，保存在Redis中，回调时校验
+type oauthState struct {
+	Provider     string `json:"provider"`
+	Mode         string `json:"mode"`
+	UserID       uint   `json:"user_id,omitempty"` // 绑定模式下发起绑定的用户
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+}
+
+// OAuthResult 回调处理结果
+type OAuthResult struct {
+	Mode        string
+	User        database.User
+	Created     bool   // 是否新建了用户
+	ReauthToken string // 重新验证身份成功后签发的一次性令牌
+}
+
+// oauthStateKey 授权流程状态的Redis键
+func oauthStateKey(state string) string {
+	return "oauth_state:" + state
+}
+
+// randomToken 生成随机十六进制串
+func randomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+var (
+	oauthRegistry     *oauth.Registry
+	oauthRegistryErr  error
+	oauthRegistryOnce sync.Once
+)
+
+// registry 根据配置构建提供方集合，只构建一次以复用 OIDC 发现文档缓存
+func (s *OAuthService) registry() (*oauth.Registry, error) {
+	oauthRegistryOnce.Do(func() {
+		oauthRegistry, oauthRegistryErr = oauth.BuildRegistry(global.Config.QQ, global.Config.OAuth, nil)
+		if oauthRegistryErr != nil {
+			global.ZapLog.Error("第三方登录配置错误", zap.Error(oauthRegistryErr))
+		}
+	})
+	return oauthRegistry, oauthRegistryErr
+}
+
+// Provider 根据名称获取已启用的提供方
+func (s *OAuthService) Provider(name string) (oauth.Provider, error) {
+	registry, err := s.registry()
+	if err != nil {
+		return nil, err
+	}
+	provider, ok := registry.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("不支持的登录方式: %s", name)
+	}
+	return provider, nil
+}
+
+// EnabledProviders 已启用的提供方名称
+func (s *OAuthService) EnabledProviders() []string {
+	registry, err := s.registry()
+	if err != nil {
+		return nil
+	}
+	return registry.Names()
+}
+
+// Begin 发起授权流程，返回跳转地址
+func (s *OAuthService) Begin(providerName, mode string, userID uint) (string, error) {
+	provider, err := s.Provider(providerName)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	data, _ := json.Marshal(oauthState{
+		Provider:     provider.Name(),
+		Mode:         mode,
+		UserID:       userID,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+	})
+	if err := global.Redis.Set(oauthStateKey(state), data, oauthStateTTL).Err(); err != nil {
+		return "", err
+	}
+
+	return provider.AuthURL(oauth.AuthRequest{State: state, Nonce: nonce, CodeVerifier: verifier})
+}
+
+// takeState 取出并删除授权流程状态，每个 state 只能使用一次
+func (s *OAuthService) takeState(state string) (*oauthState, error) {
+	if state == "" {
+		return nil, errors.New("缺少 state 参数")
+	}
+	pipe := global.Redis.TxPipeline()
+	get := pipe.Get(oauthStateKey(state))
+	pipe.Del(oauthStateKey(state))
+	if _, err := pipe.Exec(); err != nil {
+		return nil, errors.New("授权已过期，请重新登录")
+	}
+
+	var st oauthState
+	if err := json.Unmarshal([]byte(get.Val()), &st); err != nil {
+		return nil, errors.New("授权状态无效")
+	}
+	return &st, nil
+}
+
+// Callback 处理提供方回调：校验 state，换取第三方账户信息，再登录、注册或绑定
+func (s *OAuthService) Callback(ctx context.Context, providerName, code, state string) (result OAuthResult, err error) {
+	st, err := s.takeState(state)
+	if err != nil {
+		return result, err
+	}
+	if st.Provider != providerName {
+		return result, errors.New("授权状态与登录方式不匹配")
+	}
+
+	provider, err := s.Provider(providerName)
+	if err != nil {
+		return result, err
+	}
+	identity, err := provider.Exchange(ctx, code, oauth.AuthRequest{State: state, Nonce: st.Nonce, CodeVerifier: st.CodeVerifier})
+	if err != nil {
+		return result, err
+	}
+
+	result.Mode = st.Mode
+	switch st.Mode {
+	case OAuthModeLink:
+		result.User, err = s.link(st.UserID, identity)
+		return result, err
+	case OAuthModeReauth:
+		result.User, result.ReauthToken, err = s.reauth(st.UserID, identity)
+		return result, err
+	}
+	result.User, result.Created, err = s.loginOrRegister(ctx, identity)
+	return result, err
+}
+
+// loginOrRegister 根据第三方账户查找已绑定的用户，未绑定时创建新用户
+func (s *OAuthService) loginOrRegister(ctx context.Context, identity *oauth.Identity) (user database.User, created bool, err error) {
+	var link database.UserOAuth
+	err = global.DB.Where("provider = ? AND subject = ?", identity.Provider, identity.Subject).First(&link).Error
+	if err == nil {
+		if err = global.DB.Where("id = ?", link.UserID).First(&user).Error; err != nil {
+			return user, false, errors.New("绑定的用户不存在")
+		}
+		if user.Status == 0 {
+			return user, false, errors.New("用户已被禁用，请联系管理员")
+		}
+		// 同步第三方资料
+		global.DB.Model(&link).Updates(map[string]interface{}{"nickname": identity.Nickname, "avatar": identity.Avatar})
+		s.touchLastLogin(&user)
+		return user, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, false, err
+	}
+
+	// 提供方确认过的邮箱与本地已验证邮箱一致时，视为同一人，自动绑定
+	if identity.Email != "" && identity.EmailVerified {
+		err = global.DB.Where("email = ? AND email_verified IS NOT NULL", identity.Email).First(&user).Error
+		if err == nil {
+			if user.Status == 0 {
+				return user, false, errors.New("用户已被禁用，请联系管理员")
+			}
+			if user, err = s.link(user.ID, identity); err != nil {
+				return user, false, err
+			}
+			s.touchLastLogin(&user)
+			return user, false, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return user, false, err
+		}
+	}
+
+	user, err = s.createUser(ctx, identity)
+	if err != nil {
+		return user, false, err
+	}
+	s.touchLastLogin(&user)
+	return user, true, nil
+}
+
+// createUser 为第三方账户创建本地用户并建立绑定
+func (s *OAuthService) createUser(ctx context.Context, identity *oauth.Identity) (user database.User, err error) {
+	suffix, err := randomToken(4)
+	if err != nil {
+		return user, err
+	}
+	// 第三方账户不一定提供邮箱，使用占位邮箱，用户之后可通过邮箱修改流程绑定真实邮箱
+	placeholder, err := randomToken(8)
+	if err != nil {
+		return user, err
+	}
+	password, err := randomToken(16)
+	if err != nil {
+		return user, err
+	}
+
+	nickname := identity.Nickname
+	if nickname == "" {
+		nickname = identity.Provider + "用户"
+	}
+	user = database.User{
+		Username:    identity.Provider + "_" + suffix,
+		Email:       fmt.Sprintf("%s_%s@oauth.invalid", identity.Provider, placeholder),
+		Password:    utils.BcryptHash(password),
+		Nickname:    nickname,
+		Avatar:      s.importAvatar(ctx, identity.Avatar),
+		LoginMethod: appType.LoginType(identity.Provider),
+	}
+	user.Status = 1
+
+	// 提供方确认过且本地未被占用的邮箱直接作为用户邮箱
+	if identity.Email != "" && identity.EmailVerified {
+		var count int64
+		if err := global.DB.Model(&database.User{}).Where("email = ?", identity.Email).Count(&count).Error; err == nil && count == 0 {
+			now := time.Now()
+			user.Email = identity.Email
+			user.EmailVerified = &now
+		}
+	}
+
+	err = global.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return tx.Create(&database.UserOAuth{
+			UserID:   user.ID,
+			Provider: identity.Provider,
+			Subject:  identity.Subject,
+			UnionID:  identity.UnionID,
+			Nickname: identity.Nickname,
+			Avatar:   identity.Avatar,
+		}).Error
+	})
+	return user, err
+}
+
+// link 为已登录用户绑定第三方账户
+func (s *OAuthService) link(userID uint, identity *oauth.Identity) (user database.User, err error) {
+	if err = global.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		return user, errors.New("用户不存在")
+	}
+
+	var existing database.UserOAuth
+	err = global.DB.Where("provider = ? AND subject = ?", identity.Provider, identity.Subject).First(&existing).Error
+	if err == nil {
+		if existing.UserID == userID {
+			return user, nil
+		}
+		return user, errors.New("该第三方账户已绑定其他用户")
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, err
+	}
+
+	err = global.DB.Create(&database.UserOAuth{
+		UserID:   userID,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		UnionID:  identity.UnionID,
+		Nickname: identity.Nickname,
+		Avatar:   identity.Avatar,
+	}).Error
+	if err != nil && strings.Contains(err.Error(), "Duplicate entry") {
+		return user, errors.New("已绑定该登录方式，请先解绑")
+	}
+	return user, err
+}
+
+// reauth 确认第三方账户已绑定到发起验证的用户，签发一次性身份验证令牌
+func (s *OAuthService) reauth(userID uint, identity *oauth.Identity) (user database.User, token string, err error) {
+	var link database.UserOAuth
+	err = global.DB.Where("provider = ? AND subject = ? AND user_id = ?", identity.Provider, identity.Subject, userID).First(&link).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, "", errors.New("该第三方账户未绑定当前用户")
+	}
+	if err != nil {
+		return user, "", err
+	}
+	if err = global.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		return user, "", errors.New("用户不存在")
+	}
+	token, err = issueReauthToken(userID)
+	return user, token, err
+}
+
+// Links 用户已绑定的第三方账户
+func (s *OAuthService) Links(userID uint) (links []database.UserOAuth, err error) {
+	err = global.DB.Where("user_id = ?", userID).Order("id").Find(&links).Error
+	return links, err
+}
+
+// Unlink 解绑第三方账户，解绑后用户必须仍有可用的登录方式
+func (s *OAuthService) Unlink(userID uint, providerName string) error {
+	var user database.User
+	if err := global.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		return errors.New("用户不存在")
+	}
+
+	var count int64
+	if err := global.DB.Model(&database.UserOAuth{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return err
+	}
+	if user.LoginMethod != appType.LoginTypePassword && count <= 1 {
+		return errors.New("这是账户唯一的登录方式，请先通过找回密码设置密码后再解绑")
+	}
+
+	result := global.DB.Where("user_id = ? AND provider = ?", userID, providerName).Delete(&database.UserOAuth{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("未绑定该登录方式")
+	}
+	return nil
+}
+
+// importAvatar 把第三方头像下载到本地，失败时保留原地址
+func (s *OAuthService) importAvatar(ctx context.Context, avatarURL string) string {
+	if avatarURL == "" {
+		return ""
+	}
+	data, ext, err := oauth.FetchAvatar(ctx, nil, avatarURL)
+	if err != nil {
+		global.ZapLog.Warn("导入第三方头像失败", zap.String("url", avatarURL), zap.Error(err))
+		return avatarURL
+	}
+
+	avatarDir := "uploads/avatars"
+	if err := os.MkdirAll(avatarDir, 0755); err != nil {
+		return avatarURL
+	}
+	fileName := utils.GenerateUUID() + ext
+	if err := os.WriteFile(filepath.Join(avatarDir, fileName), data, 0644); err != nil {
+		global.ZapLog.Warn("保存第三方头像失败", zap.Error(err))
+		return avatarURL
+	}
+	return "/uploads/avatars/" + fileName
+}
+
+// touchLastLogin 更新最后登录时间
+func (s *OAuthService) touchLastLogin(user *database.User) {
+	now := time.Now()
+	if err := global.DB.Model(user).Update("last_login_at", now).Error; err == nil {
+		user.LastLoginAt = &now
+	}
+}
@@ -9,8 +9,8 @@ import (
 type PageService struct{}
 
 // CreatePage 创建页面
-func (s *PageService) CreatePage(page database.Page) error {
-	return global.DB.Create(&page).Error
+func (s *PageService) CreatePage(page *database.Page) error {
+	return global.DB.Create(page).Error
 }
 
 // GetPageByID 根据ID获取页面
@@ -0,0 +1,411 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"server/global"
+	"server/model/appType"
+	"server/model/database"
+	"server/model/response"
+	"server/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 浏览统计在 Redis 中的键：浏览量和来源计数累加在哈希中，定时任务把哈希整体改名后写入数据库；
+// 独立访客用每天每个统计范围一个 HyperLogLog 估算
+const (
+	pageViewPendingKey  = "analytics:pending"
+	pageViewFlushingKey = "analytics:flushing" // 写入中的数据，写入失败时下次继续写入
+	pageViewLockKey     = "analytics:lock"     // 多个服务进程同时执行写入任务时只有一个生效
+	pageViewLockTTL     = time.Minute
+	pageViewVisitorTTL  = 49 * time.Hour // 当天的 HyperLogLog 保留到次日的数据写入之后
+	pageViewSaltTTL     = 48 * time.Hour
+	pageViewFlushBatch  = 500
+	referrerMaxLength   = 100
+	utmMaxLength        = 50
+)
+
+// 哈希字段名的前缀
+const (
+	pageViewMetricViews    = "pv"
+	pageViewMetricReferrer = "ref"
+)
+
+// PageViewService 文章浏览统计服务
+// 不保存IP和 User-Agent：访客标识是二者加上每天轮换的随机盐后的哈希，只用于当天的独立访客估算
+type PageViewService struct{}
+
+// PageView 一次文章浏览
+type PageView struct {
+	ArticleID   uint
+	Referrer    string
+	UTMSource   string
+	UTMMedium   string
+	UTMCampaign string
+	IP          string
+	UserAgent   string
+	Host        string // 本站域名，从本站页面跳转的浏览不计入来源网站
+}
+
+// pageViewKey 浏览量的维度，编码为 JSON 作为 Redis 哈希字段名的一部分
+type pageViewKey struct {
+	Date     string                `json:"d"`
+	Scope    appType.PageViewScope `json:"s"`
+	TargetID uint                  `json:"t"`
+}
+
+// visitorsKey 当天该范围独立访客的 HyperLogLog
+func (k pageViewKey) visitorsKey() string {
+	return fmt.Sprintf("analytics:uv:%s:%s:%d", k.Date, k.Scope, k.TargetID)
+}
+
+// referrerKey 来源统计的维度
+type referrerKey struct {
+	Date      string `json:"d"`
+	ArticleID uint   `json:"a"`
+	Referrer  string `json:"r"`
+	Source    string `json:"us"`
+	Medium    string `json:"um"`
+	Campaign  string `json:"uc"`
+}
+
+// normalizeReferrer 只保留来源页面的域名，本站页面和无法解析的地址返回空字符串
+func normalizeReferrer(referrer, host string) string {
+	u, err := url.Parse(strings.TrimSpace(referrer))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	domain := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if domain == strings.TrimPrefix(strings.ToLower(host), "www.") {
+		return ""
+	}
+	return truncateRunes(domain, referrerMaxLength)
+}
+
+// normalizeUTM 规范化 UTM 参数：去掉首尾空白并转为小写
+func normalizeUTM(value string) string {
+	return truncateRunes(strings.ToLower(strings.TrimSpace(value)), utmMaxLength)
+}
+
+// dailySalt 当天的随机盐，多个服务进程共用，过期后无法再从哈希关联到访客
+func dailySalt(date string) (string, error) {
+	key := "analytics:salt:" + date
+	salt, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+	if err := global.Redis.SetNX(key, salt, pageViewSaltTTL).Err(); err != nil {
+		return "", err
+	}
+	return global.Redis.Get(key).Result()
+}
+
+// Record 记录一次文章浏览，返回是否计入；爬虫和未发布的文章不计入
+// 一次浏览同时计入全站、文章、分类和作者四个范围
+func (s *PageViewService) Record(view PageView) (bool, error) {
+	if utils.IsBot(view.UserAgent) {
+		return false, nil
+	}
+	if global.Redis == nil {
+		return false, errors.New("Redis未初始化")
+	}
+	var article database.Article
+	err := global.DB.Select("id", "category_id", "author_id", "status").Where("id = ?", view.ArticleID).First(&article).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, errors.New("文章不存在")
+	}
+	if err != nil {
+		return false, err
+	}
+	if article.Status != 1 {
+		return false, nil
+	}
+
+	date := time.Now().Format("2006-01-02")
+	salt, err := dailySalt(date)
+	if err != nil {
+		return false, err
+	}
+	sum := sha256.Sum256([]byte(salt + "\n" + view.IP + "\n" + view.UserAgent))
+	visitor := hex.EncodeToString(sum[:16])
+
+	keys := []pageViewKey{
+		{Date: date, Scope: appType.PageViewSite},
+		{Date: date, Scope: appType.PageViewArticle, TargetID: article.ID},
+		{Date: date, Scope: appType.PageViewAuthor, TargetID: article.AuthorID},
+	}
+	if article.CategoryID != 0 {
+		keys = append(keys, pageViewKey{Date: date, Scope: appType.PageViewCategory, TargetID: article.CategoryID})
+	}
+
+	pipe := global.Redis.Pipeline()
+	for _, key := range keys {
+		data, err := json.Marshal(key)
+		if err != nil {
+			return false, err
+		}
+		pipe.HIncrBy(pageViewPendingKey, pageViewMetricViews+" "+string(data), 1)
+		pipe.PFAdd(key.visitorsKey(), visitor)
+		pipe.Expire(key.visitorsKey(), pageViewVisitorTTL)
+	}
+	data, err := json.Marshal(referrerKey{
+		Date:      date,
+		ArticleID: article.ID,
+		Referrer:  normalizeReferrer(view.Referrer, view.Host),
+		Source:    normalizeUTM(view.UTMSource),
+		Medium:    normalizeUTM(view.UTMMedium),
+		Campaign:  normalizeUTM(view.UTMCampaign),
+	})
+	if err != nil {
+		return false, err
+	}
+	pipe.HIncrBy(pageViewPendingKey, pageViewMetricReferrer+" "+string(data), 1)
+	if _, err := pipe.Exec(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Flush 把 Redis 中累计的浏览统计写入数据库，返回写入的行数
+// 浏览量和来源计数累加；独立访客数取当天 HyperLogLog 的当前估算值，只增不减
+func (s *PageViewService) Flush() (int, error) {
+	if global.Redis == nil {
+		return 0, nil
+	}
+	token, locked, err := acquireLock(pageViewLockKey, pageViewLockTTL)
+	if err != nil || !locked {
+		return 0, err
+	}
+	defer releaseLock(pageViewLockKey, token)
+
+	ok, err := claimKey(pageViewPendingKey, pageViewFlushingKey)
+	if err != nil || !ok {
+		return 0, err
+	}
+	fields, err := global.Redis.HGetAll(pageViewFlushingKey).Result()
+	if err != nil {
+		return 0, err
+	}
+	views, visitorKeys, referrers := parsePageViewStats(fields)
+
+	if len(views) > 0 {
+		pipe := global.Redis.Pipeline()
+		counts := make([]*redis.IntCmd, len(views))
+		for i := range views {
+			counts[i] = pipe.PFCount(visitorKeys[i])
+		}
+		if _, err := pipe.Exec(); err != nil {
+			return 0, err
+		}
+		for i := range views {
+			views[i].Visitors = counts[i].Val()
+		}
+	}
+
+	err = global.DB.Transaction(func(tx *gorm.DB) error {
+		if len(views) > 0 {
+			if err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "date"}, {Name: "scope"}, {Name: "target_id"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"page_views": gorm.Expr("page_views + VALUES(page_views)"),
+					"visitors":   gorm.Expr("GREATEST(visitors, VALUES(visitors))"),
+				}),
+			}).CreateInBatches(&views, pageViewFlushBatch).Error; err != nil {
+				return err
+			}
+		}
+		if len(referrers) > 0 {
+			if err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "date"}, {Name: "article_id"}, {Name: "referrer"}, {Name: "utm_source"}, {Name: "utm_medium"}, {Name: "utm_campaign"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"views": gorm.Expr("views + VALUES(views)"),
+				}),
+			}).CreateInBatches(&referrers, pageViewFlushBatch).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("写入浏览统计失败: %v", err)
+	}
+	if err := global.Redis.Del(pageViewFlushingKey).Err(); err != nil {
+		global.ZapLog.Warn("删除已写入的浏览统计失败", zap.Error(err))
+	}
+	return len(views) + len(referrers), nil
+}
+
+// parsePageViewStats 把 Redis 哈希（字段为 "前缀 维度JSON"）转换为数据库记录，同时返回每条浏览量记录对应的 HyperLogLog 键
+func parsePageViewStats(fields map[string]string) ([]database.PageViewStat, []string, []database.ReferrerStat) {
+	var views []database.PageViewStat
+	var visitorKeys []string
+	var referrers []database.ReferrerStat
+	for field, value := range fields {
+		i := strings.IndexByte(field, ' ')
+		if i < 0 {
+			continue
+		}
+		count, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		switch field[:i] {
+		case pageViewMetricViews:
+			var key pageViewKey
+			if err := json.Unmarshal([]byte(field[i+1:]), &key); err != nil {
+				global.ZapLog.Warn("忽略无法解析的浏览统计", zap.String("field", field), zap.Error(err))
+				continue
+			}
+			date, err := time.ParseInLocation("2006-01-02", key.Date, time.Local)
+			if err != nil {
+				continue
+			}
+			views = append(views, database.PageViewStat{Date: date, Scope: key.Scope, TargetID: key.TargetID, PageViews: count})
+			visitorKeys = append(visitorKeys, key.visitorsKey())
+		case pageViewMetricReferrer:
+			var key referrerKey
+			if err := json.Unmarshal([]byte(field[i+1:]), &key); err != nil {
+				global.ZapLog.Warn("忽略无法解析的来源统计", zap.String("field", field), zap.Error(err))
+				continue
+			}
+			date, err := time.ParseInLocation("2006-01-02", key.Date, time.Local)
+			if err != nil {
+				continue
+			}
+			referrers = append(referrers, database.ReferrerStat{
+				Date:        date,
+				ArticleID:   key.ArticleID,
+				Referrer:    key.Referrer,
+				UTMSource:   key.Source,
+				UTMMedium:   key.Medium,
+				UTMCampaign: key.Campaign,
+				Views:       count,
+			})
+		}
+	}
+	return views, visitorKeys, referrers
+}
+
+// Report 最近 days 天（含今天）的浏览统计：每天的浏览量和独立访客数，以及文章、来源网站和推广活动排行，各取前 size 个
+// 访客标识每天换盐，多天的独立访客只能按天相加（VisitorDays），不能合并去重
+// scope 为全站时忽略 targetID；生成前先把 Redis 中尚未写入的统计写入数据库
+func (s *PageViewService) Report(scope appType.PageViewScope, targetID uint, days, size int) (response.PageViewReport, error) {
+	if _, err := s.Flush(); err != nil {
+		global.ZapLog.Warn("写入浏览统计失败，报表不包含最近的数据", zap.Error(err))
+	}
+	if scope == appType.PageViewSite {
+		targetID = 0
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), now.Day()-days+1, 0, 0, 0, 0, time.Local)
+	fromDate := from.Format("2006-01-02")
+	report := response.PageViewReport{
+		Scope:    scope,
+		TargetID: targetID,
+		From:     fromDate,
+		To:       now.Format("2006-01-02"),
+		Series:   []response.PageViewPoint{},
+	}
+
+	var stats []database.PageViewStat
+	if err := global.DB.Where("scope = ? AND target_id = ? AND date >= ?", scope, targetID, fromDate).Find(&stats).Error; err != nil {
+		return report, err
+	}
+	byDate := make(map[string]database.PageViewStat, len(stats))
+	for _, stat := range stats {
+		byDate[stat.Date.Format("2006-01-02")] = stat
+	}
+	for day := from; !day.After(now); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		stat := byDate[date]
+		report.Series = append(report.Series, response.PageViewPoint{Date: date, PageViews: stat.PageViews, Visitors: stat.Visitors})
+		report.PageViews += stat.PageViews
+		report.VisitorDays += stat.Visitors
+	}
+
+	// 范围内的文章：分类和作者按文章表关联，全站不限制
+	scoped := func(db *gorm.DB, articleColumn string) *gorm.DB {
+		switch scope {
+		case appType.PageViewArticle:
+			return db.Where(articleColumn+" = ?", targetID)
+		case appType.PageViewCategory:
+			return db.Joins("JOIN articles ON articles.id = "+articleColumn).Where("articles.category_id = ?", targetID)
+		case appType.PageViewAuthor:
+			return db.Joins("JOIN articles ON articles.id = "+articleColumn).Where("articles.author_id = ?", targetID)
+		}
+		return db
+	}
+
+	if scope != appType.PageViewArticle {
+		query := global.DB.Table("page_view_stats").
+			Select("page_view_stats.target_id AS article_id, articles.title AS title, "+
+				"SUM(page_view_stats.page_views) AS page_views, SUM(page_view_stats.visitors) AS visitor_days").
+			Joins("JOIN articles ON articles.id = page_view_stats.target_id").
+			Where("page_view_stats.scope = ? AND page_view_stats.date >= ?", appType.PageViewArticle, fromDate)
+		switch scope {
+		case appType.PageViewCategory:
+			query = query.Where("articles.category_id = ?", targetID)
+		case appType.PageViewAuthor:
+			query = query.Where("articles.author_id = ?", targetID)
+		}
+		if err := query.Group("page_view_stats.target_id, articles.title").
+			Order("page_views DESC").Limit(size).Scan(&report.TopArticles).Error; err != nil {
+			return report, err
+		}
+	}
+
+	referrerQuery := func() *gorm.DB {
+		return scoped(global.DB.Table("referrer_stats").Where("referrer_stats.date >= ?", fromDate), "referrer_stats.article_id")
+	}
+	if err := referrerQuery().Select("referrer_stats.referrer AS referrer, SUM(referrer_stats.views) AS views").
+		Group("referrer_stats.referrer").Order("views DESC").Limit(size).Scan(&report.Referrers).Error; err != nil {
+		return report, err
+	}
+	if err := referrerQuery().
+		Select("referrer_stats.utm_source AS source, referrer_stats.utm_medium AS medium, referrer_stats.utm_campaign AS campaign, SUM(referrer_stats.views) AS views").
+		Where("(referrer_stats.utm_source <> '' OR referrer_stats.utm_medium <> '' OR referrer_stats.utm_campaign <> '')").
+		Group("referrer_stats.utm_source, referrer_stats.utm_medium, referrer_stats.utm_campaign").
+		Order("views DESC").Limit(size).Scan(&report.Campaigns).Error; err != nil {
+		return report, err
+	}
+
+	if report.TopArticles == nil {
+		report.TopArticles = []response.ArticleViewRank{}
+	}
+	if report.Referrers == nil {
+		report.Referrers = []response.ReferrerRank{}
+	}
+	if report.Campaigns == nil {
+		report.Campaigns = []response.CampaignRank{}
+	}
+	return report, nil
+}
+
+// CleanupExpired 删除超过保留天数的浏览统计和来源统计
+func (s *PageViewService) CleanupExpired(retentionDays int) (int64, error) {
+	cutoff := time.Now().AddDate(0, 0, -retentionDays).Format("2006-01-02")
+	result := global.DB.Where("date < ?", cutoff).Delete(&database.PageViewStat{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	deleted := result.RowsAffected
+	result = global.DB.Where("date < ?", cutoff).Delete(&database.ReferrerStat{})
+	return deleted + result.RowsAffected, result.Error
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"errors"
+	"server/global"
+	"server/model/appType"
+	"server/model/database"
+	"server/utils"
+	"strconv"
+	"time"
+)
+
+// reauthTokenTTL 第三方账户重新登录后签发的身份验证令牌的有效期
+const reauthTokenTTL = 5 * time.Minute
+
+// reauthTokenKey 身份验证令牌的Redis键，值为用户ID
+func reauthTokenKey(token string) string {
+	return "reauth:" + token
+}
+
+// ReauthProof 敏感操作前再次证明身份的凭据
+type ReauthProof struct {
+	Password    string // 密码登录的账户必填
+	Code        string // 两步验证码或恢复码，开启两步验证时必填
+	ReauthToken string // 第三方账户重新登录后签发的一次性令牌
+}
+
+// issueReauthToken 为通过第三方账户重新登录的用户签发一次性身份验证令牌
+func issueReauthToken(userID uint) (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	if err := global.Redis.Set(reauthTokenKey(token), userID, reauthTokenTTL).Err(); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// consumeReauthToken 取出并删除令牌，令牌有效且属于该用户时返回 true
+func consumeReauthToken(userID uint, token string) bool {
+	if token == "" {
+		return false
+	}
+	pipe := global.Redis.TxPipeline()
+	get := pipe.Get(reauthTokenKey(token))
+	pipe.Del(reauthTokenKey(token))
+	if _, err := pipe.Exec(); err != nil {
+		return false
+	}
+	return get.Val() == strconv.FormatUint(uint64(userID), 10)
+}
+
+// Reauthenticate 敏感操作（注销账户、修改邮箱）前再次验证身份
+// 密码登录的账户验证密码；开启两步验证时必须再提供验证码或恢复码；
+// 第三方登录创建且未开启两步验证的账户没有可用的密码，必须先通过第三方账户重新登录，提交签发的一次性令牌
+func (s *AccountService) Reauthenticate(user database.User, proof ReauthProof) error {
+	if user.LoginMethod == appType.LoginTypePassword && !utils.BcryptCheck(proof.Password, user.Password) {
+		return errors.New("密码错误")
+	}
+	if user.IsTwoFactorEnabled() {
+		if !(&TwoFactorService{}).Verify(user, proof.Code) {
+			return errors.New("两步验证码错误")
+		}
+		return nil
+	}
+	if user.LoginMethod != appType.LoginTypePassword && !consumeReauthToken(user.ID, proof.ReauthToken) {
+		return errors.New("请先通过第三方账户重新登录验证身份")
+	}
+	return nil
+}
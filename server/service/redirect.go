@@ -0,0 +1,45 @@
+package service
+
+import (
+	"server/global"
+	"server/model/database"
+	"server/utils/importer"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RedirectService 旧地址重定向服务
+type RedirectService struct{}
+
+// Save 新建或覆盖旧地址的重定向
+func (s *RedirectService) Save(tx *gorm.DB, fromPath, toPath, source string) error {
+	redirect := database.Redirect{FromPath: fromPath, ToPath: toPath, StatusCode: 301, Source: source}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "from_path"}},
+		DoUpdates: clause.AssignmentColumns([]string{"to_path", "status_code", "source", "updated_at"}),
+	}).Create(&redirect).Error
+}
+
+// Resolve 查找旧地址对应的重定向，先按带查询参数的完整地址匹配，再按路径匹配
+func (s *RedirectService) Resolve(requestURI string) (database.Redirect, bool) {
+	full := importer.NormalizePath(requestURI)
+	candidates := []string{full}
+	if i := strings.IndexByte(full, '?'); i >= 0 {
+		candidates = append(candidates, full[:i])
+	}
+
+	var redirects []database.Redirect
+	if err := global.DB.Where("from_path IN ?", candidates).Find(&redirects).Error; err != nil || len(redirects) == 0 {
+		return database.Redirect{}, false
+	}
+	for _, candidate := range candidates {
+		for _, redirect := range redirects {
+			if redirect.FromPath == candidate {
+				return redirect, true
+			}
+		}
+	}
+	return database.Redirect{}, false
+}
@@ -0,0 +1,372 @@
+package service
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"server/global"
+	"server/model/database"
+	"server/model/es"
+	"server/model/response"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 搜索统计在 Redis 中的键：每次搜索只做哈希字段自增，定时任务把待写入的哈希整体改名后写入数据库
+const (
+	searchStatsPendingKey  = "search:analytics:pending"
+	searchStatsFlushingKey = "search:analytics:flushing" // 写入中的数据，写入失败时下次继续写入
+	searchStatsLockKey     = "search:analytics:lock"     // 多个服务进程同时执行写入任务时只有一个生效
+	searchStatsLockTTL     = time.Minute
+	searchClickDedupTTL    = 30 * time.Minute // 同一访客对同一搜索结果的重复点击只计一次
+	searchResultTTL        = 30 * time.Minute // 搜索ID的有效期，过期后的点击不再计入
+	searchStatsQueryLength = 100
+	searchStatsFilterSize  = 255
+	searchStatsFlushBatch  = 500
+)
+
+// 搜索统计的指标，对应 SearchQueryStat 的字段
+const (
+	searchMetricSearches    = "searches"
+	searchMetricZeroResults = "zero_results"
+	searchMetricResults     = "results"
+	searchMetricLatency     = "latency_ms"
+	searchMetricFallbacks   = "fallbacks"
+	searchMetricClicks      = "clicks"
+)
+
+// SearchAnalyticsService 搜索统计服务
+type SearchAnalyticsService struct{}
+
+// SearchEvent 一次搜索
+type SearchEvent struct {
+	Query    string
+	Filters  string
+	Results  int64
+	Latency  time.Duration
+	Fallback bool // 是否降级到了 MySQL 搜索
+}
+
+// searchStatKey 统计的维度，编码为 JSON 作为 Redis 哈希字段名的一部分
+type searchStatKey struct {
+	Date    string `json:"d"`
+	Query   string `json:"q"`
+	Filters string `json:"f"`
+}
+
+// searchResult 一次搜索的搜索词和本页返回的文章，点击只有引用其中的文章才计入
+type searchResult struct {
+	Query    string   `json:"q"`
+	Articles []uint64 `json:"a"`
+}
+
+// searchResultKey 搜索ID对应的搜索结果的Redis键
+func searchResultKey(searchID string) string {
+	return "search:result:" + searchID
+}
+
+// truncateRunes 按字符截断，避免超出数据库字段长度
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+// incrSearchStats 在 Redis 中累加一组指标
+func incrSearchStats(key searchStatKey, metrics map[string]int64) error {
+	if global.Redis == nil {
+		return errors.New("Redis未初始化")
+	}
+	data, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	pipe := global.Redis.Pipeline()
+	for metric, value := range metrics {
+		if value != 0 {
+			pipe.HIncrBy(searchStatsPendingKey, metric+" "+string(data), value)
+		}
+	}
+	_, err = pipe.Exec()
+	return err
+}
+
+// RecordSearch 记录一次搜索，失败只记录日志，不影响搜索
+func (s *SearchAnalyticsService) RecordSearch(event SearchEvent) {
+	key := searchStatKey{
+		Date:    time.Now().Format("2006-01-02"),
+		Query:   truncateRunes(normalizeQuery(event.Query), searchStatsQueryLength),
+		Filters: truncateRunes(event.Filters, searchStatsFilterSize),
+	}
+	metrics := map[string]int64{
+		searchMetricSearches: 1,
+		searchMetricResults:  event.Results,
+		searchMetricLatency:  event.Latency.Milliseconds(),
+	}
+	if event.Results == 0 {
+		metrics[searchMetricZeroResults] = 1
+	}
+	if event.Fallback {
+		metrics[searchMetricFallbacks] = 1
+	}
+	if err := incrSearchStats(key, metrics); err != nil {
+		global.ZapLog.Warn("记录搜索统计失败", zap.String("query", key.Query), zap.Error(err))
+	}
+}
+
+// IssueSearchID 为一次有关键词的搜索生成不透明的搜索ID，记录搜索词和本页返回的文章；失败时返回空字符串，不影响搜索
+func (s *SearchAnalyticsService) IssueSearchID(query string, articles []es.ArticleES) string {
+	query = truncateRunes(normalizeQuery(query), searchStatsQueryLength)
+	if global.Redis == nil || query == "" || len(articles) == 0 {
+		return ""
+	}
+	result := searchResult{Query: query, Articles: make([]uint64, 0, len(articles))}
+	for _, article := range articles {
+		result.Articles = append(result.Articles, article.ID)
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return ""
+	}
+	searchID, err := randomToken(16)
+	if err != nil {
+		return ""
+	}
+	if err := global.Redis.Set(searchResultKey(searchID), data, searchResultTTL).Err(); err != nil {
+		global.ZapLog.Warn("记录搜索结果失败", zap.Error(err))
+		return ""
+	}
+	return searchID
+}
+
+// RecordClick 记录一次从搜索结果到文章的点击，visitor 用于去重（如客户端IP），返回是否计入
+// 只接受引用了搜索接口返回的搜索ID、且文章在该次搜索结果中的点击，搜索词取自搜索时的记录，不能由客户端伪造
+func (s *SearchAnalyticsService) RecordClick(searchID string, articleID uint, visitor string) (bool, error) {
+	if global.Redis == nil {
+		return false, errors.New("Redis未初始化")
+	}
+	data, err := global.Redis.Get(searchResultKey(searchID)).Bytes()
+	if err == redis.Nil {
+		return false, errors.New("搜索已过期或不存在")
+	}
+	if err != nil {
+		return false, err
+	}
+	var result searchResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return false, err
+	}
+	found := false
+	for _, id := range result.Articles {
+		if id == uint64(articleID) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return false, errors.New("文章不在该次搜索结果中")
+	}
+
+	sum := sha1.Sum([]byte(visitor + "\n" + searchID + "\n" + strconv.FormatUint(uint64(articleID), 10)))
+	fresh, err := global.Redis.SetNX("search:click:"+hex.EncodeToString(sum[:]), 1, searchClickDedupTTL).Result()
+	if err != nil {
+		return false, err
+	}
+	if !fresh {
+		return false, nil
+	}
+	key := searchStatKey{Date: time.Now().Format("2006-01-02"), Query: result.Query}
+	if err := incrSearchStats(key, map[string]int64{searchMetricClicks: 1}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Flush 把 Redis 中累计的搜索统计写入数据库，返回写入的行数
+// 写入成功后才删除 Redis 中的数据；数据库已提交但删除失败时，下次会重复累加这一批
+func (s *SearchAnalyticsService) Flush() (int, error) {
+	if global.Redis == nil {
+		return 0, nil
+	}
+	token, locked, err := acquireLock(searchStatsLockKey, searchStatsLockTTL)
+	if err != nil || !locked {
+		return 0, err
+	}
+	defer releaseLock(searchStatsLockKey, token)
+
+	// 上次写入失败的数据还在时先写入这一批，新数据下次再写
+	ok, err := claimKey(searchStatsPendingKey, searchStatsFlushingKey)
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	fields, err := global.Redis.HGetAll(searchStatsFlushingKey).Result()
+	if err != nil {
+		return 0, err
+	}
+	stats := parseSearchStats(fields)
+	if len(stats) > 0 {
+		err = global.DB.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "date"}, {Name: "query"}, {Name: "filters"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				searchMetricSearches:    gorm.Expr("searches + VALUES(searches)"),
+				searchMetricZeroResults: gorm.Expr("zero_results + VALUES(zero_results)"),
+				searchMetricResults:     gorm.Expr("results + VALUES(results)"),
+				searchMetricLatency:     gorm.Expr("latency_ms + VALUES(latency_ms)"),
+				searchMetricFallbacks:   gorm.Expr("fallbacks + VALUES(fallbacks)"),
+				searchMetricClicks:      gorm.Expr("clicks + VALUES(clicks)"),
+			}),
+		}).CreateInBatches(&stats, searchStatsFlushBatch).Error
+		if err != nil {
+			return 0, fmt.Errorf("写入搜索统计失败: %v", err)
+		}
+	}
+	if err := global.Redis.Del(searchStatsFlushingKey).Err(); err != nil {
+		global.ZapLog.Warn("删除已写入的搜索统计失败", zap.Error(err))
+	}
+	return len(stats), nil
+}
+
+// parseSearchStats 把 Redis 哈希（字段为 "指标 维度JSON"）汇总为数据库记录
+func parseSearchStats(fields map[string]string) []database.SearchQueryStat {
+	rows := make(map[searchStatKey]*database.SearchQueryStat)
+	for field, value := range fields {
+		i := strings.IndexByte(field, ' ')
+		if i < 0 {
+			continue
+		}
+		metric := field[:i]
+		var key searchStatKey
+		if err := json.Unmarshal([]byte(field[i+1:]), &key); err != nil {
+			global.ZapLog.Warn("忽略无法解析的搜索统计", zap.String("field", field), zap.Error(err))
+			continue
+		}
+		count, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		row, ok := rows[key]
+		if !ok {
+			date, err := time.ParseInLocation("2006-01-02", key.Date, time.Local)
+			if err != nil {
+				continue
+			}
+			row = &database.SearchQueryStat{Date: date, Query: key.Query, Filters: key.Filters}
+			rows[key] = row
+		}
+		switch metric {
+		case searchMetricSearches:
+			row.Searches += count
+		case searchMetricZeroResults:
+			row.ZeroResults += count
+		case searchMetricResults:
+			row.Results += count
+		case searchMetricLatency:
+			row.LatencyMs += count
+		case searchMetricFallbacks:
+			row.Fallbacks += count
+		case searchMetricClicks:
+			row.Clicks += count
+		}
+	}
+	stats := make([]database.SearchQueryStat, 0, len(rows))
+	for _, row := range rows {
+		stats = append(stats, *row)
+	}
+	return stats
+}
+
+// searchQueryRow 按搜索词汇总的查询结果
+type searchQueryRow struct {
+	Query       string
+	Searches    int64
+	ZeroResults int64
+	Results     int64
+	LatencyMs   int64
+	Fallbacks   int64
+	Clicks      int64
+}
+
+func (r searchQueryRow) report() response.SearchQueryReport {
+	report := response.SearchQueryReport{
+		Query:       r.Query,
+		Searches:    r.Searches,
+		ZeroResults: r.ZeroResults,
+		Clicks:      r.Clicks,
+	}
+	if r.Searches > 0 {
+		searches := float64(r.Searches)
+		report.ClickRate = float64(r.Clicks) / searches
+		report.AvgResults = float64(r.Results) / searches
+		report.AvgLatencyMs = float64(r.LatencyMs) / searches
+		report.FallbackRate = float64(r.Fallbacks) / searches
+	}
+	return report
+}
+
+// Report 最近 days 天（含今天）的搜索统计：总体情况、热门搜索词和没有结果的搜索词，各取前 size 个
+// 生成前先把 Redis 中尚未写入的统计写入数据库
+func (s *SearchAnalyticsService) Report(days, size int) (response.SearchAnalyticsReport, error) {
+	if _, err := s.Flush(); err != nil {
+		global.ZapLog.Warn("写入搜索统计失败，报表不包含最近的数据", zap.Error(err))
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), now.Day()-days+1, 0, 0, 0, 0, time.Local)
+	report := response.SearchAnalyticsReport{
+		From:              from.Format("2006-01-02"),
+		To:                now.Format("2006-01-02"),
+		TopQueries:        []response.SearchQueryReport{},
+		ZeroResultQueries: []response.SearchQueryReport{},
+	}
+
+	columns := "SUM(searches) AS searches, SUM(zero_results) AS zero_results, SUM(results) AS results, " +
+		"SUM(latency_ms) AS latency_ms, SUM(fallbacks) AS fallbacks, SUM(clicks) AS clicks"
+	// 只统计有搜索词的搜索，只按筛选条件浏览的请求不计入
+	base := func() *gorm.DB {
+		return global.DB.Model(&database.SearchQueryStat{}).Where("date >= ? AND query <> ''", from)
+	}
+
+	var summary searchQueryRow
+	if err := base().Select(columns).Scan(&summary).Error; err != nil {
+		return report, err
+	}
+	report.Summary = summary.report()
+
+	var top []searchQueryRow
+	if err := base().Select("query, " + columns).
+		Group("query").Order("searches DESC").Limit(size).Scan(&top).Error; err != nil {
+		return report, err
+	}
+	for _, row := range top {
+		report.TopQueries = append(report.TopQueries, row.report())
+	}
+
+	var zero []searchQueryRow
+	if err := base().Select("query, " + columns).
+		Group("query").Having("SUM(zero_results) > 0").Order("zero_results DESC").Limit(size).Scan(&zero).Error; err != nil {
+		return report, err
+	}
+	for _, row := range zero {
+		report.ZeroResultQueries = append(report.ZeroResultQueries, row.report())
+	}
+	return report, nil
+}
+
+// CleanupExpired 删除超过保留天数的搜索统计
+func (s *SearchAnalyticsService) CleanupExpired(retentionDays int) (int64, error) {
+	cutoff := time.Now().AddDate(0, 0, -retentionDays)
+	result := global.DB.Where("date < ?", cutoff.Format("2006-01-02")).Delete(&database.SearchQueryStat{})
+	return result.RowsAffected, result.Error
+}
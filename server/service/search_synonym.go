@@ -0,0 +1,243 @@
+package service
+
+import (
+	"errors"
+	"server/global"
+	"server/model/database"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 同义词扩展的参数
+const (
+	searchSynonymMaxExpansions = 8               // 一次搜索最多扩展出的查询数
+	searchSynonymCacheTTL      = 5 * time.Minute // 其他进程修改同义词后，最迟在此时间后生效
+)
+
+// SearchSynonymService 搜索同义词管理；同义词在搜索时扩展查询词，修改后无需重建索引
+type SearchSynonymService struct{}
+
+// synonymCache 同义词组缓存，修改时清空
+var synonymCache struct {
+	sync.RWMutex
+	groups   [][]string
+	loadedAt time.Time
+}
+
+// normalizeSynonymTerms 去除空白和重复的词，至少需要两个不同的词
+func normalizeSynonymTerms(terms []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var result []string
+	for _, term := range terms {
+		term = strings.TrimSpace(term)
+		key := strings.ToLower(term)
+		if term == "" || seen[key] {
+			continue
+		}
+		if strings.Contains(term, ",") {
+			return nil, errors.New("同义词不能包含逗号")
+		}
+		seen[key] = true
+		result = append(result, term)
+	}
+	if len(result) < 2 {
+		return nil, errors.New("同义词组至少需要两个不同的词")
+	}
+	return result, nil
+}
+
+// List 全部同义词组
+func (s *SearchSynonymService) List() ([]database.SearchSynonym, error) {
+	var synonyms []database.SearchSynonym
+	err := global.DB.Order("id").Find(&synonyms).Error
+	return synonyms, err
+}
+
+// Create 新建同义词组
+func (s *SearchSynonymService) Create(terms []string) (database.SearchSynonym, error) {
+	terms, err := normalizeSynonymTerms(terms)
+	if err != nil {
+		return database.SearchSynonym{}, err
+	}
+	synonym := database.SearchSynonym{Terms: strings.Join(terms, ",")}
+	if err := global.DB.Create(&synonym).Error; err != nil {
+		return synonym, err
+	}
+	s.invalidate()
+	return synonym, nil
+}
+
+// Update 修改同义词组，返回修改前后的记录
+func (s *SearchSynonymService) Update(id uint, terms []string) (database.SearchSynonym, database.SearchSynonym, error) {
+	var synonym database.SearchSynonym
+	if err := global.DB.First(&synonym, id).Error; err != nil {
+		return synonym, synonym, err
+	}
+	terms, err := normalizeSynonymTerms(terms)
+	if err != nil {
+		return synonym, synonym, err
+	}
+	before := synonym
+	synonym.Terms = strings.Join(terms, ",")
+	if err := global.DB.Model(&synonym).Update("terms", synonym.Terms).Error; err != nil {
+		return before, synonym, err
+	}
+	s.invalidate()
+	return before, synonym, nil
+}
+
+// Delete 删除同义词组，返回删除的记录
+func (s *SearchSynonymService) Delete(id uint) (database.SearchSynonym, error) {
+	var synonym database.SearchSynonym
+	if err := global.DB.First(&synonym, id).Error; err != nil {
+		return synonym, err
+	}
+	if err := global.DB.Delete(&synonym).Error; err != nil {
+		return synonym, err
+	}
+	s.invalidate()
+	return synonym, nil
+}
+
+// invalidate 清空缓存，下次搜索时重新加载
+func (s *SearchSynonymService) invalidate() {
+	synonymCache.Lock()
+	synonymCache.groups = nil
+	synonymCache.loadedAt = time.Time{}
+	synonymCache.Unlock()
+}
+
+// groups 返回缓存的同义词组（小写），加载失败时返回空
+func (s *SearchSynonymService) groups() [][]string {
+	synonymCache.RLock()
+	groups, loadedAt := synonymCache.groups, synonymCache.loadedAt
+	synonymCache.RUnlock()
+	if !loadedAt.IsZero() && time.Since(loadedAt) < searchSynonymCacheTTL {
+		return groups
+	}
+
+	synonyms, err := s.List()
+	if err != nil {
+		return groups
+	}
+	groups = make([][]string, 0, len(synonyms))
+	for _, synonym := range synonyms {
+		groups = append(groups, strings.Split(strings.ToLower(synonym.Terms), ","))
+	}
+	synonymCache.Lock()
+	synonymCache.groups = groups
+	synonymCache.loadedAt = time.Now()
+	synonymCache.Unlock()
+	return groups
+}
+
+// Expand 把查询词中出现的同义词替换为同组的其他词，返回扩展出的查询（不含原查询）
+// 中文查询没有空格分隔，包含汉字的词按子串匹配；其他词按整词匹配，避免 go 匹配到 google
+func (s *SearchSynonymService) Expand(keyword string) []string {
+	lower := strings.ToLower(strings.TrimSpace(keyword))
+	if lower == "" {
+		return nil
+	}
+	seen := map[string]bool{lower: true}
+	var expansions []string
+	for _, group := range s.groups() {
+		for _, term := range group {
+			if !containsSynonym(lower, term) {
+				continue
+			}
+			for _, other := range group {
+				if other == term {
+					continue
+				}
+				expanded := replaceSynonym(lower, term, other)
+				if seen[expanded] {
+					continue
+				}
+				seen[expanded] = true
+				expansions = append(expansions, expanded)
+				if len(expansions) >= searchSynonymMaxExpansions {
+					return expansions
+				}
+			}
+		}
+	}
+	return expansions
+}
+
+// hasHan 词中是否包含汉字
+func hasHan(term string) bool {
+	for _, r := range term {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// isWordRune 是否是构成单词的字符；汉字不算，"go语言" 中的 go 仍是一个完整的词
+func isWordRune(r rune) bool {
+	if unicode.Is(unicode.Han, r) {
+		return false
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// synonymMatches 返回同义词在文本中出现的位置，不含汉字的词要求前后不是单词字符
+func synonymMatches(text, term string) []int {
+	if term == "" {
+		return nil
+	}
+	han := hasHan(term)
+	var matches []int
+	for offset := 0; offset < len(text); {
+		i := strings.Index(text[offset:], term)
+		if i < 0 {
+			break
+		}
+		start, end := offset+i, offset+i+len(term)
+		if han || wordBoundary(text, start, end) {
+			matches = append(matches, start)
+			offset = end
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(text[start:])
+		offset = start + size
+	}
+	return matches
+}
+
+// wordBoundary text[start:end] 前后是否都不是单词字符
+func wordBoundary(text string, start, end int) bool {
+	if before, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && isWordRune(before) {
+		return false
+	}
+	if after, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) && isWordRune(after) {
+		return false
+	}
+	return true
+}
+
+// containsSynonym 文本中是否出现同义词
+func containsSynonym(text, term string) bool {
+	return len(synonymMatches(text, term)) > 0
+}
+
+// replaceSynonym 把文本中出现的同义词全部替换为 other
+func replaceSynonym(text, term, other string) string {
+	matches := synonymMatches(text, term)
+	if len(matches) == 0 {
+		return text
+	}
+	var sb strings.Builder
+	last := 0
+	for _, start := range matches {
+		sb.WriteString(text[last:start])
+		sb.WriteString(other)
+		last = start + len(term)
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExpandSynonyms(t *testing.T) {
+	synonymCache.Lock()
+	synonymCache.groups = [][]string{{"go", "golang"}, {"数据库", "db"}}
+	synonymCache.loadedAt = time.Now()
+	synonymCache.Unlock()
+	t.Cleanup((&SearchSynonymService{}).invalidate)
+
+	cases := []struct {
+		keyword string
+		want    []string
+	}{
+		{"google", nil},
+		{"go tutorial", []string{"golang tutorial"}},
+		{"Learn Go", []string{"learn golang"}},
+		{"go语言入门", []string{"golang语言入门"}},
+		{"golang", []string{"go"}},
+		{"mysql数据库优化", []string{"mysqldb优化"}},
+		{"go-kit 和 go", []string{"golang-kit 和 golang"}},
+		{"mongodb", nil},
+	}
+	for _, c := range cases {
+		if got := (&SearchSynonymService{}).Expand(c.keyword); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q 扩展为 %q，期望 %q", c.keyword, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,414 @@
+package service
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"server/global"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// 整站备份包的格式标识和版本
+const (
+	BundleFormatName    = "go_blog_bundle"
+	BundleFormatVersion = 1
+)
+
+// 备份包中的文件
+const (
+	bundleManifestFile = "manifest.json"
+	bundleDatabaseFile = "database.json.gz"
+	bundleESFile       = "elasticsearch/articles.jsonl"
+	bundleUploadsDir   = "uploads"
+)
+
+// SiteBackupService 整站备份服务：数据库、搜索索引和上传的媒体文件打包为一个 tar.gz 文件
+type SiteBackupService struct{}
+
+// BundleFile 备份包中单个文件的校验信息
+type BundleFile struct {
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// BundleManifest 备份包清单，位于备份包末尾
+type BundleManifest struct {
+	Format        string                `json:"format"`
+	Version       int                   `json:"version"`
+	CreatedAt     time.Time             `json:"created_at"`
+	SchemaVersion string                `json:"schema_version"`
+	Tables        map[string]int64      `json:"tables"`       // 各表行数
+	ESIncluded    bool                  `json:"es_included"`  // 是否包含搜索索引快照，导出失败时不包含
+	ESDocuments   int64                 `json:"es_documents"` // 索引文档数
+	MediaFiles    int                   `json:"media_files"`  // 媒体文件数
+	Files         map[string]BundleFile `json:"files"`
+}
+
+// RestoreBundleOptions 恢复选项
+// 索引快照在数据库事务之后导出，两者之间的文章修改会让快照和数据库不一致，因此默认从恢复后的数据库重建索引
+type RestoreBundleOptions struct {
+	UseESSnapshot bool // 恢复备份包中的索引快照（大站点比重建快），恢复后再按数据库修复差异
+}
+
+// bundleWriter 写入备份包并记录校验信息
+type bundleWriter struct {
+	tw       *tar.Writer
+	manifest *BundleManifest
+}
+
+// addReader 写入一个文件，size 必须与内容长度一致
+func (w *bundleWriter) addReader(name string, r io.Reader, size int64, modTime time.Time) error {
+	if err := w.tw.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     size,
+		ModTime:  modTime,
+		Typeflag: tar.TypeReg,
+	}); err != nil {
+		return err
+	}
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(w.tw, hash), r)
+	if err != nil {
+		return err
+	}
+	if written != size {
+		return fmt.Errorf("文件 %s 在写入过程中发生变化", name)
+	}
+	w.manifest.Files[name] = BundleFile{Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))}
+	return nil
+}
+
+// addFile 写入磁盘上的文件
+func (w *bundleWriter) addFile(name, localPath string) error {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	return w.addReader(name, file, info.Size(), info.ModTime())
+}
+
+// CreateBundle 创建整站备份包：先备份数据库（一致性快照），再导出搜索索引和媒体文件，最后写入清单
+func (s *SiteBackupService) CreateBundle(bundlePath string) (BundleManifest, error) {
+	manifest := BundleManifest{
+		Format:    BundleFormatName,
+		Version:   BundleFormatVersion,
+		CreatedAt: time.Now(),
+		Files:     make(map[string]BundleFile),
+	}
+
+	tmp := bundlePath + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return manifest, err
+	}
+	err = s.writeBundle(file, &manifest)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return manifest, err
+	}
+	return manifest, os.Rename(tmp, bundlePath)
+}
+
+// writeBundle 依次写入数据库、索引、媒体文件和清单
+func (s *SiteBackupService) writeBundle(out io.Writer, manifest *BundleManifest) error {
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	w := &bundleWriter{tw: tw, manifest: manifest}
+
+	// 数据库和索引需要先知道大小才能写入 tar，先写到临时文件
+	dbFile, err := os.CreateTemp("", "blog_db_*.json.gz")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(dbFile.Name())
+	defer dbFile.Close()
+
+	result, err := (&BackupService{}).Backup(dbFile, nil)
+	if err != nil {
+		return fmt.Errorf("备份数据库失败: %v", err)
+	}
+	manifest.SchemaVersion = result.SchemaVersion
+	manifest.Tables = result.Counts
+	if err := w.addFile(bundleDatabaseFile, dbFile.Name()); err != nil {
+		return err
+	}
+
+	esFile, err := os.CreateTemp("", "blog_es_*.jsonl")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(esFile.Name())
+	defer esFile.Close()
+
+	// 索引可以从数据库重建，导出失败时不中断备份
+	manifest.ESDocuments, err = NewArticleESService().ExportDocuments(esFile, ESExportOptions{})
+	if err != nil {
+		global.ZapLog.Warn("导出搜索索引失败，备份包将不包含索引快照", zap.Error(err))
+		manifest.ESDocuments = 0
+	} else {
+		manifest.ESIncluded = true
+		if err := w.addFile(bundleESFile, esFile.Name()); err != nil {
+			return err
+		}
+	}
+
+	err = filepath.WalkDir(bundleUploadsDir, func(localPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && localPath == bundleUploadsDir {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if err := w.addFile(filepath.ToSlash(localPath), localPath); err != nil {
+			return err
+		}
+		manifest.MediaFiles++
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("备份媒体文件失败: %v", err)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: bundleManifestFile, Mode: 0644, Size: int64(len(data)), ModTime: manifest.CreatedAt, Typeflag: tar.TypeReg}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// openBundle 打开备份包，对每个文件调用 fn
+func openBundle(bundlePath string, fn func(header *tar.Header, r io.Reader) error) error {
+	file, err := os.Open(bundlePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("不是有效的备份包: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("读取备份包失败: %v", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := fn(header, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// VerifyBundle 校验备份包：清单格式、每个文件的大小和 SHA-256，以及是否有清单之外的文件
+func (s *SiteBackupService) VerifyBundle(bundlePath string) (BundleManifest, error) {
+	var manifest BundleManifest
+	var hasManifest bool
+	actual := make(map[string]BundleFile)
+
+	err := openBundle(bundlePath, func(header *tar.Header, r io.Reader) error {
+		if header.Name == bundleManifestFile {
+			hasManifest = true
+			return json.NewDecoder(r).Decode(&manifest)
+		}
+		hash := sha256.New()
+		size, err := io.Copy(hash, r)
+		if err != nil {
+			return err
+		}
+		actual[header.Name] = BundleFile{Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))}
+		return nil
+	})
+	if err != nil {
+		return manifest, err
+	}
+
+	if !hasManifest || manifest.Format != BundleFormatName {
+		return manifest, errors.New("不是有效的备份包：缺少清单")
+	}
+	if manifest.Version > BundleFormatVersion {
+		return manifest, fmt.Errorf("备份包格式版本 %d 高于当前支持的版本 %d", manifest.Version, BundleFormatVersion)
+	}
+	if _, ok := manifest.Files[bundleDatabaseFile]; !ok {
+		return manifest, errors.New("备份包中缺少数据库备份")
+	}
+
+	var problems []string
+	for name, want := range manifest.Files {
+		got, ok := actual[name]
+		switch {
+		case !ok:
+			problems = append(problems, "缺少文件 "+name)
+		case got != want:
+			problems = append(problems, "校验失败 "+name)
+		}
+	}
+	for name := range actual {
+		if _, ok := manifest.Files[name]; !ok {
+			problems = append(problems, "清单之外的文件 "+name)
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return manifest, errors.New("备份包已损坏: " + strings.Join(problems, "；"))
+	}
+	return manifest, nil
+}
+
+// RestoreBundle 校验并恢复整站备份包：数据库在一个事务中恢复，成功后再写入媒体文件和搜索索引
+// 本地存在但备份包中没有的媒体文件会保留；Redis 中尚未写入数据库的计数会被清空
+// 恢复期间必须停止博客服务，否则服务进程的定时任务会把恢复前的计数写入恢复后的数据库
+func (s *SiteBackupService) RestoreBundle(bundlePath string, opts RestoreBundleOptions) (BundleManifest, error) {
+	manifest, err := s.VerifyBundle(bundlePath)
+	if err != nil {
+		return manifest, err
+	}
+	if opts.UseESSnapshot && !manifest.ESIncluded {
+		global.ZapLog.Warn("备份包不包含索引快照，将从数据库重建搜索索引")
+		opts.UseESSnapshot = false
+	}
+
+	esFile, err := os.CreateTemp("", "blog_es_*.jsonl")
+	if err != nil {
+		return manifest, err
+	}
+	defer os.Remove(esFile.Name())
+	defer esFile.Close()
+
+	// 备份包中数据库位于最前面，数据库恢复失败时不会改动媒体文件和索引
+	databaseRestored := false
+	err = openBundle(bundlePath, func(header *tar.Header, r io.Reader) error {
+		switch {
+		case header.Name == bundleDatabaseFile:
+			if _, err := (&BackupService{}).Restore(r); err != nil {
+				return fmt.Errorf("恢复数据库失败: %v", err)
+			}
+			databaseRestored = true
+		case !databaseRestored:
+			return errors.New("备份包格式错误：数据库备份必须位于最前面")
+		case header.Name == bundleESFile:
+			if opts.UseESSnapshot {
+				_, err := io.Copy(esFile, r)
+				return err
+			}
+		case strings.HasPrefix(header.Name, bundleUploadsDir+"/"):
+			return restoreUploadFile(header.Name, r)
+		}
+		return nil
+	})
+	if err != nil {
+		return manifest, err
+	}
+	if err := clearCounterState(); err != nil {
+		return manifest, fmt.Errorf("清理计数缓存失败: %v", err)
+	}
+
+	esService := NewArticleESService()
+	if err := esService.CreateIndex(); err != nil {
+		return manifest, fmt.Errorf("重建索引失败: %v", err)
+	}
+	if !opts.UseESSnapshot {
+		if err := (&ArticleService{}).SyncAllPublishedArticlesToES(); err != nil {
+			return manifest, fmt.Errorf("从数据库重建索引失败: %v", err)
+		}
+		return manifest, nil
+	}
+	if _, err := esFile.Seek(0, io.SeekStart); err != nil {
+		return manifest, err
+	}
+	if _, err := esService.ImportDocuments(esFile, ESImportOptions{}); err != nil {
+		return manifest, fmt.Errorf("恢复索引快照失败: %v", err)
+	}
+
+	// 快照和数据库不是同一时刻的数据，按恢复后的数据库修复差异
+	report, err := esService.CheckConsistency()
+	if err != nil {
+		return manifest, fmt.Errorf("检查索引快照一致性失败: %v", err)
+	}
+	if !report.Consistent() {
+		result, err := esService.RepairConsistency(report)
+		if err != nil {
+			return manifest, fmt.Errorf("修复索引快照失败: %v", err)
+		}
+		global.ZapLog.Info("已按数据库修复索引快照", zap.Int("indexed", result.Indexed), zap.Int("deleted", result.Deleted))
+	}
+	return manifest, nil
+}
+
+// clearCounterState 清空 Redis 中尚未写入数据库的阅读量、访问统计和搜索统计
+// 这些增量属于恢复前的数据，保留的话下次写入时会累加到恢复后的数据上
+func clearCounterState() error {
+	return global.Redis.Del(
+		articleViewsPendingKey, articleViewsFlushingKey,
+		articleStatsDirtyKey, articleStatsSyncingKey,
+		pageViewPendingKey, pageViewFlushingKey,
+		searchStatsPendingKey, searchStatsFlushingKey,
+	).Err()
+}
+
+// restoreUploadFile 把备份包中的媒体文件写回 uploads 目录，拒绝跳出目录的路径
+func restoreUploadFile(name string, r io.Reader) error {
+	cleaned := path.Clean(name)
+	if !strings.HasPrefix(cleaned, bundleUploadsDir+"/") || strings.Contains(cleaned, "..") {
+		return fmt.Errorf("备份包中的路径不合法: %s", name)
+	}
+	localPath := filepath.FromSlash(cleaned)
+	if err := os.MkdirAll(filepath.Dir(localPath), os.ModePerm); err != nil {
+		return err
+	}
+
+	tmp := localPath + ".restore"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, r)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, localPath)
+}
@@ -0,0 +1,311 @@
+package service
+
+import (
+	"errors"
+	"server/global"
+	"server/model/appType"
+	"server/model/database"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ErrTrashForbidden 没有权限操作回收站中的内容
+var ErrTrashForbidden = errors.New("没有权限操作该回收站内容")
+
+// errTrashNotFound 回收站中找不到指定内容
+var errTrashNotFound = errors.New("回收站中不存在该内容")
+
+// trashOwnerColumns 各资源的所有者字段，非管理员只能操作自己的内容；页面没有所有者，只有管理员可以操作
+var trashOwnerColumns = map[appType.TrashResource]string{
+	appType.TrashArticles: "author_id",
+	appType.TrashComments: "user_id",
+	appType.TrashMedia:    "user_id",
+}
+
+// TrashService 回收站服务：查看、恢复和彻底删除已软删除的内容
+type TrashService struct{}
+
+// trashModel 资源对应的模型
+func trashModel(resource appType.TrashResource) interface{} {
+	switch resource {
+	case appType.TrashArticles:
+		return &database.Article{}
+	case appType.TrashPages:
+		return &database.Page{}
+	case appType.TrashComments:
+		return &database.Comment{}
+	default:
+		return &database.Media{}
+	}
+}
+
+// scope 构建回收站查询，ownerID 为0表示管理员，不限制所有者
+func (s *TrashService) scope(resource appType.TrashResource, ownerID uint) (*gorm.DB, error) {
+	query := global.DB.Unscoped().Model(trashModel(resource)).Where("deleted_at IS NOT NULL")
+	if ownerID != 0 {
+		column, ok := trashOwnerColumns[resource]
+		if !ok {
+			return nil, ErrTrashForbidden
+		}
+		query = query.Where(column+" = ?", ownerID)
+	}
+	return query, nil
+}
+
+// List 分页查询回收站中的内容，按删除时间倒序；文章和页面不返回正文
+func (s *TrashService) List(resource appType.TrashResource, ownerID uint, page, size int) (list interface{}, total int64, err error) {
+	query, err := s.scope(resource, ownerID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err = query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	query = query.Order("deleted_at DESC").Offset((page - 1) * size).Limit(size)
+
+	switch resource {
+	case appType.TrashArticles:
+		var articles []database.Article
+		err = query.Omit("content").Find(&articles).Error
+		list = articles
+	case appType.TrashPages:
+		var pages []database.Page
+		err = query.Omit("content").Find(&pages).Error
+		list = pages
+	case appType.TrashComments:
+		var comments []database.Comment
+		err = query.Find(&comments).Error
+		list = comments
+	default:
+		var media []database.Media
+		err = query.Find(&media).Error
+		list = media
+	}
+	return list, total, err
+}
+
+// exists 检查内容是否在回收站中且当前用户有权操作
+// 非管理员只能恢复或彻底删除自己删除的内容，被管理员（或文章作者）删除的内容只能由管理员处理
+func (s *TrashService) exists(resource appType.TrashResource, id, ownerID uint) error {
+	query, err := s.scope(resource, ownerID)
+	if err != nil {
+		return err
+	}
+	var deletedBy []uint
+	if err := query.Where("id = ?", id).Pluck("deleted_by", &deletedBy).Error; err != nil {
+		return err
+	}
+	if len(deletedBy) == 0 {
+		return errTrashNotFound
+	}
+	// 早于记录操作人的数据 deleted_by 为0，保持原来的权限
+	if ownerID != 0 && deletedBy[0] != 0 && deletedBy[0] != ownerID {
+		return ErrTrashForbidden
+	}
+	return nil
+}
+
+// Restore 从回收站恢复内容
+func (s *TrashService) Restore(resource appType.TrashResource, id, ownerID uint) error {
+	if err := s.exists(resource, id, ownerID); err != nil {
+		return err
+	}
+
+	switch resource {
+	case appType.TrashArticles:
+		return s.restoreArticle(id)
+	case appType.TrashComments:
+		return s.restoreComment(id)
+	case appType.TrashMedia:
+		return global.DB.Unscoped().Model(&database.Media{}).Where("id = ?", id).Updates(map[string]interface{}{"deleted_at": nil, "deleted_by": 0}).Error
+	default:
+		return global.DB.Unscoped().Model(trashModel(resource)).Where("id = ?", id).Update("deleted_at", nil).Error
+	}
+}
+
+// restoreArticle 恢复文章及随文章一起删除的标签关联，重新统计标签数量并同步到ES
+func (s *TrashService) restoreArticle(id uint) error {
+	var article database.Article
+	if err := global.DB.Unscoped().Where("id = ?", id).First(&article).Error; err != nil {
+		return err
+	}
+
+	articleService := ArticleService{}
+	err := global.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&article).Updates(map[string]interface{}{"deleted_at": nil, "deleted_by": 0}).Error; err != nil {
+			return err
+		}
+
+		// 恢复标签关联，以及删除文章时被当作孤儿清理掉的标签
+		var tagIDs []uint
+		if err := tx.Unscoped().Model(&database.ArticleTag{}).Where("article_id = ? AND deleted_at IS NOT NULL", id).Pluck("tag_id", &tagIDs).Error; err != nil {
+			return err
+		}
+		if len(tagIDs) > 0 {
+			if err := tx.Unscoped().Model(&database.ArticleTag{}).Where("article_id = ?", id).Update("deleted_at", nil).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Model(&database.Tag{}).Where("id IN ? AND deleted_at IS NOT NULL", tagIDs).Update("deleted_at", nil).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := articleService.recountArticleTags(tx, id, nil); err != nil {
+			return err
+		}
+		if err := articleService.updateCategoryArticleCount(tx, article.CategoryID); err != nil {
+			return err
+		}
+		// 删除文章时已从ES移除，恢复后重新索引
+		return enqueueArticleSync(tx, id, appType.ESOutboxIndex)
+	})
+	if err != nil {
+		return err
+	}
+	go (&ESOutboxService{}).DeliverArticle(id)
+	return nil
+}
+
+// restoreComment 恢复评论以及和它一起被删除的子评论
+func (s *TrashService) restoreComment(id uint) error {
+	var comment database.Comment
+	if err := global.DB.Unscoped().Where("id = ?", id).First(&comment).Error; err != nil {
+		return err
+	}
+
+	// 上级评论或文章仍在回收站时不能单独恢复
+	if comment.ParentID != nil {
+		var count int64
+		global.DB.Model(&database.Comment{}).Where("id = ?", *comment.ParentID).Count(&count)
+		if count == 0 {
+			return errors.New("上级评论已删除，请先恢复上级评论")
+		}
+	}
+	var articleCount int64
+	global.DB.Model(&database.Article{}).Where("id = ?", comment.ArticleID).Count(&articleCount)
+	if articleCount == 0 {
+		return errors.New("评论所属的文章已删除，请先恢复文章")
+	}
+
+	ids, err := s.commentTree(id, &comment.DeletedAt.Time)
+	if err != nil {
+		return err
+	}
+	if err := global.DB.Unscoped().Model(&database.Comment{}).Where("id IN ?", ids).Updates(map[string]interface{}{"deleted_at": nil, "deleted_by": 0}).Error; err != nil {
+		return err
+	}
+
+	(&CommentService{}).updateArticleCommentCount(comment.ArticleID)
+	return nil
+}
+
+// commentTree 获取评论及其所有子评论的ID（包括已删除的），deletedAt 不为空时只包含同一时间删除的子评论
+func (s *TrashService) commentTree(id uint, deletedAt *time.Time) ([]uint, error) {
+	ids := []uint{id}
+	frontier := []uint{id}
+	for len(frontier) > 0 {
+		query := global.DB.Unscoped().Model(&database.Comment{}).Where("parent_id IN ?", frontier)
+		if deletedAt != nil {
+			query = query.Where("deleted_at = ?", *deletedAt)
+		}
+		var children []uint
+		if err := query.Pluck("id", &children).Error; err != nil {
+			return nil, err
+		}
+		ids = append(ids, children...)
+		frontier = children
+	}
+	return ids, nil
+}
+
+// Purge 从回收站彻底删除内容
+func (s *TrashService) Purge(resource appType.TrashResource, id, ownerID uint) error {
+	if err := s.exists(resource, id, ownerID); err != nil {
+		return err
+	}
+	return s.purge(resource, id)
+}
+
+// purge 彻底删除内容及其附属数据
+func (s *TrashService) purge(resource appType.TrashResource, id uint) error {
+	switch resource {
+	case appType.TrashArticles:
+		return s.purgeArticle(id)
+	case appType.TrashComments:
+		ids, err := s.commentTree(id, nil)
+		if err != nil {
+			return err
+		}
+		return global.DB.Unscoped().Where("id IN ?", ids).Delete(&database.Comment{}).Error
+	case appType.TrashMedia:
+		var media database.Media
+		if err := global.DB.Unscoped().Where("id = ?", id).First(&media).Error; err != nil {
+			return err
+		}
+		if err := global.DB.Unscoped().Delete(&media).Error; err != nil {
+			return err
+		}
+		removeMediaFile(media)
+		return nil
+	default:
+		return global.DB.Unscoped().Delete(trashModel(resource), id).Error
+	}
+}
+
+// purgeArticle 彻底删除文章及其标签关联、评论、点赞和收藏
+func (s *TrashService) purgeArticle(id uint) error {
+	return global.DB.Transaction(func(tx *gorm.DB) error {
+		var tagIDs []uint
+		if err := tx.Unscoped().Model(&database.ArticleTag{}).Where("article_id = ?", id).Pluck("tag_id", &tagIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("article_id = ?", id).Delete(&database.ArticleTag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("article_id = ?", id).Delete(&database.Comment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("article_id = ?", id).Delete(&database.Like{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("article_id = ?", id).Delete(&database.Favorite{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(&database.Article{}, id).Error; err != nil {
+			return err
+		}
+		return (&ArticleService{}).recountArticleTags(tx, id, tagIDs)
+	})
+}
+
+// PurgeExpired 彻底删除在回收站中超过保留天数的内容，返回各类资源的删除数量
+func (s *TrashService) PurgeExpired(retentionDays int) (map[appType.TrashResource]int64, error) {
+	purged := make(map[appType.TrashResource]int64)
+	if retentionDays <= 0 {
+		return purged, nil
+	}
+	cutoff := time.Now().AddDate(0, 0, -retentionDays)
+
+	for _, resource := range appType.AllTrashResources {
+		var ids []uint
+		if err := global.DB.Unscoped().Model(trashModel(resource)).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Order("id").Pluck("id", &ids).Error; err != nil {
+			return purged, err
+		}
+		for _, id := range ids {
+			if err := s.purge(resource, id); err != nil {
+				// 评论可能已随上级评论一起删除
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					continue
+				}
+				global.ZapLog.Error("清理回收站失败", zap.String("resource", string(resource)), zap.Uint("id", id), zap.Error(err))
+				continue
+			}
+			purged[resource]++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"errors"
+	"server/global"
+	"server/model/appType"
+	"server/model/database"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestTrashOwnerCannotRestoreAdminDeleted(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+	if err := db.AutoMigrate(&database.Media{}); err != nil {
+		t.Fatalf("建表失败: %v", err)
+	}
+	global.DB = db
+
+	const ownerID, adminID = 1, 2
+	own := database.Media{Filename: "own.png", StoragePath: "uploads/own.png", UserID: ownerID}
+	moderated := database.Media{Filename: "bad.png", StoragePath: "uploads/bad.png", UserID: ownerID}
+	if err := db.Create(&own).Error; err != nil {
+		t.Fatalf("写入测试数据失败: %v", err)
+	}
+	if err := db.Create(&moderated).Error; err != nil {
+		t.Fatalf("写入测试数据失败: %v", err)
+	}
+
+	// 自己删除的图片，自己可以恢复
+	if err := DeleteImage(own.ID, ownerID); err != nil {
+		t.Fatalf("删除图片失败: %v", err)
+	}
+	trashService := TrashService{}
+	if err := trashService.Restore(appType.TrashMedia, own.ID, ownerID); err != nil {
+		t.Errorf("恢复自己删除的图片失败: %v", err)
+	}
+
+	// 管理员删除的图片，所有者不能恢复或彻底删除，管理员可以
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&moderated).Update("deleted_by", adminID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&moderated).Error
+	}); err != nil {
+		t.Fatalf("删除图片失败: %v", err)
+	}
+	if err := trashService.Restore(appType.TrashMedia, moderated.ID, ownerID); !errors.Is(err, ErrTrashForbidden) {
+		t.Errorf("所有者恢复管理员删除的图片应被拒绝，实际为 %v", err)
+	}
+	if err := trashService.Purge(appType.TrashMedia, moderated.ID, ownerID); !errors.Is(err, ErrTrashForbidden) {
+		t.Errorf("所有者彻底删除管理员删除的图片应被拒绝，实际为 %v", err)
+	}
+	if err := trashService.Restore(appType.TrashMedia, moderated.ID, 0); err != nil {
+		t.Errorf("管理员恢复图片失败: %v", err)
+	}
+	var restored database.Media
+	if err := db.First(&restored, moderated.ID).Error; err != nil || restored.DeletedBy != 0 {
+		t.Errorf("恢复后应清除删除操作人，实际为 %d（%v）", restored.DeletedBy, err)
+	}
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"server/global"
+	"server/model/database"
+	"server/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 两步验证相关的Redis键和限制
+const (
+	twoFactorSetupTTL          = 10 * time.Minute // 待确认密钥的保留时间
+	twoFactorMaxChallengeTries = 5                // 单个挑战令牌允许的错误次数
+)
+
+// TwoFactorService 两步验证（TOTP）服务
+type TwoFactorService struct{}
+
+// TwoFactorStatus 两步验证状态
+type TwoFactorStatus struct {
+	Enabled           bool       `json:"enabled"`
+	EnabledAt         *time.Time `json:"enabled_at"`
+	Required          bool       `json:"required"`            // 当前角色是否强制要求开启
+	RecoveryCodesLeft int64      `json:"recovery_codes_left"` // 剩余可用恢复码数量
+}
+
+// twoFactorSetupKey 待确认TOTP密钥的Redis键
+func twoFactorSetupKey(userID uint) string {
+	return fmt.Sprintf("2fa_setup:%d", userID)
+}
+
+// twoFactorChallengeKey 挑战令牌状态的Redis键
+func twoFactorChallengeKey(tokenID string) string {
+	return "2fa_challenge:" + tokenID
+}
+
+// twoFactorUsedKey 已使用的TOTP时间步，防止同一验证码被重放
+func twoFactorUsedKey(userID uint, counter uint64) string {
+	return fmt.Sprintf("2fa_used:%d:%d", userID, counter)
+}
+
+// IssueChallenge 为通过密码校验的用户签发挑战令牌
+func (s *TwoFactorService) IssueChallenge(user database.User, purpose string) (string, time.Duration, error) {
+	ttl := global.Config.Account.TwoFactor.ChallengeTTL()
+	token, tokenID, err := utils.GenerateChallengeToken(user.ID, user.Username, purpose, ttl)
+	if err != nil {
+		return "", 0, err
+	}
+	if err := global.Redis.Set(twoFactorChallengeKey(tokenID), 0, ttl).Err(); err != nil {
+		return "", 0, err
+	}
+	return token, ttl, nil
+}
+
+// ChallengeActive 挑战令牌是否仍然有效（未被使用且未因错误次数过多而作废）
+func (s *TwoFactorService) ChallengeActive(tokenID string) bool {
+	exists, err := global.Redis.Exists(twoFactorChallengeKey(tokenID)).Result()
+	return err == nil && exists > 0
+}
+
+// RevokeChallenge 作废挑战令牌
+func (s *TwoFactorService) RevokeChallenge(tokenID string) {
+	global.Redis.Del(twoFactorChallengeKey(tokenID))
+}
+
+// CompleteLogin 登录第二步：校验挑战令牌和两步验证码，成功后返回用户
+func (s *TwoFactorService) CompleteLogin(challengeToken, code string) (user database.User, err error) {
+	claims, err := utils.ParseToken(challengeToken, false)
+	if err != nil || claims.Purpose != utils.TokenPurposeTwoFactorLogin {
+		return user, errors.New("登录会话无效或已过期，请重新登录")
+	}
+	if !s.ChallengeActive(claims.ID) {
+		return user, errors.New("登录会话无效或已过期，请重新登录")
+	}
+
+	if err = global.DB.Where("id = ?", claims.UserID).First(&user).Error; err != nil {
+		return user, errors.New("用户不存在")
+	}
+	if user.Status == 0 {
+		return user, errors.New("用户已被禁用，请联系管理员")
+	}
+
+	if !s.Verify(user, code) {
+		// 同一挑战令牌错误次数过多时作废，必须重新输入密码
+		key := twoFactorChallengeKey(claims.ID)
+		if tries, _ := global.Redis.Incr(key).Result(); tries >= twoFactorMaxChallengeTries {
+			global.Redis.Del(key)
+		}
+		return user, errors.New("两步验证码错误")
+	}
+
+	s.RevokeChallenge(claims.ID)
+	return user, nil
+}
+
+// Verify 校验TOTP验证码或一次性恢复码
+func (s *TwoFactorService) Verify(user database.User, code string) bool {
+	if !user.IsTwoFactorEnabled() || code == "" {
+		return false
+	}
+	if s.verifyTOTP(user.ID, user.TwoFactorSecret, code) {
+		return true
+	}
+	return s.useRecoveryCode(user.ID, code)
+}
+
+// verifyTOTP 校验TOTP验证码，同一时间步的验证码只能使用一次
+func (s *TwoFactorService) verifyTOTP(userID uint, secret, code string) bool {
+	counter, ok := utils.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return false
+	}
+	fresh, err := global.Redis.SetNX(twoFactorUsedKey(userID, counter), 1, 2*time.Minute).Result()
+	if err != nil {
+		// Redis异常时不阻塞登录
+		return true
+	}
+	return fresh
+}
+
+// useRecoveryCode 使用一次性恢复码
+func (s *TwoFactorService) useRecoveryCode(userID uint, code string) bool {
+	result := global.DB.Model(&database.TwoFactorRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, utils.HashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	return result.Error == nil && result.RowsAffected > 0
+}
+
+// Setup 生成新的TOTP密钥，确认前暂存在Redis中
+func (s *TwoFactorService) Setup(userID uint) (secret string, uri string, err error) {
+	var user database.User
+	if err = global.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		return "", "", errors.New("用户不存在")
+	}
+	if user.IsTwoFactorEnabled() {
+		return "", "", errors.New("两步验证已开启")
+	}
+
+	if secret, err = utils.GenerateTOTPSecret(); err != nil {
+		return "", "", err
+	}
+	if err = global.Redis.Set(twoFactorSetupKey(userID), secret, twoFactorSetupTTL).Err(); err != nil {
+		return "", "", err
+	}
+
+	issuer := global.Config.Account.TwoFactor.Issuer
+	if issuer == "" {
+		issuer = global.Config.Jwt.Issuer
+	}
+	return secret, utils.TOTPProvisioningURI(secret, issuer, user.Username), nil
+}
+
+// Enable 校验验证器应用生成的验证码，通过后开启两步验证并返回恢复码
+func (s *TwoFactorService) Enable(userID uint, code string) ([]string, error) {
+	secret, err := global.Redis.Get(twoFactorSetupKey(userID)).Result()
+	if err != nil || secret == "" {
+		return nil, errors.New("请先获取两步验证密钥")
+	}
+	if !s.verifyTOTP(userID, secret, code) {
+		return nil, errors.New("验证码错误")
+	}
+
+	codes, err := utils.GenerateRecoveryCodes(global.Config.Account.TwoFactor.RecoveryCodes())
+	if err != nil {
+		return nil, err
+	}
+
+	err = global.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&database.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"two_factor_secret":     secret,
+			"two_factor_enabled_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		return replaceRecoveryCodes(tx, userID, codes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	global.Redis.Del(twoFactorSetupKey(userID))
+	return codes, nil
+}
+
+// Disable 关闭两步验证，需要密码和当前验证码（或恢复码）
+func (s *TwoFactorService) Disable(userID uint, password, code string) error {
+	var user database.User
+	if err := global.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		return errors.New("用户不存在")
+	}
+	if !user.IsTwoFactorEnabled() {
+		return errors.New("两步验证未开启")
+	}
+	if global.Config.Account.TwoFactor.RequiredFor(user.Role) {
+		return errors.New("当前角色必须开启两步验证")
+	}
+	if !utils.BcryptCheck(password, user.Password) {
+		return errors.New("密码错误")
+	}
+	if !s.Verify(user, code) {
+		return errors.New("两步验证码错误")
+	}
+	return s.clear(userID)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧恢复码全部作废
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	var user database.User
+	if err := global.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	if !user.IsTwoFactorEnabled() {
+		return nil, errors.New("两步验证未开启")
+	}
+	if !s.verifyTOTP(user.ID, user.TwoFactorSecret, code) {
+		return nil, errors.New("两步验证码错误")
+	}
+
+	codes, err := utils.GenerateRecoveryCodes(global.Config.Account.TwoFactor.RecoveryCodes())
+	if err != nil {
+		return nil, err
+	}
+	if err := global.DB.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codes)
+	}); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Status 查询两步验证状态
+func (s *TwoFactorService) Status(userID uint) (status TwoFactorStatus, err error) {
+	var user database.User
+	if err = global.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		return status, errors.New("用户不存在")
+	}
+	status.Enabled = user.IsTwoFactorEnabled()
+	status.EnabledAt = user.TwoFactorEnabledAt
+	status.Required = global.Config.Account.TwoFactor.RequiredFor(user.Role)
+	if status.Enabled {
+		err = global.DB.Model(&database.TwoFactorRecoveryCode{}).
+			Where("user_id = ? AND used_at IS NULL", userID).
+			Count(&status.RecoveryCodesLeft).Error
+	}
+	return status, err
+}
+
+// AdminReset 管理员为丢失验证器的用户重置两步验证
+func (s *TwoFactorService) AdminReset(userUUID string) error {
+	var user database.User
+	if err := global.DB.Where("uuid = ?", userUUID).First(&user).Error; err != nil {
+		return errors.New("用户不存在")
+	}
+	return s.clear(user.ID)
+}
+
+// clear 清除两步验证密钥和恢复码
+func (s *TwoFactorService) clear(userID uint) error {
+	return global.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&database.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"two_factor_secret":     "",
+			"two_factor_enabled_at": nil,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&database.TwoFactorRecoveryCode{}).Error
+	})
+}
+
+// replaceRecoveryCodes 用新的恢复码替换用户现有的恢复码
+func replaceRecoveryCodes(tx *gorm.DB, userID uint, codes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&database.TwoFactorRecoveryCode{}).Error; err != nil {
+		return err
+	}
+	records := make([]database.TwoFactorRecoveryCode, 0, len(codes))
+	for _, code := range codes {
+		records = append(records, database.TwoFactorRecoveryCode{
+			UserID:   userID,
+			CodeHash: utils.HashRecoveryCode(code),
+		})
+	}
+	return tx.Create(&records).Error
+}
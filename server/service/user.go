@@ -2,10 +2,12 @@ package service
 
 import (
 	"errors"
+	"fmt"
 	"mime/multipart"
 	"os"
 	"path/filepath"
 	"server/global"
+	"server/model/appType"
 	"server/model/database"
 	"server/model/request"
 	"server/utils"
@@ -49,11 +51,14 @@ func (u *UserService) Register(registerReq request.RegisterRequest) (err error,
 	}
 
 	// 创建用户
+	// 注册时已校验邮箱验证码，邮箱视为已验证
+	now := time.Now()
 	user = database.User{
-		Username: registerReq.Username,
-		Password: utils.BcryptHash(registerReq.Password),
-		Nickname: registerReq.Nickname,
-		Email:    registerReq.Email,
+		Username:      registerReq.Username,
+		Password:      utils.BcryptHash(registerReq.Password),
+		Nickname:      registerReq.Nickname,
+		Email:         registerReq.Email,
+		EmailVerified: &now,
 	}
 
 	if err = tx.Create(&user).Error; err != nil {
@@ -127,8 +132,10 @@ func (u *UserService) UpdateUserInfo(updateReq request.UserUpdateRequest) (err e
 	if updateReq.Nickname != "" {
 		updateMap["nickname"] = updateReq.Nickname
 	}
-	if updateReq.Email != "" {
+	if updateReq.Email != "" && updateReq.Email != user.Email {
+		// 邮箱变更后需要重新验证
 		updateMap["email"] = updateReq.Email
+		updateMap["email_verified"] = nil
 	}
 	if updateReq.Avatar != "" {
 		updateMap["avatar"] = updateReq.Avatar
@@ -185,20 +192,6 @@ func (u *UserService) ChangePassword(id uint, passwordReq request.ChangePassword
 	return nil
 }
 
-// DeleteUser 删除用户
-func (u *UserService) DeleteUser(id uint) (err error) {
-	var user database.User
-	if err = global.DB.Where("id = ?", id).First(&user).Error; err != nil {
-		return errors.New("用户不存在")
-	}
-
-	if err = global.DB.Delete(&user).Error; err != nil {
-		return err
-	}
-
-	return nil
-}
-
 // GetUserList 获取用户列表
 func (u *UserService) GetUserList(listReq request.UserListRequest) (err error, list []database.User, total int64) {
 	// 构建查询
@@ -333,8 +326,11 @@ func (u *UserService) ResetUserPassword(email, newPassword string) error {
 	// 加密密码
 	hashedPassword := utils.BcryptHash(newPassword)
 
-	// 更新数据库中的密码
-	return global.DB.Model(&database.User{}).Where("email = ?", email).Update("password", string(hashedPassword)).Error
+	// 更新数据库中的密码，第三方登录创建的账户设置密码后也可以使用密码登录
+	return global.DB.Model(&database.User{}).Where("email = ?", email).Updates(map[string]interface{}{
+		"password":     string(hashedPassword),
+		"login_method": appType.LoginTypePassword,
+	}).Error
 }
 
 // FindUserByEmail 根据邮箱查找用户
@@ -344,6 +340,13 @@ func (u *UserService) FindUserByEmail(email string) (database.User, error) {
 	return user, err
 }
 
+// FindUserByUUID 根据UUID查找用户
+func (u *UserService) FindUserByUUID(userUUID string) (database.User, error) {
+	var user database.User
+	err := global.DB.Where("uuid = ?", userUUID).First(&user).Error
+	return user, err
+}
+
 // UploadAvatar 上传头像
 func (u *UserService) UploadAvatar(file *multipart.FileHeader, userID uint) (string, error) {
 	// 创建头像存储目录
@@ -404,3 +407,132 @@ func (u *UserService) RejectUser(userUUID string) error {
 
 	return nil
 }
+
+// emailVerifyKey 邮箱验证码的Redis键
+func emailVerifyKey(userID uint) string {
+	return fmt.Sprintf("email_verify:%d", userID)
+}
+
+// emailChangeKey 邮箱变更验证码的Redis键
+func emailChangeKey(userID uint) string {
+	return fmt.Sprintf("email_change:%d", userID)
+}
+
+// SendEmailVerification 向用户当前邮箱发送验证码
+func (u *UserService) SendEmailVerification(userID uint) error {
+	var user database.User
+	if err := global.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		return errors.New("用户不存在")
+	}
+	if user.IsEmailVerified() {
+		return errors.New("邮箱已验证")
+	}
+
+	ttl := global.Config.Account.EmailCodeTTL()
+	code := utils.GenerateEmailCode()
+	if err := utils.StorePendingEmailCode(emailVerifyKey(userID), user.Email, code, ttl); err != nil {
+		return err
+	}
+	body := fmt.Sprintf("您的邮箱验证码为: <b>%s</b>，有效期%d分钟", code, int(ttl.Minutes()))
+	return utils.SendEmail(user.Email, "邮箱验证", body)
+}
+
+// VerifyEmail 校验验证码并标记当前邮箱为已验证
+func (u *UserService) VerifyEmail(userID uint, code string) (user database.User, err error) {
+	email, ok := utils.CheckPendingEmailCode(emailVerifyKey(userID), code)
+	if !ok {
+		return user, errors.New("验证码错误或已过期")
+	}
+
+	// 验证码发出后邮箱发生过变更时，不能用来验证新邮箱
+	now := time.Now()
+	result := global.DB.Model(&database.User{}).
+		Where("id = ? AND email = ?", userID, email).
+		Update("email_verified", now)
+	if result.Error != nil {
+		return user, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return user, errors.New("邮箱已变更，请重新发送验证码")
+	}
+
+	err = global.DB.Where("id = ?", userID).First(&user).Error
+	return user, err
+}
+
+// RequestEmailChange 申请修改邮箱：向新邮箱发送确认验证码，并通知旧邮箱
+func (u *UserService) RequestEmailChange(userID uint, req request.EmailChangeRequest) error {
+	var user database.User
+	if err := global.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		return errors.New("用户不存在")
+	}
+
+	// 修改邮箱前需要再次验证身份，否则只凭登录令牌就能改掉邮箱，再通过找回密码接管账户
+	proof := ReauthProof{Password: req.Password, Code: req.Code, ReauthToken: req.ReauthToken}
+	if err := (&AccountService{}).Reauthenticate(user, proof); err != nil {
+		return err
+	}
+	if strings.EqualFold(user.Email, req.NewEmail) {
+		return errors.New("新邮箱与当前邮箱相同")
+	}
+
+	var count int64
+	if err := global.DB.Model(&database.User{}).Where("email = ?", req.NewEmail).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("邮箱已被注册")
+	}
+
+	ttl := global.Config.Account.EmailCodeTTL()
+	code := utils.GenerateEmailCode()
+	if err := utils.StorePendingEmailCode(emailChangeKey(userID), req.NewEmail, code, ttl); err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("您正在将账户 %s 的邮箱修改为本邮箱，验证码为: <b>%s</b>，有效期%d分钟", user.Username, code, int(ttl.Minutes()))
+	if err := utils.SendEmail(req.NewEmail, "确认修改邮箱", body); err != nil {
+		global.Redis.Del(emailChangeKey(userID))
+		return err
+	}
+
+	// 通知旧邮箱，发送失败不影响流程
+	notice := fmt.Sprintf("您的账户 %s 正在申请将邮箱修改为 %s。如非本人操作，请立即修改密码。", user.Username, req.NewEmail)
+	if err := utils.SendEmail(user.Email, "邮箱修改提醒", notice); err != nil {
+		global.ZapLog.Warn("发送邮箱修改提醒失败", zap.String("email", user.Email), zap.Error(err))
+	}
+	return nil
+}
+
+// ConfirmEmailChange 校验新邮箱收到的验证码并完成邮箱修改
+func (u *UserService) ConfirmEmailChange(userID uint, code string) (user database.User, err error) {
+	newEmail, ok := utils.CheckPendingEmailCode(emailChangeKey(userID), code)
+	if !ok {
+		return user, errors.New("验证码错误或已过期")
+	}
+
+	if err = global.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		return user, errors.New("用户不存在")
+	}
+	oldEmail := user.Email
+
+	// 新邮箱已通过验证码证明归属，直接标记为已验证
+	now := time.Now()
+	if err = global.DB.Model(&user).Updates(map[string]interface{}{
+		"email":          newEmail,
+		"email_verified": now,
+	}).Error; err != nil {
+		if strings.Contains(err.Error(), "Duplicate entry") {
+			return user, errors.New("邮箱已被注册")
+		}
+		return user, err
+	}
+
+	notice := fmt.Sprintf("您的账户 %s 的邮箱已修改为 %s。如非本人操作，请立即联系管理员。", user.Username, newEmail)
+	if err := utils.SendEmail(oldEmail, "邮箱已修改", notice); err != nil {
+		global.ZapLog.Warn("发送邮箱修改通知失败", zap.String("email", oldEmail), zap.Error(err))
+	}
+
+	err = global.DB.Where("id = ?", userID).First(&user).Error
+	return user, err
+}
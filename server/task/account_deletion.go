@@ -0,0 +1,26 @@
+package task
+
+import (
+	"server/global"
+	"server/service"
+
+	"github.com/robfig/cron/v3"
+)
+
+// ProcessAccountDeletionTask 执行等待中或中断的账户注销任务
+// 注销任务提交后会立即在后台执行，定时任务用于处理服务重启等原因未完成的任务
+func ProcessAccountDeletionTask() {
+	accountService := service.AccountService{}
+	accountService.ProcessPendingDeletions()
+}
+
+// RegisterAccountDeletionTask 注册账户注销任务
+func RegisterAccountDeletionTask(c *cron.Cron) error {
+	// 每5分钟执行一次
+	_, err := c.AddFunc("0 */5 * * * *", ProcessAccountDeletionTask)
+	if err != nil {
+		return err
+	}
+	global.ZapLog.Info("账户注销任务注册成功")
+	return nil
+}
@@ -0,0 +1,35 @@
+package task
+
+import (
+	"server/global"
+	"server/service"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+// CleanupAuditLogTask 按保留天数清理过期的审计日志
+func CleanupAuditLogTask() {
+	retentionDays := global.Config.Audit.RetentionDays
+	if retentionDays <= 0 {
+		return
+	}
+
+	auditService := service.AuditService{}
+	deleted, err := auditService.CleanupExpiredAuditLogs(retentionDays)
+	if err != nil {
+		global.ZapLog.Error("清理过期审计日志失败", zap.Int64("deleted", deleted), zap.Error(err))
+		return
+	}
+	global.ZapLog.Info("过期审计日志清理完成", zap.Int("retention_days", retentionDays), zap.Int64("deleted", deleted))
+}
+
+// RegisterCleanupAuditLogTask 注册审计日志清理任务
+func RegisterCleanupAuditLogTask(c *cron.Cron) error {
+	_, err := c.AddFunc(global.Config.Audit.CleanupSpec(), CleanupAuditLogTask)
+	if err != nil {
+		return err
+	}
+	global.ZapLog.Info("审计日志清理任务注册成功")
+	return nil
+}
@@ -0,0 +1,44 @@
+package task
+
+import (
+	"server/global"
+	"server/service"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+// BackupTask 自动备份数据库，并按保留数量清理旧备份
+func BackupTask() {
+	conf := global.Config.Backup
+	backupService := service.BackupService{}
+
+	path, result, err := backupService.BackupToDir(conf.BackupDir())
+	if err != nil {
+		global.ZapLog.Error("自动备份失败", zap.Error(err))
+		return
+	}
+	global.ZapLog.Info("自动备份完成", zap.String("path", path), zap.String("schema_version", result.SchemaVersion), zap.Any("counts", result.Counts))
+
+	removed, err := backupService.RotateBackups(conf.BackupDir(), conf.Keep)
+	if err != nil {
+		global.ZapLog.Error("清理旧备份失败", zap.Strings("removed", removed), zap.Error(err))
+		return
+	}
+	if len(removed) > 0 {
+		global.ZapLog.Info("已清理旧备份", zap.Strings("removed", removed))
+	}
+}
+
+// RegisterBackupTask 注册自动备份任务
+func RegisterBackupTask(c *cron.Cron) error {
+	if !global.Config.Backup.Enable {
+		return nil
+	}
+	_, err := c.AddFunc(global.Config.Backup.Spec(), BackupTask)
+	if err != nil {
+		return err
+	}
+	global.ZapLog.Info("自动备份任务注册成功")
+	return nil
+}
@@ -0,0 +1,33 @@
+package task
+
+import (
+	"server/global"
+	"server/service"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+// DeliverESOutboxTask 投递到期的文章变更事件，包括等待重试的事件
+func DeliverESOutboxTask() {
+	outboxService := service.ESOutboxService{}
+	delivered, failed, err := outboxService.DeliverDue()
+	if err != nil {
+		global.ZapLog.Error("投递ES同步事件失败", zap.Error(err))
+		return
+	}
+	if delivered > 0 || failed > 0 {
+		global.ZapLog.Info("ES同步事件投递完成", zap.Int("delivered", delivered), zap.Int("failed", failed))
+	}
+}
+
+// RegisterESOutboxTask 注册ES同步事件投递任务，上一次还没执行完时跳过本次
+func RegisterESOutboxTask(c *cron.Cron) error {
+	job := cron.NewChain(cron.SkipIfStillRunning(cron.DefaultLogger)).Then(cron.FuncJob(DeliverESOutboxTask))
+	_, err := c.AddJob(global.Config.ES.OutboxSpec(), job)
+	if err != nil {
+		return err
+	}
+	global.ZapLog.Info("ES同步事件投递任务注册成功")
+	return nil
+}
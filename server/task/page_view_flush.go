@@ -0,0 +1,45 @@
+package task
+
+import (
+	"server/global"
+	"server/service"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+// FlushPageViewsTask 把 Redis 中累计的浏览统计写入数据库，并按保留天数清理过期统计
+func FlushPageViewsTask() {
+	pageViewService := service.PageViewService{}
+	rows, err := pageViewService.Flush()
+	if err != nil {
+		global.ZapLog.Error("写入浏览统计失败", zap.Error(err))
+		return
+	}
+	if rows > 0 {
+		global.ZapLog.Info("浏览统计写入完成", zap.Int("rows", rows))
+	}
+
+	retentionDays := global.Config.Analytics.RetentionDays
+	if retentionDays <= 0 {
+		return
+	}
+	deleted, err := pageViewService.CleanupExpired(retentionDays)
+	if err != nil {
+		global.ZapLog.Error("清理过期浏览统计失败", zap.Error(err))
+		return
+	}
+	if deleted > 0 {
+		global.ZapLog.Info("过期浏览统计清理完成", zap.Int("retention_days", retentionDays), zap.Int64("deleted", deleted))
+	}
+}
+
+// RegisterFlushPageViewsTask 注册浏览统计写入任务
+func RegisterFlushPageViewsTask(c *cron.Cron) error {
+	_, err := c.AddFunc(global.Config.Analytics.FlushSpec(), FlushPageViewsTask)
+	if err != nil {
+		return err
+	}
+	global.ZapLog.Info("浏览统计写入任务注册成功")
+	return nil
+}
@@ -11,4 +11,25 @@ func RegisterTask(c *cron.Cron) {
 	if err := RegisterSyncArticleStatsTask(c); err != nil {
 		global.ZapLog.Error("注册文章统计数据同步任务失败", zap.Error(err))
 	}
+	if err := RegisterCleanupAuditLogTask(c); err != nil {
+		global.ZapLog.Error("注册审计日志清理任务失败", zap.Error(err))
+	}
+	if err := RegisterPurgeTrashTask(c); err != nil {
+		global.ZapLog.Error("注册回收站清理任务失败", zap.Error(err))
+	}
+	if err := RegisterAccountDeletionTask(c); err != nil {
+		global.ZapLog.Error("注册账户注销任务失败", zap.Error(err))
+	}
+	if err := RegisterBackupTask(c); err != nil {
+		global.ZapLog.Error("注册自动备份任务失败", zap.Error(err))
+	}
+	if err := RegisterFlushSearchAnalyticsTask(c); err != nil {
+		global.ZapLog.Error("注册搜索统计写入任务失败", zap.Error(err))
+	}
+	if err := RegisterESOutboxTask(c); err != nil {
+		global.ZapLog.Error("注册ES同步事件投递任务失败", zap.Error(err))
+	}
+	if err := RegisterFlushPageViewsTask(c); err != nil {
+		global.ZapLog.Error("注册浏览统计写入任务失败", zap.Error(err))
+	}
 }
@@ -0,0 +1,45 @@
+package task
+
+import (
+	"server/global"
+	"server/service"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+// FlushSearchAnalyticsTask 把 Redis 中累计的搜索统计写入数据库，并按保留天数清理过期统计
+func FlushSearchAnalyticsTask() {
+	analyticsService := service.SearchAnalyticsService{}
+	rows, err := analyticsService.Flush()
+	if err != nil {
+		global.ZapLog.Error("写入搜索统计失败", zap.Error(err))
+		return
+	}
+	if rows > 0 {
+		global.ZapLog.Info("搜索统计写入完成", zap.Int("rows", rows))
+	}
+
+	retentionDays := global.Config.Search.AnalyticsRetentionDays
+	if retentionDays <= 0 {
+		return
+	}
+	deleted, err := analyticsService.CleanupExpired(retentionDays)
+	if err != nil {
+		global.ZapLog.Error("清理过期搜索统计失败", zap.Error(err))
+		return
+	}
+	if deleted > 0 {
+		global.ZapLog.Info("过期搜索统计清理完成", zap.Int("retention_days", retentionDays), zap.Int64("deleted", deleted))
+	}
+}
+
+// RegisterFlushSearchAnalyticsTask 注册搜索统计写入任务
+func RegisterFlushSearchAnalyticsTask(c *cron.Cron) error {
+	_, err := c.AddFunc(global.Config.Search.FlushSpec(), FlushSearchAnalyticsTask)
+	if err != nil {
+		return err
+	}
+	global.ZapLog.Info("搜索统计写入任务注册成功")
+	return nil
+}
@@ -0,0 +1,35 @@
+package task
+
+import (
+	"server/global"
+	"server/service"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+// PurgeTrashTask 彻底删除在回收站中超过保留期的内容，图片会同时删除文件
+func PurgeTrashTask() {
+	retentionDays := global.Config.Trash.RetentionDays
+	if retentionDays <= 0 {
+		return
+	}
+
+	trashService := service.TrashService{}
+	purged, err := trashService.PurgeExpired(retentionDays)
+	if err != nil {
+		global.ZapLog.Error("清理回收站失败", zap.Any("purged", purged), zap.Error(err))
+		return
+	}
+	global.ZapLog.Info("回收站清理完成", zap.Int("retention_days", retentionDays), zap.Any("purged", purged))
+}
+
+// RegisterPurgeTrashTask 注册回收站清理任务
+func RegisterPurgeTrashTask(c *cron.Cron) error {
+	_, err := c.AddFunc(global.Config.Trash.PurgeSpec(), PurgeTrashTask)
+	if err != nil {
+		return err
+	}
+	global.ZapLog.Info("回收站清理任务注册成功")
+	return nil
+}
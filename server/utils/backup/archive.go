@@ -0,0 +1,237 @@
+// Package backup 数据库备份归档格式：gzip 压缩的 JSON Lines，依次为文件头、各表的列定义和数据行、结束记录
+package backup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf8"
+)
+
+// 归档格式标识和版本，格式不兼容地变化时递增 FormatVersion
+const (
+	FormatName    = "go_blog_backup"
+	FormatVersion = 1
+)
+
+// timeLayout 时间值的存储格式，恢复时按原样写回 DATETIME 列
+const timeLayout = "2006-01-02 15:04:05.999999"
+
+// Header 归档文件头
+type Header struct {
+	Format        string    `json:"format"`
+	Version       int       `json:"version"`
+	SchemaVersion string    `json:"schema_version"` // 备份时的表结构版本，恢复时必须一致
+	CreatedAt     time.Time `json:"created_at"`
+	Tables        []string  `json:"tables"`
+}
+
+// 归档中的记录类型
+const (
+	EntryTable = "table" // 开始一张表，包含列名
+	EntryRow   = "row"   // 一行数据
+	entryEnd   = "end"   // 结束记录，包含各表行数，用于检测文件是否完整
+)
+
+// Entry 归档中的一条记录
+type Entry struct {
+	Kind    string        `json:"kind"`
+	Table   string        `json:"table,omitempty"`
+	Columns []string      `json:"columns,omitempty"`
+	Row     []interface{} `json:"row,omitempty"`
+}
+
+// endEntry 结束记录
+type endEntry struct {
+	Kind   string           `json:"kind"`
+	Counts map[string]int64 `json:"counts"`
+}
+
+// binaryValue 非 UTF-8 的二进制值
+type binaryValue struct {
+	Base64 string `json:"b64"`
+}
+
+// EncodeValue 把数据库驱动返回的值转换为可 JSON 序列化的值
+func EncodeValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []byte:
+		if utf8.Valid(v) {
+			return string(v)
+		}
+		return binaryValue{Base64: base64.StdEncoding.EncodeToString(v)}
+	case time.Time:
+		return v.Format(timeLayout)
+	default:
+		return v
+	}
+}
+
+// DecodeValue 把归档中的值还原为数据库参数，数字以字符串形式交给数据库转换以保持精度
+func DecodeValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case nil, string, bool:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case map[string]interface{}:
+		if s, ok := v["b64"].(string); ok {
+			return base64.StdEncoding.DecodeString(s)
+		}
+	}
+	return nil, fmt.Errorf("无法识别的值: %v", v)
+}
+
+// Writer 归档写入器
+type Writer struct {
+	gz      *gzip.Writer
+	enc     *json.Encoder
+	table   string
+	columns int
+	counts  map[string]int64
+}
+
+// NewWriter 创建归档写入器并写入文件头
+func NewWriter(w io.Writer, header Header) (*Writer, error) {
+	header.Format = FormatName
+	header.Version = FormatVersion
+	gz := gzip.NewWriter(w)
+	aw := &Writer{gz: gz, enc: json.NewEncoder(gz), counts: make(map[string]int64)}
+	if err := aw.enc.Encode(header); err != nil {
+		return nil, err
+	}
+	return aw, nil
+}
+
+// BeginTable 开始写入一张表
+func (w *Writer) BeginTable(table string, columns []string) error {
+	if _, ok := w.counts[table]; ok {
+		return fmt.Errorf("表 %s 重复写入", table)
+	}
+	w.table = table
+	w.columns = len(columns)
+	w.counts[table] = 0
+	return w.enc.Encode(Entry{Kind: EntryTable, Table: table, Columns: columns})
+}
+
+// WriteRow 写入当前表的一行数据，values 为数据库驱动返回的原始值
+func (w *Writer) WriteRow(values []interface{}) error {
+	if w.table == "" {
+		return errors.New("写入数据行前必须先开始一张表")
+	}
+	if len(values) != w.columns {
+		return fmt.Errorf("表 %s 的数据行列数不匹配", w.table)
+	}
+	row := make([]interface{}, len(values))
+	for i, v := range values {
+		row[i] = EncodeValue(v)
+	}
+	w.counts[w.table]++
+	return w.enc.Encode(Entry{Kind: EntryRow, Row: row})
+}
+
+// Counts 已写入的各表行数
+func (w *Writer) Counts() map[string]int64 {
+	return w.counts
+}
+
+// Close 写入结束记录并关闭压缩流，不关闭底层 io.Writer
+func (w *Writer) Close() error {
+	if err := w.enc.Encode(endEntry{Kind: entryEnd, Counts: w.counts}); err != nil {
+		return err
+	}
+	return w.gz.Close()
+}
+
+// Reader 归档读取器
+type Reader struct {
+	header Header
+	dec    *json.Decoder
+	table  string
+	counts map[string]int64
+	done   bool
+}
+
+// NewReader 打开归档并校验文件头
+func NewReader(r io.Reader) (*Reader, error) {
+	gz, err := gzip.NewReader(bufio.NewReader(r))
+	if err != nil {
+		return nil, fmt.Errorf("不是有效的备份文件: %v", err)
+	}
+	dec := json.NewDecoder(gz)
+	dec.UseNumber()
+
+	var header Header
+	if err := dec.Decode(&header); err != nil {
+		return nil, fmt.Errorf("读取备份文件头失败: %v", err)
+	}
+	if header.Format != FormatName {
+		return nil, errors.New("不是有效的备份文件")
+	}
+	if header.Version > FormatVersion {
+		return nil, fmt.Errorf("备份格式版本 %d 高于当前支持的版本 %d，请升级程序后再恢复", header.Version, FormatVersion)
+	}
+	return &Reader{header: header, dec: dec, counts: make(map[string]int64)}, nil
+}
+
+// Header 归档文件头
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+// Next 读取下一条记录，数据行中的值已还原为数据库参数；读到结束记录后返回 io.EOF
+// 文件在结束记录之前中断或行数与结束记录不一致时返回错误
+func (r *Reader) Next() (Entry, error) {
+	if r.done {
+		return Entry{}, io.EOF
+	}
+
+	var raw struct {
+		Entry
+		Counts map[string]int64 `json:"counts"`
+	}
+	if err := r.dec.Decode(&raw); err != nil {
+		if errors.Is(err, io.EOF) {
+			return Entry{}, errors.New("备份文件不完整：缺少结束记录")
+		}
+		return Entry{}, err
+	}
+
+	switch raw.Kind {
+	case EntryTable:
+		r.table = raw.Table
+		r.counts[raw.Table] = 0
+		return raw.Entry, nil
+	case EntryRow:
+		if r.table == "" {
+			return Entry{}, errors.New("备份文件格式错误：数据行不属于任何表")
+		}
+		for i, v := range raw.Row {
+			value, err := DecodeValue(v)
+			if err != nil {
+				return Entry{}, err
+			}
+			raw.Row[i] = value
+		}
+		r.counts[r.table]++
+		raw.Table = r.table
+		return raw.Entry, nil
+	case entryEnd:
+		for table, count := range raw.Counts {
+			if r.counts[table] != count {
+				return Entry{}, fmt.Errorf("备份文件不完整：表 %s 应有 %d 行，实际读取 %d 行", table, count, r.counts[table])
+			}
+		}
+		r.done = true
+		return Entry{}, io.EOF
+	default:
+		return Entry{}, fmt.Errorf("备份文件格式错误：未知记录类型 %q", raw.Kind)
+	}
+}
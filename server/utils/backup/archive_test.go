@@ -0,0 +1,97 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeSample(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, Header{SchemaVersion: "abc", Tables: []string{"users"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.BeginTable("users", []string{"id", "name", "avatar", "created_at", "deleted_at"}); err != nil {
+		t.Fatal(err)
+	}
+	created := time.Date(2024, 1, 2, 3, 4, 5, 600000000, time.Local)
+	if err := w.WriteRow([]interface{}{int64(1), []byte("张三"), []byte{0xff, 0x00}, created, nil}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRow([]interface{}{int64(2)}); err == nil {
+		t.Fatal("列数不匹配时应返回错误")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	r, err := NewReader(bytes.NewReader(writeSample(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := r.Header(); h.Format != FormatName || h.Version != FormatVersion || h.SchemaVersion != "abc" {
+		t.Fatalf("文件头错误: %+v", h)
+	}
+
+	entry, err := r.Next()
+	if err != nil || entry.Kind != EntryTable || entry.Table != "users" || len(entry.Columns) != 5 {
+		t.Fatalf("表记录错误: %+v %v", entry, err)
+	}
+	entry, err = r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{"1", "张三", []byte{0xff, 0x00}, "2024-01-02 03:04:05.6", nil}
+	if entry.Kind != EntryRow || entry.Table != "users" || !reflect.DeepEqual(entry.Row, want) {
+		t.Fatalf("数据行错误: %#v", entry.Row)
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Fatalf("期望 io.EOF，实际 %v", err)
+	}
+}
+
+func TestArchiveTruncated(t *testing.T) {
+	data := writeSample(t)
+
+	// 重新压缩去掉结束记录的内容，模拟写入中断
+	r, _ := NewReader(bytes.NewReader(data))
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf, r.Header())
+	w.BeginTable("users", []string{"id"})
+	w.WriteRow([]interface{}{int64(1)})
+	w.gz.Close()
+
+	r, err := NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		if _, err = r.Next(); err != nil {
+			break
+		}
+	}
+	if err == io.EOF || !strings.Contains(err.Error(), "不完整") {
+		t.Fatalf("期望文件不完整错误，实际 %v", err)
+	}
+}
+
+func TestReaderRejectsNewerVersion(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	fmt.Fprintf(gz, `{"format":%q,"version":%d}`+"\n", FormatName, FormatVersion+1)
+	gz.Close()
+
+	if _, err := NewReader(&buf); err == nil || !strings.Contains(err.Error(), "升级") {
+		t.Fatalf("更高版本的备份格式应被拒绝，实际 %v", err)
+	}
+}
@@ -6,37 +6,82 @@ import (
 	"sync"
 	"time"
 
+	"github.com/go-redis/redis"
 	"github.com/mojocn/base64Captcha"
 	"go.uber.org/zap"
 
 	"server/global"
 )
 
+// 验证码类型
+const (
+	CaptchaTypeImage = "image" // 数字图片验证码
+	CaptchaTypeMath  = "math"  // 算术题验证码
+	CaptchaTypeAudio = "audio" // 语音验证码（供读屏用户使用）
+)
+
 var (
-	captchaInstance *base64Captcha.Captcha
-	once            sync.Once
+	captchaInstances map[string]*base64Captcha.Captcha
+	once             sync.Once
 )
 
 // 初始化验证码驱动
 func initCaptcha() {
-	driver := base64Captcha.NewDriverDigit(
-		global.Config.Captcha.Height,
-		global.Config.Captcha.Width,
-		global.Config.Captcha.Length,
-		global.Config.Captcha.MaxSkew,
-		global.Config.Captcha.DotCount,
-	)
+	conf := global.Config.Captcha
 
 	// 使用默认内存存储
 	store := base64Captcha.DefaultMemStore
 
-	captchaInstance = base64Captcha.NewCaptcha(driver, store)
+	audioLanguage := conf.AudioLanguage
+	if audioLanguage == "" {
+		audioLanguage = "zh"
+	}
+
+	captchaInstances = map[string]*base64Captcha.Captcha{
+		CaptchaTypeImage: base64Captcha.NewCaptcha(base64Captcha.NewDriverDigit(
+			conf.Height,
+			conf.Width,
+			conf.Length,
+			conf.MaxSkew,
+			conf.DotCount,
+		), store),
+		CaptchaTypeMath: base64Captcha.NewCaptcha(base64Captcha.NewDriverMath(
+			conf.Height,
+			conf.Width,
+			0,
+			base64Captcha.OptionShowHollowLine,
+			nil,
+			nil,
+			nil,
+		), store),
+		CaptchaTypeAudio: base64Captcha.NewCaptcha(base64Captcha.NewDriverAudio(conf.Length, audioLanguage), store),
+	}
+}
+
+// IsValidCaptchaType 检查验证码类型是否受支持
+func IsValidCaptchaType(captchaType string) bool {
+	switch captchaType {
+	case CaptchaTypeImage, CaptchaTypeMath, CaptchaTypeAudio:
+		return true
+	default:
+		return false
+	}
 }
 
 // GenerateCaptcha 生成验证码
 func GenerateCaptcha() (string, string, error) {
+	return GenerateCaptchaOfType(CaptchaTypeImage)
+}
+
+// GenerateCaptchaOfType 生成指定类型的验证码，返回验证码ID和base64编码的图片或音频
+func GenerateCaptchaOfType(captchaType string) (string, string, error) {
 	once.Do(initCaptcha)
 
+	captchaInstance, ok := captchaInstances[captchaType]
+	if !ok {
+		return "", "", fmt.Errorf("不支持的验证码类型: %s", captchaType)
+	}
+
 	// 生成验证码
 	id, b64s, code, err := captchaInstance.Generate()
 	if err != nil {
@@ -45,14 +90,10 @@ func GenerateCaptcha() (string, string, error) {
 	}
 
 	// 从内存存储获取验证码文本
-	code = captchaInstance.Store.Get(id, false)
+	code = captchaInstance.Store.Get(id, true)
 	if code == "" {
 		return "", "", fmt.Errorf("验证码不存在或已过期")
 	}
-	if err != nil {
-		global.ZapLog.Error("获取验证码文本失败", zap.Error(err))
-		return "", "", err
-	}
 
 	// 将验证码文本存储到Redis，设置5分钟过期
 	redisKey := fmt.Sprintf("captcha:%s", id)
@@ -71,27 +112,18 @@ func VerifyCaptcha(id string, code string) bool {
 		return false
 	}
 
-	// 从Redis获取验证码
+	// 取出验证码的同时删除，无论对错都只能验证一次，避免同一验证码被反复猜测
 	redisKey := fmt.Sprintf("captcha:%s", id)
-	storedCode, err := global.Redis.Get(redisKey).Result()
-	if err != nil {
-		global.ZapLog.Error("从Redis获取验证码失败", zap.Error(err))
+	pipe := global.Redis.TxPipeline()
+	get := pipe.Get(redisKey)
+	pipe.Del(redisKey)
+	if _, err := pipe.Exec(); err != nil {
+		if err != redis.Nil {
+			global.ZapLog.Error("从Redis获取验证码失败", zap.Error(err))
+		}
 		return false
 	}
 
-	// 记录验证信息用于调试
-	global.ZapLog.Info("验证码验证",
-		zap.String("id", id),
-		zap.String("stored", storedCode),
-		zap.String("input", code),
-	)
-
 	// 不区分大小写比较验证码
-	if strings.EqualFold(storedCode, code) {
-		// 验证成功后删除验证码
-		global.Redis.Del(redisKey)
-		return true
-	}
-
-	return false
+	return strings.EqualFold(get.Val(), code)
 }
@@ -11,12 +11,17 @@ import (
 
 // SendEmailCode 发送邮箱验证码，支持多种场景
 func SendEmailCode(toEmail, code, subject, usage string) error {
+	return SendEmail(toEmail, subject, "您的"+usage+"验证码为: <b>"+code+"</b>，有效期5分钟")
+}
+
+// SendEmail 发送HTML邮件
+func SendEmail(toEmail, subject, body string) error {
 	// 创建邮件消息
 	msg := gomail.NewMessage()
 	msg.SetHeader("From", global.Config.Email.From)
 	msg.SetHeader("To", toEmail)
 	msg.SetHeader("Subject", subject)
-	msg.SetBody("text/html", "您的"+usage+"验证码为: <b>"+code+"</b>，有效期5分钟")
+	msg.SetBody("text/html", body)
 
 	// 创建SMTP客户端
 	dialer := gomail.NewDialer(
@@ -63,3 +68,33 @@ func VerifyEmailCode(email, code string) bool {
 
 	return storedCode == code
 }
+
+// maxPendingEmailCodeAttempts 邮箱验证码允许的最大错误次数，超过后验证码作废
+const maxPendingEmailCodeAttempts = 5
+
+// StorePendingEmailCode 保存与邮箱绑定的待确认验证码，用于邮箱验证和邮箱变更
+func StorePendingEmailCode(key, email, code string, ttl time.Duration) error {
+	pipe := global.Redis.TxPipeline()
+	pipe.Del(key)
+	pipe.HMSet(key, map[string]interface{}{"email": email, "code": code})
+	pipe.Expire(key, ttl)
+	_, err := pipe.Exec()
+	return err
+}
+
+// CheckPendingEmailCode 校验待确认的验证码，成功时返回绑定的邮箱并删除记录
+func CheckPendingEmailCode(key, code string) (string, bool) {
+	values, err := global.Redis.HGetAll(key).Result()
+	if err != nil || values["code"] == "" {
+		return "", false
+	}
+	if values["code"] != code {
+		// 错误次数过多时作废验证码，防止暴力猜测
+		if attempts, _ := global.Redis.HIncrBy(key, "attempts", 1).Result(); attempts >= maxPendingEmailCodeAttempts {
+			global.Redis.Del(key)
+		}
+		return "", false
+	}
+	global.Redis.Del(key)
+	return values["email"], true
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// highlightSpan 文本中一处关键词命中的位置（按字符计）
+type highlightSpan struct {
+	start, end int
+}
+
+// findHighlightSpans 查找关键词的全部命中位置，不区分大小写，同一位置优先匹配较长的关键词
+func findHighlightSpans(text []rune, keywords []string) []highlightSpan {
+	lower := make([]rune, len(text))
+	for i, r := range text {
+		lower[i] = unicode.ToLower(r)
+	}
+	var words [][]rune
+	for _, keyword := range keywords {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			words = append(words, []rune(strings.ToLower(keyword)))
+		}
+	}
+
+	var spans []highlightSpan
+	for i := 0; i < len(lower); {
+		longest := 0
+		for _, word := range words {
+			if len(word) > longest && i+len(word) <= len(lower) && string(lower[i:i+len(word)]) == string(word) {
+				longest = len(word)
+			}
+		}
+		if longest == 0 {
+			i++
+			continue
+		}
+		spans = append(spans, highlightSpan{i, i + longest})
+		i += longest
+	}
+	return spans
+}
+
+// renderHighlight 输出 text[start:end]，命中部分用 pre/post 包裹，其余内容做 HTML 转义
+func renderHighlight(text []rune, spans []highlightSpan, start, end int, pre, post string) string {
+	var sb strings.Builder
+	pos := start
+	for _, span := range spans {
+		if span.end <= start || span.start >= end {
+			continue
+		}
+		s, e := max(span.start, start), min(span.end, end)
+		sb.WriteString(html.EscapeString(string(text[pos:s])))
+		sb.WriteString(pre)
+		sb.WriteString(html.EscapeString(string(text[s:e])))
+		sb.WriteString(post)
+		pos = e
+	}
+	sb.WriteString(html.EscapeString(string(text[pos:end])))
+	return sb.String()
+}
+
+// SplitKeywords 把搜索词按空白切分为关键词
+func SplitKeywords(keyword string) []string {
+	return strings.Fields(keyword)
+}
+
+// HighlightText 高亮整段文本中的关键词，没有命中时返回空字符串
+func HighlightText(text string, keywords []string, pre, post string) string {
+	runes := []rune(text)
+	spans := findHighlightSpans(runes, keywords)
+	if len(spans) == 0 {
+		return ""
+	}
+	return renderHighlight(runes, spans, 0, len(runes), pre, post)
+}
+
+// HighlightFragments 截取包含关键词的片段并高亮，每段约 size 个字符，最多 count 段，片段之间不重叠
+func HighlightFragments(text string, keywords []string, pre, post string, size, count int) []string {
+	runes := []rune(text)
+	spans := findHighlightSpans(runes, keywords)
+	if size <= 0 || count <= 0 {
+		return nil
+	}
+
+	var fragments []string
+	covered := 0
+	for _, span := range spans {
+		if len(fragments) >= count {
+			break
+		}
+		if span.start < covered {
+			continue
+		}
+		// 命中位置居中，靠近开头或结尾时向另一侧延伸
+		start := span.start - (size-(span.end-span.start))/2
+		start = max(start, covered)
+		end := min(start+size, len(runes))
+		start = max(min(start, end-size), covered)
+		end = max(end, span.end)
+		fragments = append(fragments, strings.TrimSpace(renderHighlight(runes, spans, start, end, pre, post)))
+		covered = end
+	}
+	return fragments
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHighlightText(t *testing.T) {
+	got := HighlightText("Go 语言入门 <教程>", []string{"go", "入门"}, "<em>", "</em>")
+	want := "<em>Go</em> 语言<em>入门</em> &lt;教程&gt;"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if got := HighlightText("没有命中", []string{"go"}, "<em>", "</em>"); got != "" {
+		t.Fatalf("没有命中时应返回空字符串, got %q", got)
+	}
+}
+
+func TestHighlightTextPrefersLongestKeyword(t *testing.T) {
+	got := HighlightText("笔记本电脑", []string{"笔记本", "笔记本电脑"}, "[", "]")
+	if got != "[笔记本电脑]" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestHighlightFragments(t *testing.T) {
+	text := "一二三四五六七八九十关键词一二三四五六七八九十一二三四五六七八九十关键词结尾"
+	got := HighlightFragments(text, []string{"关键词"}, "[", "]", 9, 3)
+	want := []string{"八九十[关键词]一二三", "七八九十[关键词]结尾"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	if got := HighlightFragments(text, []string{"关键词"}, "[", "]", 9, 1); len(got) != 1 {
+		t.Fatalf("片段数应受 count 限制, got %q", got)
+	}
+	if got := HighlightFragments(text, []string{"不存在"}, "[", "]", 9, 3); got != nil {
+		t.Fatalf("没有命中时应返回空, got %q", got)
+	}
+}
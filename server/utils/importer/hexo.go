@@ -0,0 +1,257 @@
+package importer
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"server/utils"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultHexoPermalink Hexo 默认的固定链接格式
+const DefaultHexoPermalink = ":year/:month/:day/:title/"
+
+// hexoFrontMatter Hexo 和 Hugo 文章头部元数据中用到的字段
+type hexoFrontMatter struct {
+	Title       string           `yaml:"title"`
+	Slug        string           `yaml:"slug"`
+	Date        string           `yaml:"date"`
+	Updated     string           `yaml:"updated"` // Hexo
+	Lastmod     string           `yaml:"lastmod"` // Hugo
+	Tags        utils.StringList `yaml:"tags"`
+	Categories  interface{}      `yaml:"categories"`
+	Permalink   string           `yaml:"permalink"` // Hexo 自定义固定链接
+	URL         string           `yaml:"url"`       // Hugo 自定义地址
+	Aliases     utils.StringList `yaml:"aliases"`   // Hugo 别名地址
+	Draft       bool             `yaml:"draft"`
+	Published   *bool            `yaml:"published"` // Hexo 中 published: false 表示草稿
+	Layout      string           `yaml:"layout"`
+	Type        string           `yaml:"type"`
+	Description string           `yaml:"description"`
+	Excerpt     string           `yaml:"excerpt"`
+	Summary     string           `yaml:"summary"`
+}
+
+// hexoTimeLayouts 头部元数据中常见的时间格式
+var hexoTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseHexoTime 按常见格式解析时间，没有时区信息时使用 loc
+func parseHexoTime(value string, loc *time.Location) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range hexoTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// hexoCategories 解析分类：[A, B] 表示层级 A > B，[[A], [B]] 表示多个分类
+// 返回每个分类路径，路径最后一项为文章所属分类
+func hexoCategories(value interface{}) [][]string {
+	var paths [][]string
+	switch v := value.(type) {
+	case string:
+		if v = strings.TrimSpace(v); v != "" {
+			paths = append(paths, []string{v})
+		}
+	case []interface{}:
+		var chain []string
+		for _, item := range v {
+			switch item := item.(type) {
+			case []interface{}:
+				var sub []string
+				for _, name := range item {
+					if s := strings.TrimSpace(fmt.Sprint(name)); s != "" {
+						sub = append(sub, s)
+					}
+				}
+				if len(sub) > 0 {
+					paths = append(paths, sub)
+				}
+			case nil:
+			default:
+				if s := strings.TrimSpace(fmt.Sprint(item)); s != "" {
+					chain = append(chain, s)
+				}
+			}
+		}
+		if len(chain) > 0 {
+			paths = append([][]string{chain}, paths...)
+		}
+	}
+	return paths
+}
+
+var permalinkSlugPattern = regexp.MustCompile(`[\s/\\?#]+`)
+
+// expandPermalink 按 Hexo 固定链接格式生成旧地址，支持 :year :month :day :i_month :i_day :title :name :slug :category
+func expandPermalink(pattern, title, category string, date time.Time) string {
+	name := path.Base(title)
+	replacer := strings.NewReplacer(
+		":year", date.Format("2006"),
+		":month", date.Format("01"),
+		":i_month", date.Format("1"),
+		":day", date.Format("02"),
+		":i_day", date.Format("2"),
+		":title", title,
+		":name", name,
+		":slug", name,
+		":category", permalinkSlugPattern.ReplaceAllString(category, "-"),
+	)
+	return replacer.Replace(pattern)
+}
+
+// ParseHexoDir 解析 Hexo 的 source/_posts 或 Hugo 的 content 目录中的 Markdown 文章
+// permalink 为旧站的固定链接格式，为空时使用 DefaultHexoPermalink；loc 为没有时区信息的时间所用时区
+func ParseHexoDir(fsys fs.FS, permalink string, loc *time.Location) (*Site, error) {
+	if permalink == "" {
+		permalink = DefaultHexoPermalink
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	site := &Site{Source: "hexo"}
+	knownCategories := make(map[string]bool)
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		base := d.Name()
+		if d.IsDir() {
+			if name != "." && (strings.HasPrefix(base, ".") || base == "__MACOSX") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(base, ".") {
+			return nil
+		}
+		ext := strings.ToLower(path.Ext(base))
+		if ext != ".md" && ext != ".markdown" {
+			return nil
+		}
+		// Hugo 的 _index.md 是栏目列表页
+		if strings.HasPrefix(base, "_index.") {
+			site.skip(name, "栏目列表页不导入")
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			site.skip(name, "读取失败: "+err.Error())
+			return nil
+		}
+		if strings.HasPrefix(string(data), "+++") {
+			site.skip(name, "暂不支持 TOML 格式的头部元数据")
+			return nil
+		}
+		meta, body, err := utils.SplitFrontMatter(data)
+		if err != nil {
+			site.skip(name, err.Error())
+			return nil
+		}
+		var fm hexoFrontMatter
+		if err := yaml.Unmarshal(meta, &fm); err != nil {
+			site.skip(name, "解析头部元数据失败: "+err.Error())
+			return nil
+		}
+
+		// 文章标识：文件相对路径去掉扩展名，页面包（目录/index.md）使用目录名
+		title := strings.TrimSuffix(name, path.Ext(name))
+		if path.Base(title) == "index" && path.Dir(title) != "." {
+			title = path.Dir(title)
+		}
+
+		post := Post{
+			Source:  name,
+			Type:    TypePost,
+			Title:   strings.TrimSpace(fm.Title),
+			Slug:    fm.Slug,
+			Content: body,
+			Draft:   fm.Draft || (fm.Published != nil && !*fm.Published) || strings.HasPrefix(name, "_drafts/"),
+			Date:    parseHexoTime(fm.Date, loc),
+			Tags:    fm.Tags,
+		}
+		if fm.Layout == "page" || fm.Type == "page" {
+			post.Type = TypePage
+		}
+		if post.Title == "" {
+			post.Title = path.Base(title)
+		}
+		if post.Slug == "" {
+			post.Slug = path.Base(title)
+		}
+		for _, summary := range []string{fm.Summary, fm.Description, fm.Excerpt} {
+			if summary != "" {
+				post.Summary = summary
+				break
+			}
+		}
+		if post.Updated = parseHexoTime(fm.Updated, loc); post.Updated.IsZero() {
+			post.Updated = parseHexoTime(fm.Lastmod, loc)
+		}
+		if post.Date.IsZero() {
+			if info, err := d.Info(); err == nil {
+				post.Date = info.ModTime()
+			}
+		}
+
+		paths := hexoCategories(fm.Categories)
+		for i, chain := range paths {
+			for j, category := range chain {
+				if !knownCategories[category] {
+					knownCategories[category] = true
+					c := Category{Name: category}
+					if j > 0 {
+						c.Parent = chain[j-1]
+					}
+					site.Categories = append(site.Categories, c)
+				}
+			}
+			post.Categories = append(post.Categories, chain[len(chain)-1])
+			if i > 0 {
+				site.skip(name, "只保留第一个分类，忽略分类: "+strings.Join(chain, " > "))
+			}
+		}
+
+		// 旧地址：自定义地址优先，其次按固定链接格式生成，再加上 Hugo 别名
+		var links []string
+		switch {
+		case fm.Permalink != "":
+			links = append(links, fm.Permalink)
+		case fm.URL != "":
+			links = append(links, fm.URL)
+		default:
+			category := ""
+			if len(post.Categories) > 0 {
+				category = post.Categories[0]
+			}
+			links = append(links, expandPermalink(permalink, title, category, post.Date))
+		}
+		links = append(links, fm.Aliases...)
+		for _, link := range links {
+			if p := NormalizePath(link); p != "" && p != "/" && !containsString(post.Permalinks, p) {
+				post.Permalinks = append(post.Permalinks, p)
+			}
+		}
+
+		site.Posts = append(site.Posts, post)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return site, nil
+}
@@ -0,0 +1,68 @@
+package importer
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestParseHexoDir(t *testing.T) {
+	fsys := fstest.MapFS{
+		"hello-world.md":  {Data: []byte("---\ntitle: Hello\ndate: 2021-03-04 05:06:07\ntags: go\ncategories:\n  - 技术\n  - Go\n---\n正文\n")},
+		"custom.md":       {Data: []byte("---\ntitle: Custom\ndate: 2021-01-01\npermalink: /custom/path/\naliases: [/old/custom]\npublished: false\n---\nbody")},
+		"bundle/index.md": {Data: []byte("---\ntitle: Bundle\ndate: 2021-02-03\ncategories: [[A], [B]]\n---\nbundle")},
+		"hugo.md":         {Data: []byte("+++\ntitle = \"toml\"\n+++\n")},
+		"_index.md":       {Data: []byte("---\ntitle: 列表\n---\n")},
+	}
+
+	site, err := ParseHexoDir(fsys, "", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	posts := make(map[string]Post)
+	for _, post := range site.Posts {
+		posts[post.Slug] = post
+	}
+	if len(posts) != 3 {
+		t.Fatalf("期望3篇文章，实际 %d，跳过 %+v", len(posts), site.Skipped)
+	}
+
+	hello := posts["hello-world"]
+	if hello.Title != "Hello" || hello.Draft || !hello.Date.Equal(time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)) {
+		t.Fatalf("文章解析错误: %+v", hello)
+	}
+	if !reflect.DeepEqual(hello.Tags, []string{"go"}) || !reflect.DeepEqual(hello.Categories, []string{"Go"}) {
+		t.Fatalf("分类标签解析错误: %v %v", hello.Tags, hello.Categories)
+	}
+	if !reflect.DeepEqual(hello.Permalinks, []string{"/2021/03/04/hello-world"}) {
+		t.Fatalf("旧地址错误: %v", hello.Permalinks)
+	}
+
+	custom := posts["custom"]
+	if !custom.Draft || !reflect.DeepEqual(custom.Permalinks, []string{"/custom/path", "/old/custom"}) {
+		t.Fatalf("自定义地址解析错误: %+v", custom)
+	}
+
+	bundle := posts["bundle"]
+	if !reflect.DeepEqual(bundle.Categories, []string{"A", "B"}) || !reflect.DeepEqual(bundle.Permalinks, []string{"/2021/02/03/bundle"}) {
+		t.Fatalf("页面包解析错误: %+v", bundle)
+	}
+
+	wantCategories := []Category{{Name: "A"}, {Name: "B"}, {Name: "技术"}, {Name: "Go", Parent: "技术"}}
+	if !reflect.DeepEqual(site.Categories, wantCategories) {
+		t.Fatalf("分类层级错误: %+v", site.Categories)
+	}
+	// TOML 文件、栏目列表页、被忽略的第二个分类
+	if len(site.Skipped) != 3 {
+		t.Fatalf("跳过项错误: %+v", site.Skipped)
+	}
+}
+
+func TestExpandPermalink(t *testing.T) {
+	date := time.Date(2022, 7, 5, 0, 0, 0, 0, time.UTC)
+	got := expandPermalink("/:category/:i_month/:i_day/:name.html", "2022/post", "随笔 杂谈", date)
+	if got != "/随笔-杂谈/7/5/post.html" {
+		t.Fatalf("固定链接生成错误: %s", got)
+	}
+}
@@ -0,0 +1,94 @@
+// Package importer 解析其他博客系统的导出数据，转换为统一的中间结构，由 service 层写入数据库
+package importer
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+// 内容类型
+const (
+	TypePost = "post" // 文章
+	TypePage = "page" // 独立页面
+)
+
+// Site 从其他博客系统解析出的全部内容
+type Site struct {
+	Source     string     // 来源系统，如 wordpress、hexo
+	Posts      []Post     // 文章和页面
+	Categories []Category // 分类（含层级），文章引用的分类即使不在此列表中也会被创建
+	Skipped    []Skipped  // 解析阶段跳过的内容
+}
+
+// Post 文章或页面
+type Post struct {
+	Source     string // 来源标识（文件名或原ID），用于报告
+	Type       string // TypePost 或 TypePage
+	Title      string
+	Slug       string
+	Content    string
+	Summary    string
+	Draft      bool
+	Date       time.Time
+	Updated    time.Time
+	Categories []string  // 分类名称，第一个为主分类
+	Tags       []string  // 标签名称
+	Permalinks []string  // 旧地址（站内路径，可带查询参数），导入后重定向到新地址
+	Comments   []Comment // 评论
+}
+
+// Category 分类
+type Category struct {
+	Name   string
+	Slug   string
+	Parent string // 上级分类名称
+}
+
+// Comment 评论
+type Comment struct {
+	ID          string // 原评论ID
+	ParentID    string // 原上级评论ID，为空表示顶级评论
+	AuthorName  string
+	AuthorEmail string
+	Content     string
+	Date        time.Time
+	Approved    bool
+}
+
+// Skipped 跳过的内容及原因
+type Skipped struct {
+	Source string `json:"source"`
+	Reason string `json:"reason"`
+}
+
+// skip 记录跳过的内容
+func (s *Site) skip(source, reason string) {
+	s.Skipped = append(s.Skipped, Skipped{Source: source, Reason: reason})
+}
+
+// NormalizePath 把旧地址（完整URL或路径）规范化为站内路径：保留查询参数，去掉末尾斜杠和锚点
+func NormalizePath(link string) string {
+	link = strings.TrimSpace(link)
+	if link == "" {
+		return ""
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	p := u.EscapedPath()
+	if unescaped, err := url.PathUnescape(p); err == nil {
+		p = unescaped
+	}
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	if len(p) > 1 {
+		p = strings.TrimRight(p, "/")
+	}
+	if u.RawQuery != "" {
+		p += "?" + u.RawQuery
+	}
+	return p
+}
@@ -0,0 +1,235 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// contentNamespace WXR 中正文 content:encoded 的命名空间，摘要 excerpt:encoded 的命名空间随版本变化
+const contentNamespace = "http://purl.org/rss/1.0/modules/content/"
+
+// wxrDocument WordPress 导出文件（WXR）结构，元素按本地名匹配以兼容 1.0~1.2 各版本的命名空间
+type wxrDocument struct {
+	Channel struct {
+		Categories []struct {
+			Slug   string `xml:"category_nicename"`
+			Parent string `xml:"category_parent"`
+			Name   string `xml:"cat_name"`
+		} `xml:"category"`
+		Tags []struct {
+			Slug string `xml:"tag_slug"`
+			Name string `xml:"tag_name"`
+		} `xml:"tag"`
+		Items []wxrItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type wxrItem struct {
+	Title   string `xml:"title"`
+	Link    string `xml:"link"`
+	PubDate string `xml:"pubDate"`
+	GUID    string `xml:"guid"`
+	Encoded []struct {
+		XMLName xml.Name
+		Value   string `xml:",chardata"`
+	} `xml:"encoded"`
+	PostID      string `xml:"post_id"`
+	PostDate    string `xml:"post_date"`
+	PostDateGMT string `xml:"post_date_gmt"`
+	Modified    string `xml:"post_modified"`
+	ModifiedGMT string `xml:"post_modified_gmt"`
+	PostName    string `xml:"post_name"`
+	Status      string `xml:"status"`
+	PostType    string `xml:"post_type"`
+	Terms       []struct {
+		Domain   string `xml:"domain,attr"`
+		Nicename string `xml:"nicename,attr"`
+		Name     string `xml:",chardata"`
+	} `xml:"category"`
+	Comments []struct {
+		ID          string `xml:"comment_id"`
+		Parent      string `xml:"comment_parent"`
+		Author      string `xml:"comment_author"`
+		AuthorEmail string `xml:"comment_author_email"`
+		Date        string `xml:"comment_date"`
+		DateGMT     string `xml:"comment_date_gmt"`
+		Content     string `xml:"comment_content"`
+		Approved    string `xml:"comment_approved"`
+		Type        string `xml:"comment_type"`
+	} `xml:"comment"`
+}
+
+// wxrTime 解析 WXR 中的时间，优先使用 GMT 时间，其次按站点本地时间解析
+func wxrTime(gmt, local string, loc *time.Location) time.Time {
+	const layout = "2006-01-02 15:04:05"
+	if t, err := time.Parse(layout, gmt); err == nil && !strings.HasPrefix(gmt, "0000") {
+		return t
+	}
+	if t, err := time.ParseInLocation(layout, local, loc); err == nil && !strings.HasPrefix(local, "0000") {
+		return t
+	}
+	return time.Time{}
+}
+
+// ParseWXR 解析 WordPress 导出的 WXR 文件，loc 为站点时区（GMT 时间缺失时使用）
+func ParseWXR(r io.Reader, loc *time.Location) (*Site, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	var doc wxrDocument
+	decoder := xml.NewDecoder(r)
+	// WordPress 导出文件偶尔包含非法字符实体，宽松解析
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("解析WXR文件失败: %v", err)
+	}
+
+	site := &Site{Source: "wordpress"}
+
+	// 分类层级通过上级分类的 slug 关联
+	categoryNames := make(map[string]string)
+	for _, c := range doc.Channel.Categories {
+		categoryNames[c.Slug] = html.UnescapeString(c.Name)
+	}
+	for _, c := range doc.Channel.Categories {
+		site.Categories = append(site.Categories, Category{
+			Name:   categoryNames[c.Slug],
+			Slug:   c.Slug,
+			Parent: categoryNames[c.Parent],
+		})
+	}
+
+	for _, item := range doc.Channel.Items {
+		source := fmt.Sprintf("%s #%s %s", item.PostType, item.PostID, item.Title)
+
+		var postType string
+		switch item.PostType {
+		case "post":
+			postType = TypePost
+		case "page":
+			postType = TypePage
+		case "attachment":
+			site.skip(source, "附件不导入，正文中的图片地址保持不变")
+			continue
+		default:
+			site.skip(source, "不支持的内容类型: "+item.PostType)
+			continue
+		}
+
+		var draft bool
+		switch item.Status {
+		case "publish":
+		case "draft", "pending", "private", "future":
+			draft = true
+		case "trash":
+			site.skip(source, "已在回收站中")
+			continue
+		default:
+			site.skip(source, "不支持的状态: "+item.Status)
+			continue
+		}
+
+		post := Post{
+			Source:  source,
+			Type:    postType,
+			Title:   strings.TrimSpace(item.Title),
+			Slug:    item.PostName,
+			Draft:   draft,
+			Date:    wxrTime(item.PostDateGMT, item.PostDate, loc),
+			Updated: wxrTime(item.ModifiedGMT, item.Modified, loc),
+		}
+		if post.Date.IsZero() {
+			post.Date, _ = time.Parse(time.RFC1123Z, item.PubDate)
+		}
+		for _, encoded := range item.Encoded {
+			if encoded.XMLName.Space == contentNamespace {
+				post.Content = encoded.Value
+			} else if strings.Contains(encoded.XMLName.Space, "excerpt") {
+				post.Summary = strings.TrimSpace(encoded.Value)
+			}
+		}
+		// 草稿的 post_name 可能为空，使用原ID
+		if post.Slug == "" {
+			post.Slug = "wp-" + item.PostID
+		}
+		if unescaped, err := url.PathUnescape(post.Slug); err == nil {
+			post.Slug = unescaped
+		}
+
+		for _, term := range item.Terms {
+			name := strings.TrimSpace(html.UnescapeString(term.Name))
+			if name == "" {
+				continue
+			}
+			switch term.Domain {
+			case "category":
+				post.Categories = append(post.Categories, name)
+			case "post_tag":
+				post.Tags = append(post.Tags, name)
+			}
+		}
+
+		// 旧地址：固定链接，以及 ?p=ID / ?page_id=ID 形式的默认链接
+		for _, link := range []string{item.Link, item.GUID} {
+			if p := NormalizePath(link); p != "" && p != "/" && !containsString(post.Permalinks, p) {
+				post.Permalinks = append(post.Permalinks, p)
+			}
+		}
+		idParam := "p"
+		if postType == TypePage {
+			idParam = "page_id"
+		}
+		if p := fmt.Sprintf("/?%s=%s", idParam, item.PostID); item.PostID != "" && !containsString(post.Permalinks, p) {
+			post.Permalinks = append(post.Permalinks, p)
+		}
+
+		for _, c := range item.Comments {
+			commentSource := fmt.Sprintf("%s 的评论 #%s", source, c.ID)
+			if c.Type == "pingback" || c.Type == "trackback" {
+				site.skip(commentSource, "不导入 pingback/trackback")
+				continue
+			}
+			if c.Approved != "0" && c.Approved != "1" {
+				site.skip(commentSource, "垃圾评论或已删除的评论")
+				continue
+			}
+			parent := c.Parent
+			if parent == "0" {
+				parent = ""
+			}
+			post.Comments = append(post.Comments, Comment{
+				ID:          c.ID,
+				ParentID:    parent,
+				AuthorName:  strings.TrimSpace(c.Author),
+				AuthorEmail: strings.TrimSpace(c.AuthorEmail),
+				Content:     c.Content,
+				Date:        wxrTime(c.DateGMT, c.Date, loc),
+				Approved:    c.Approved == "1",
+			})
+		}
+		// 按时间排序，保证上级评论先于回复写入
+		sort.SliceStable(post.Comments, func(i, j int) bool {
+			return post.Comments[i].Date.Before(post.Comments[j].Date)
+		})
+
+		site.Posts = append(site.Posts, post)
+	}
+	return site, nil
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleWXR = `<?xml version="1.0" encoding="UTF-8" ?>
+<rss version="2.0"
+	xmlns:excerpt="http://wordpress.org/export/1.2/excerpt/"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<wp:category><wp:term_id>1</wp:term_id><wp:category_nicename>tech</wp:category_nicename><wp:category_parent></wp:category_parent><wp:cat_name><![CDATA[技术]]></wp:cat_name></wp:category>
+	<wp:category><wp:term_id>2</wp:term_id><wp:category_nicename>go</wp:category_nicename><wp:category_parent>tech</wp:category_parent><wp:cat_name><![CDATA[Go]]></wp:cat_name></wp:category>
+	<item>
+		<title>Hello World</title>
+		<link>https://old.example.com/2020/01/hello-world/</link>
+		<guid isPermaLink="false">https://old.example.com/?p=12</guid>
+		<content:encoded><![CDATA[<p>正文</p>]]></content:encoded>
+		<excerpt:encoded><![CDATA[摘要]]></excerpt:encoded>
+		<wp:post_id>12</wp:post_id>
+		<wp:post_date><![CDATA[2020-01-02 18:00:00]]></wp:post_date>
+		<wp:post_date_gmt><![CDATA[2020-01-02 10:00:00]]></wp:post_date_gmt>
+		<wp:post_name><![CDATA[hello-world]]></wp:post_name>
+		<wp:status><![CDATA[publish]]></wp:status>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<category domain="category" nicename="go"><![CDATA[Go]]></category>
+		<category domain="post_tag" nicename="intro"><![CDATA[入门]]></category>
+		<wp:comment>
+			<wp:comment_id>5</wp:comment_id>
+			<wp:comment_author><![CDATA[访客]]></wp:comment_author>
+			<wp:comment_author_email><![CDATA[guest@example.com]]></wp:comment_author_email>
+			<wp:comment_date_gmt><![CDATA[2020-01-03 00:00:00]]></wp:comment_date_gmt>
+			<wp:comment_content><![CDATA[写得好]]></wp:comment_content>
+			<wp:comment_approved><![CDATA[1]]></wp:comment_approved>
+			<wp:comment_type><![CDATA[comment]]></wp:comment_type>
+			<wp:comment_parent>0</wp:comment_parent>
+		</wp:comment>
+		<wp:comment>
+			<wp:comment_id>6</wp:comment_id>
+			<wp:comment_approved><![CDATA[spam]]></wp:comment_approved>
+		</wp:comment>
+	</item>
+	<item>
+		<title>logo</title>
+		<wp:post_id>13</wp:post_id>
+		<wp:status><![CDATA[inherit]]></wp:status>
+		<wp:post_type><![CDATA[attachment]]></wp:post_type>
+	</item>
+	<item>
+		<title>关于</title>
+		<link>https://old.example.com/about/</link>
+		<wp:post_id>2</wp:post_id>
+		<wp:post_name><![CDATA[about]]></wp:post_name>
+		<wp:status><![CDATA[draft]]></wp:status>
+		<wp:post_type><![CDATA[page]]></wp:post_type>
+	</item>
+</channel>
+</rss>`
+
+func TestParseWXR(t *testing.T) {
+	site, err := ParseWXR(strings.NewReader(sampleWXR), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantCategories := []Category{{Name: "技术", Slug: "tech"}, {Name: "Go", Slug: "go", Parent: "技术"}}
+	if !reflect.DeepEqual(site.Categories, wantCategories) {
+		t.Fatalf("分类解析错误: %+v", site.Categories)
+	}
+	if len(site.Posts) != 2 || len(site.Skipped) != 2 {
+		t.Fatalf("期望2篇内容、2项跳过，实际 %d、%+v", len(site.Posts), site.Skipped)
+	}
+
+	post := site.Posts[0]
+	if post.Type != TypePost || post.Slug != "hello-world" || post.Content != "<p>正文</p>" || post.Summary != "摘要" || post.Draft {
+		t.Fatalf("文章解析错误: %+v", post)
+	}
+	if !post.Date.Equal(time.Date(2020, 1, 2, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("发布时间应使用GMT时间: %v", post.Date)
+	}
+	if !reflect.DeepEqual(post.Categories, []string{"Go"}) || !reflect.DeepEqual(post.Tags, []string{"入门"}) {
+		t.Fatalf("分类标签解析错误: %v %v", post.Categories, post.Tags)
+	}
+	if !reflect.DeepEqual(post.Permalinks, []string{"/2020/01/hello-world", "/?p=12"}) {
+		t.Fatalf("旧地址解析错误: %v", post.Permalinks)
+	}
+	if len(post.Comments) != 1 || post.Comments[0].AuthorEmail != "guest@example.com" || !post.Comments[0].Approved {
+		t.Fatalf("评论解析错误: %+v", post.Comments)
+	}
+
+	page := site.Posts[1]
+	if page.Type != TypePage || !page.Draft || !reflect.DeepEqual(page.Permalinks, []string{"/about", "/?page_id=2"}) {
+		t.Fatalf("页面解析错误: %+v", page)
+	}
+}
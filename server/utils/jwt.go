@@ -11,9 +11,16 @@ import (
 type Claims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
+	Purpose  string `json:"purpose,omitempty"` // 令牌用途，为空表示普通访问令牌
 	jwt.RegisteredClaims
 }
 
+// 受限令牌的用途，这类令牌不能当作访问令牌使用
+const (
+	TokenPurposeTwoFactorLogin = "2fa_login" // 登录第二步：提交两步验证码
+	TokenPurposeTwoFactorSetup = "2fa_setup" // 角色要求两步验证但尚未开启：只能用于开启两步验证
+)
+
 // 生成访问令牌
 // 生成访问令牌
 func GenerateToken(userID uint, username string) (string, error) {
@@ -56,6 +63,27 @@ func GenerateRefreshToken(userID uint, username string) (string, error) {
 	return token.SignedString([]byte(global.Config.Jwt.RefreshTokenSecret))
 }
 
+// 生成受限用途的短期挑战令牌，返回令牌和令牌ID（用于服务端记录使用状态）
+func GenerateChallengeToken(userID uint, username, purpose string, ttl time.Duration) (string, string, error) {
+	tokenID := GenerateUUID()
+	claims := Claims{
+		UserID:   userID,
+		Username: username,
+		Purpose:  purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    global.Config.Jwt.Issuer,
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(global.Config.Jwt.AccessTokenSecret))
+	return signed, tokenID, err
+}
+
 // 解析令牌
 func ParseToken(tokenString string, isRefresh bool) (*Claims, error) {
 	// 根据令牌类型选择对应的密钥
@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FrontMatter 文章 Markdown 文件头部的 YAML 元数据，draft 对应文章状态（true 为草稿）
+type FrontMatter struct {
+	Title    string     `yaml:"title"`
+	Slug     string     `yaml:"slug,omitempty"`
+	Date     time.Time  `yaml:"date,omitempty"`
+	Updated  time.Time  `yaml:"updated,omitempty"`
+	Category string     `yaml:"category,omitempty"`
+	Tags     StringList `yaml:"tags,omitempty"`
+	Summary  string     `yaml:"summary,omitempty"`
+	Cover    string     `yaml:"cover,omitempty"`
+	Draft    bool       `yaml:"draft,omitempty"`
+}
+
+// StringList 字符串列表，解析时同时兼容 YAML 列表和单个字符串（如 tags: golang）
+type StringList []string
+
+// UnmarshalYAML 实现 yaml.Unmarshaler
+func (l *StringList) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		if value.Value == "" {
+			*l = nil
+			return nil
+		}
+		*l = StringList{value.Value}
+		return nil
+	case yaml.SequenceNode:
+		var list []string
+		if err := value.Decode(&list); err != nil {
+			return err
+		}
+		*l = list
+		return nil
+	default:
+		return errors.New("无法解析为字符串列表")
+	}
+}
+
+// RenderMarkdown 生成带 YAML 头部元数据的 Markdown 文本
+func RenderMarkdown(fm FrontMatter, body string) ([]byte, error) {
+	meta, err := yaml.Marshal(fm)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(meta)
+	buf.WriteString("---\n\n")
+	buf.WriteString(body)
+	if !strings.HasSuffix(body, "\n") {
+		buf.WriteString("\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// ParseMarkdown 拆分 Markdown 文本的 YAML 头部元数据和正文，没有头部元数据时整个文本作为正文
+func ParseMarkdown(data []byte) (fm FrontMatter, body string, err error) {
+	meta, body, err := SplitFrontMatter(data)
+	if err != nil || meta == nil {
+		return fm, body, err
+	}
+	if err = yaml.Unmarshal(meta, &fm); err != nil {
+		return fm, "", err
+	}
+	return fm, body, nil
+}
+
+// SplitFrontMatter 拆分 --- 包围的 YAML 头部元数据和正文，没有头部元数据时 meta 为 nil
+func SplitFrontMatter(data []byte) (meta []byte, body string, err error) {
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if !strings.HasPrefix(text, "---\n") {
+		return nil, text, nil
+	}
+
+	// 保留开头的换行，使空的头部元数据也能匹配结束标记
+	rest := text[len("---"):]
+	end := strings.Index(rest, "\n---")
+	if end < 0 {
+		return nil, "", errors.New("头部元数据缺少结束标记 ---")
+	}
+	meta = []byte(strings.TrimPrefix(rest[:end], "\n"))
+
+	body = rest[end+len("\n---"):]
+	if i := strings.IndexByte(body, '\n'); i >= 0 {
+		body = body[i+1:]
+	} else {
+		body = ""
+	}
+	return meta, strings.TrimLeft(body, "\n"), nil
+}
+
+var (
+	markdownImagePattern = regexp.MustCompile(`!\[[^\]]*\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)`)
+	htmlImagePattern     = regexp.MustCompile(`<img\b[^>]*?\bsrc\s*=\s*["']([^"']+)["']`)
+)
+
+// MarkdownImageRefs 提取正文中引用的图片地址（Markdown 语法和 HTML img 标签），按出现顺序去重
+func MarkdownImageRefs(body string) []string {
+	var refs []string
+	seen := make(map[string]bool)
+	for _, pattern := range []*regexp.Regexp{markdownImagePattern, htmlImagePattern} {
+		for _, match := range pattern.FindAllStringSubmatch(body, -1) {
+			if ref := match[1]; !seen[ref] {
+				seen[ref] = true
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs
+}
+
+// ReplaceMarkdownImageRefs 按映射替换正文中的图片地址，只替换图片语法中的地址
+func ReplaceMarkdownImageRefs(body string, replacements map[string]string) string {
+	replace := func(pattern *regexp.Regexp, s string) string {
+		return pattern.ReplaceAllStringFunc(s, func(match string) string {
+			sub := pattern.FindStringSubmatchIndex(match)
+			ref := match[sub[2]:sub[3]]
+			if target, ok := replacements[ref]; ok {
+				return match[:sub[2]] + target + match[sub[3]:]
+			}
+			return match
+		})
+	}
+	return replace(htmlImagePattern, replace(markdownImagePattern, body))
+}
+
+// IsLocalImageRef 判断图片地址是否为相对路径的本地文件（而非网址、站内绝对路径或 data URI）
+func IsLocalImageRef(ref string) bool {
+	lower := strings.ToLower(ref)
+	return ref != "" && !strings.HasPrefix(ref, "/") && !strings.HasPrefix(ref, "#") &&
+		!strings.HasPrefix(lower, "data:") && !strings.Contains(lower, "://") && !strings.HasPrefix(lower, "//")
+}
+
+var unsafeFileNameChars = regexp.MustCompile(`[\\/:*?"<>|\s]+`)
+
+// SafeFileName 把任意字符串转换为可用作文件名的形式
+func SafeFileName(name string) string {
+	name = strings.Trim(unsafeFileNameChars.ReplaceAllString(name, "-"), "-.")
+	if runes := []rune(name); len(runes) > 80 {
+		name = string(runes[:80])
+	}
+	return name
+}
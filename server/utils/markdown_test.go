@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMarkdownRoundTrip(t *testing.T) {
+	fm := FrontMatter{
+		Title:    "你好，世界",
+		Slug:     "hello-world",
+		Date:     time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC),
+		Category: "随笔",
+		Tags:     StringList{"go", "博客"},
+		Draft:    true,
+	}
+	data, err := RenderMarkdown(fm, "正文\n\n---\n\n分隔线之后")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, body, err := ParseMarkdown(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != fm.Title || got.Slug != fm.Slug || !got.Date.Equal(fm.Date) || !reflect.DeepEqual(got.Tags, fm.Tags) || !got.Draft {
+		t.Fatalf("头部元数据不一致: %+v", got)
+	}
+	if body != "正文\n\n---\n\n分隔线之后\n" {
+		t.Fatalf("正文不一致: %q", body)
+	}
+}
+
+func TestParseMarkdownScalarTags(t *testing.T) {
+	fm, body, err := ParseMarkdown([]byte("---\ntitle: x\ntags: golang\n---\nbody"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fm.Tags, StringList{"golang"}) || body != "body" {
+		t.Fatalf("解析结果不正确: %+v %q", fm, body)
+	}
+}
+
+func TestReplaceMarkdownImageRefs(t *testing.T) {
+	body := `![a](img/a.png) ![b](https://example.com/b.png "t") <img src="img/a.png" alt="a">`
+	refs := MarkdownImageRefs(body)
+	if !reflect.DeepEqual(refs, []string{"img/a.png", "https://example.com/b.png"}) {
+		t.Fatalf("图片引用提取错误: %v", refs)
+	}
+	if IsLocalImageRef(refs[1]) || !IsLocalImageRef(refs[0]) {
+		t.Fatal("本地图片判断错误")
+	}
+
+	got := ReplaceMarkdownImageRefs(body, map[string]string{"img/a.png": "/api/image/show/1"})
+	want := `![a](/api/image/show/1) ![b](https://example.com/b.png "t") <img src="/api/image/show/1" alt="a">`
+	if got != want {
+		t.Fatalf("替换结果错误:\n%s", got)
+	}
+}
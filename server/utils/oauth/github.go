@@ -0,0 +1,141 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"server/config"
+	"server/model/appType"
+	"strconv"
+	"strings"
+)
+
+// GitHub 默认地址
+const (
+	GitHubBaseURL    = "https://github.com"
+	GitHubAPIBaseURL = "https://api.github.com"
+)
+
+// GitHub GitHub OAuth 登录
+type GitHub struct {
+	Config     config.OAuthClient
+	HTTPClient *http.Client // 为空时使用 DefaultHTTPClient
+	BaseURL    string       // 授权和令牌接口地址，为空时使用 GitHubBaseURL
+	APIBaseURL string       // 用户信息接口地址，为空时使用 GitHubAPIBaseURL
+}
+
+// NewGitHub 创建GitHub登录提供方
+func NewGitHub(conf config.OAuthClient, client *http.Client) *GitHub {
+	return &GitHub{Config: conf, HTTPClient: client}
+}
+
+func (g *GitHub) Name() string {
+	return string(appType.LoginTypeGitHub)
+}
+
+func (g *GitHub) baseURL() string {
+	if g.BaseURL != "" {
+		return strings.TrimRight(g.BaseURL, "/")
+	}
+	return GitHubBaseURL
+}
+
+func (g *GitHub) apiBaseURL() string {
+	if g.APIBaseURL != "" {
+		return strings.TrimRight(g.APIBaseURL, "/")
+	}
+	return GitHubAPIBaseURL
+}
+
+// AuthURL 生成GitHub授权地址
+func (g *GitHub) AuthURL(req AuthRequest) (string, error) {
+	if !g.Config.Enable || g.Config.ClientID == "" {
+		return "", ErrProviderDisabled
+	}
+	scopes := g.Config.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"read:user", "user:email"}
+	}
+	query := url.Values{}
+	query.Set("client_id", g.Config.ClientID)
+	query.Set("redirect_uri", g.Config.RedirectURI)
+	query.Set("scope", strings.Join(scopes, " "))
+	query.Set("state", req.State)
+	if req.CodeVerifier != "" {
+		query.Set("code_challenge", CodeChallengeS256(req.CodeVerifier))
+		query.Set("code_challenge_method", "S256")
+	}
+	return g.baseURL() + "/login/oauth/authorize?" + query.Encode(), nil
+}
+
+// Exchange 用授权码换取 access_token，再查询用户资料和已验证邮箱
+func (g *GitHub) Exchange(ctx context.Context, code string, req AuthRequest) (*Identity, error) {
+	if !g.Config.Enable || g.Config.ClientID == "" {
+		return nil, ErrProviderDisabled
+	}
+	if code == "" {
+		return nil, errors.New("缺少授权码")
+	}
+
+	form := url.Values{}
+	form.Set("client_id", g.Config.ClientID)
+	form.Set("client_secret", g.Config.ClientSecret)
+	form.Set("code", code)
+	form.Set("redirect_uri", g.Config.RedirectURI)
+	if req.CodeVerifier != "" {
+		form.Set("code_verifier", req.CodeVerifier)
+	}
+	var token struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := postForm(ctx, g.HTTPClient, g.baseURL()+"/login/oauth/access_token", form, &token); err != nil {
+		return nil, err
+	}
+	if token.Error != "" || token.AccessToken == "" {
+		return nil, fmt.Errorf("获取GitHub access_token失败: %s %s", token.Error, token.ErrorDescription)
+	}
+
+	var user struct {
+		ID        int64  `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err := getJSONWithToken(ctx, g.HTTPClient, g.apiBaseURL()+"/user", token.AccessToken, &user); err != nil {
+		return nil, err
+	}
+	if user.ID == 0 {
+		return nil, errors.New("获取GitHub用户信息失败")
+	}
+
+	identity := &Identity{
+		Provider: g.Name(),
+		Subject:  strconv.FormatInt(user.ID, 10),
+		Nickname: user.Name,
+		Avatar:   user.AvatarURL,
+	}
+	if identity.Nickname == "" {
+		identity.Nickname = user.Login
+	}
+
+	// /user 返回的公开邮箱未必经过验证，以 /user/emails 中的主邮箱验证状态为准
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSONWithToken(ctx, g.HTTPClient, g.apiBaseURL()+"/user/emails", token.AccessToken, &emails); err == nil {
+		for _, e := range emails {
+			if e.Primary {
+				identity.Email = e.Email
+				identity.EmailVerified = e.Verified
+				break
+			}
+		}
+	}
+	return identity, nil
+}
@@ -0,0 +1,91 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"server/config"
+	"testing"
+)
+
+func TestGitHubExchange(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Method != http.MethodPost || r.Form.Get("code") != "good-code" || r.Form.Get("code_verifier") != "v" {
+			json.NewEncoder(w).Encode(map[string]string{"error": "bad_verification_code"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "gho_1", "token_type": "bearer"})
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gho_1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": 1001, "login": "octocat", "name": "", "avatar_url": "https://avatars/u/1001"})
+	})
+	mux.HandleFunc("/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"email": "old@example.com", "primary": false, "verified": true},
+			{"email": "octo@example.com", "primary": true, "verified": true},
+		})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	g := NewGitHub(config.OAuthClient{Enable: true, ClientID: "id", ClientSecret: "secret"}, server.Client())
+	g.BaseURL, g.APIBaseURL = server.URL, server.URL
+
+	identity, err := g.Exchange(context.Background(), "good-code", AuthRequest{CodeVerifier: "v"})
+	if err != nil {
+		t.Fatalf("换取账户信息失败: %v", err)
+	}
+	if identity.Subject != "1001" || identity.Nickname != "octocat" || identity.Avatar != "https://avatars/u/1001" {
+		t.Errorf("账户资料错误: %+v", identity)
+	}
+	if identity.Email != "octo@example.com" || !identity.EmailVerified {
+		t.Errorf("应使用已验证的主邮箱: %+v", identity)
+	}
+
+	if _, err := g.Exchange(context.Background(), "bad-code", AuthRequest{CodeVerifier: "v"}); err == nil {
+		t.Errorf("错误的授权码应当返回错误")
+	}
+}
+
+func TestBuildRegistry(t *testing.T) {
+	conf := config.OAuth{
+		GitHub: config.OAuthClient{Enable: true, ClientID: "id"},
+		OIDC: []config.OIDCProvider{
+			{OAuthClient: config.OAuthClient{Enable: true}, Name: "gitlab", Issuer: "https://gitlab.example.com"},
+			{OAuthClient: config.OAuthClient{Enable: false}, Name: "disabled"},
+		},
+	}
+	registry, err := BuildRegistry(config.QQ{}, conf, nil)
+	if err != nil {
+		t.Fatalf("构建提供方集合失败: %v", err)
+	}
+	if names := registry.Names(); len(names) != 2 || names[0] != "github" || names[1] != "gitlab" {
+		t.Errorf("已启用的提供方错误: %v", names)
+	}
+	if _, ok := registry.Get("disabled"); ok {
+		t.Errorf("未启用的提供方不应注册")
+	}
+
+	conf.OIDC = append(conf.OIDC, config.OIDCProvider{OAuthClient: config.OAuthClient{Enable: true}, Name: "github"})
+	if _, err := BuildRegistry(config.QQ{}, conf, nil); err == nil {
+		t.Errorf("与内置提供方重名时应当返回错误")
+	}
+}
+
+func TestBuildRegistryRejectsInvalidNames(t *testing.T) {
+	for _, name := range []string{"", "GitLab", "git lab", "../admin", "a-very-long-provider-name"} {
+		conf := config.OAuth{OIDC: []config.OIDCProvider{
+			{OAuthClient: config.OAuthClient{Enable: true}, Name: name, Issuer: "https://idp.example.com"},
+		}}
+		if _, err := BuildRegistry(config.QQ{}, conf, nil); err == nil {
+			t.Errorf("提供方名称 %q 应当被拒绝", name)
+		}
+	}
+}
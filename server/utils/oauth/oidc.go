@@ -0,0 +1,351 @@
+package oauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"server/config"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// discoveryTTL 发现文档和公钥的缓存时间
+const discoveryTTL = time.Hour
+
+// OIDC 通用 OpenID Connect 登录，端点和签名公钥通过发现文档获取
+type OIDC struct {
+	Config     config.OIDCProvider
+	HTTPClient *http.Client // 为空时使用 DefaultHTTPClient
+
+	mu          sync.Mutex
+	discovery   *oidcDiscovery
+	keys        map[string]crypto.PublicKey
+	refreshedAt time.Time
+}
+
+// oidcDiscovery OpenID Connect 发现文档中用到的字段
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcClaims ID Token 中用到的声明
+type oidcClaims struct {
+	Nonce             string      `json:"nonce"`
+	Email             string      `json:"email"`
+	EmailVerified     interface{} `json:"email_verified"` // 部分提供方返回字符串 "true"
+	Name              string      `json:"name"`
+	PreferredUsername string      `json:"preferred_username"`
+	Picture           string      `json:"picture"`
+	jwt.RegisteredClaims
+}
+
+// NewOIDC 创建通用 OpenID Connect 登录提供方
+func NewOIDC(conf config.OIDCProvider, client *http.Client) *OIDC {
+	return &OIDC{Config: conf, HTTPClient: client}
+}
+
+func (o *OIDC) Name() string {
+	return o.Config.Name
+}
+
+func (o *OIDC) enabled() bool {
+	return o.Config.Enable && o.Config.ClientID != "" && o.Config.Issuer != ""
+}
+
+// metadata 获取发现文档和签名公钥，带缓存；forceKeys 为 true 时强制刷新公钥（用于签名密钥轮换）
+func (o *OIDC) metadata(ctx context.Context, forceKeys bool) (*oidcDiscovery, map[string]crypto.PublicKey, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.discovery != nil && !forceKeys && time.Since(o.refreshedAt) < discoveryTTL {
+		return o.discovery, o.keys, nil
+	}
+
+	issuer := strings.TrimRight(o.Config.Issuer, "/")
+	var doc oidcDiscovery
+	if err := getJSON(ctx, o.HTTPClient, issuer+"/.well-known/openid-configuration", &doc); err != nil {
+		return nil, nil, err
+	}
+	if strings.TrimRight(doc.Issuer, "/") != issuer {
+		return nil, nil, fmt.Errorf("发现文档的签发方 %s 与配置 %s 不一致", doc.Issuer, o.Config.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, nil, errors.New("发现文档缺少必要的端点")
+	}
+
+	keys, err := o.fetchKeys(ctx, doc.JWKSURI)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	o.discovery = &doc
+	o.keys = keys
+	o.refreshedAt = time.Now()
+	return o.discovery, o.keys, nil
+}
+
+// jsonWebKey JWKS 中的公钥
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetchKeys 下载并解析签名公钥，支持 RSA 和 EC(P-256/P-384)
+func (o *OIDC) fetchKeys(ctx context.Context, jwksURI string) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := getJSON(ctx, o.HTTPClient, jwksURI, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS 中没有可用的签名公钥")
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	decode := base64.RawURLEncoding.DecodeString
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("不支持的曲线: %s", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("不支持的密钥类型: %s", k.Kty)
+	}
+}
+
+// AuthURL 生成授权地址，携带 state、nonce 和 PKCE code_challenge
+func (o *OIDC) AuthURL(req AuthRequest) (string, error) {
+	if !o.enabled() {
+		return "", ErrProviderDisabled
+	}
+	doc, _, err := o.metadata(context.Background(), false)
+	if err != nil {
+		return "", err
+	}
+
+	scopes := o.Config.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "profile", "email"}
+	}
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", o.Config.ClientID)
+	query.Set("redirect_uri", o.Config.RedirectURI)
+	query.Set("scope", strings.Join(scopes, " "))
+	query.Set("state", req.State)
+	if req.Nonce != "" {
+		query.Set("nonce", req.Nonce)
+	}
+	if req.CodeVerifier != "" {
+		query.Set("code_challenge", CodeChallengeS256(req.CodeVerifier))
+		query.Set("code_challenge_method", "S256")
+	}
+
+	sep := "?"
+	if strings.Contains(doc.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return doc.AuthorizationEndpoint + sep + query.Encode(), nil
+}
+
+// Exchange 用授权码换取令牌，校验 ID Token 后返回账户信息
+func (o *OIDC) Exchange(ctx context.Context, code string, req AuthRequest) (*Identity, error) {
+	if !o.enabled() {
+		return nil, ErrProviderDisabled
+	}
+	if code == "" {
+		return nil, errors.New("缺少授权码")
+	}
+	doc, _, err := o.metadata(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", o.Config.RedirectURI)
+	form.Set("client_id", o.Config.ClientID)
+	form.Set("client_secret", o.Config.ClientSecret)
+	if req.CodeVerifier != "" {
+		form.Set("code_verifier", req.CodeVerifier)
+	}
+	var token struct {
+		AccessToken      string `json:"access_token"`
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := postForm(ctx, o.HTTPClient, doc.TokenEndpoint, form, &token); err != nil {
+		return nil, err
+	}
+	if token.Error != "" || token.IDToken == "" {
+		return nil, fmt.Errorf("获取 ID Token 失败: %s %s", token.Error, token.ErrorDescription)
+	}
+
+	claims, err := o.verifyIDToken(ctx, token.IDToken, req.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{
+		Provider:      o.Name(),
+		Subject:       claims.Subject,
+		Nickname:      claims.Name,
+		Avatar:        claims.Picture,
+		Email:         claims.Email,
+		EmailVerified: isTrue(claims.EmailVerified),
+	}
+
+	// ID Token 未携带邮箱时从 userinfo 端点补充
+	if identity.Email == "" && doc.UserinfoEndpoint != "" && token.AccessToken != "" {
+		var info oidcClaims
+		if err := getJSONWithToken(ctx, o.HTTPClient, doc.UserinfoEndpoint, token.AccessToken, &info); err == nil && info.Subject == claims.Subject {
+			identity.Email = info.Email
+			identity.EmailVerified = isTrue(info.EmailVerified)
+			if identity.Nickname == "" {
+				identity.Nickname = info.Name
+			}
+			if identity.Avatar == "" {
+				identity.Avatar = info.Picture
+			}
+			if claims.PreferredUsername == "" {
+				claims.PreferredUsername = info.PreferredUsername
+			}
+		}
+	}
+	if identity.Nickname == "" {
+		identity.Nickname = claims.PreferredUsername
+	}
+	return identity, nil
+}
+
+// verifyIDToken 校验 ID Token 的签名、签发方、受众、有效期和 nonce
+func (o *OIDC) verifyIDToken(ctx context.Context, raw, nonce string) (*oidcClaims, error) {
+	parse := func(keys map[string]crypto.PublicKey) (*oidcClaims, error) {
+		claims := new(oidcClaims)
+		_, err := jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			if key, ok := keys[kid]; ok {
+				return key, nil
+			}
+			// 只有一个公钥时允许令牌不带 kid
+			if kid == "" && len(keys) == 1 {
+				for _, key := range keys {
+					return key, nil
+				}
+			}
+			return nil, errUnknownKey
+		}, jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384"}))
+		return claims, err
+	}
+
+	_, keys, err := o.metadata(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := parse(keys)
+	if err != nil && errors.Is(err, errUnknownKey) {
+		// 提供方可能已轮换密钥，刷新后重试一次
+		if _, keys, err = o.metadata(ctx, true); err != nil {
+			return nil, err
+		}
+		claims, err = parse(keys)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ID Token 校验失败: %w", err)
+	}
+
+	issuer := strings.TrimRight(o.Config.Issuer, "/")
+	if strings.TrimRight(claims.Issuer, "/") != issuer {
+		return nil, errors.New("ID Token 签发方不匹配")
+	}
+	if !claims.VerifyAudience(o.Config.ClientID, true) {
+		return nil, errors.New("ID Token 受众不匹配")
+	}
+	if claims.ExpiresAt == nil {
+		return nil, errors.New("ID Token 缺少过期时间")
+	}
+	if nonce != "" && claims.Nonce != nonce {
+		return nil, errors.New("ID Token nonce 不匹配")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("ID Token 缺少 sub")
+	}
+	return claims, nil
+}
+
+var errUnknownKey = errors.New("未知的签名公钥")
+
+// isTrue 兼容布尔值和字符串形式的 email_verified
+func isTrue(v interface{}) bool {
+	switch value := v.(type) {
+	case bool:
+		return value
+	case string:
+		return strings.EqualFold(value, "true")
+	default:
+		return false
+	}
+}
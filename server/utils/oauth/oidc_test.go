@@ -0,0 +1,195 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"server/config"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// mockIdP 本地模拟的 OpenID Connect 提供方
+type mockIdP struct {
+	t        *testing.T
+	server   *httptest.Server
+	key      *rsa.PrivateKey
+	nonce    string // 令牌中签发的 nonce
+	verifier string // 期望收到的 code_verifier
+	claims   jwt.MapClaims
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("生成密钥失败: %v", err)
+	}
+	idp := &mockIdP{t: t, key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"userinfo_endpoint":      idp.server.URL + "/userinfo",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "key-1",
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("code") != "good-code" || r.Form.Get("client_id") != "client-1" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		if r.Form.Get("code_verifier") != idp.verifier {
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+			return
+		}
+		claims := jwt.MapClaims{
+			"iss":   idp.server.URL,
+			"sub":   "user-42",
+			"aud":   "client-1",
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"iat":   time.Now().Unix(),
+			"nonce": idp.nonce,
+		}
+		for k, v := range idp.claims {
+			claims[k] = v
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "key-1"
+		signed, _ := token.SignedString(key)
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "access-1", "id_token": signed, "token_type": "Bearer"})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"sub": "user-42", "email": "dev@example.com", "email_verified": true, "name": "Dev"})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *mockIdP) provider() *OIDC {
+	return NewOIDC(config.OIDCProvider{
+		OAuthClient: config.OAuthClient{Enable: true, ClientID: "client-1", ClientSecret: "secret", RedirectURI: "http://localhost/cb"},
+		Name:        "mock",
+		Issuer:      idp.server.URL,
+	}, idp.server.Client())
+}
+
+func TestOIDCAuthURL(t *testing.T) {
+	idp := newMockIdP(t)
+	raw, err := idp.provider().AuthURL(AuthRequest{State: "st", Nonce: "n-1", CodeVerifier: "verifier"})
+	if err != nil {
+		t.Fatalf("生成授权地址失败: %v", err)
+	}
+	parsed, _ := url.Parse(raw)
+	query := parsed.Query()
+	if !strings.HasPrefix(raw, idp.server.URL+"/authorize?") {
+		t.Errorf("授权端点应来自发现文档: %s", raw)
+	}
+	if query.Get("state") != "st" || query.Get("nonce") != "n-1" || query.Get("code_challenge_method") != "S256" {
+		t.Errorf("授权参数错误: %s", raw)
+	}
+	if query.Get("code_challenge") != CodeChallengeS256("verifier") {
+		t.Errorf("code_challenge 错误: %s", query.Get("code_challenge"))
+	}
+}
+
+func TestOIDCExchange(t *testing.T) {
+	idp := newMockIdP(t)
+	idp.nonce, idp.verifier = "n-1", "verifier"
+	idp.claims = jwt.MapClaims{"email": "dev@example.com", "email_verified": true, "name": "Dev", "picture": "http://img/1.png"}
+
+	identity, err := idp.provider().Exchange(context.Background(), "good-code", AuthRequest{State: "st", Nonce: "n-1", CodeVerifier: "verifier"})
+	if err != nil {
+		t.Fatalf("换取账户信息失败: %v", err)
+	}
+	if identity.Provider != "mock" || identity.Subject != "user-42" {
+		t.Errorf("账户标识错误: %+v", identity)
+	}
+	if identity.Email != "dev@example.com" || !identity.EmailVerified || identity.Avatar != "http://img/1.png" {
+		t.Errorf("账户资料错误: %+v", identity)
+	}
+}
+
+func TestOIDCExchangeUserinfoFallback(t *testing.T) {
+	idp := newMockIdP(t)
+	idp.nonce, idp.verifier = "n-1", "v"
+
+	identity, err := idp.provider().Exchange(context.Background(), "good-code", AuthRequest{Nonce: "n-1", CodeVerifier: "v"})
+	if err != nil {
+		t.Fatalf("换取账户信息失败: %v", err)
+	}
+	if identity.Email != "dev@example.com" || !identity.EmailVerified || identity.Nickname != "Dev" {
+		t.Errorf("应从 userinfo 补充邮箱和昵称: %+v", identity)
+	}
+}
+
+func TestOIDCExchangeRejects(t *testing.T) {
+	idp := newMockIdP(t)
+	idp.verifier = "v"
+	p := idp.provider()
+
+	idp.nonce = "other-nonce"
+	if _, err := p.Exchange(context.Background(), "good-code", AuthRequest{Nonce: "n-1", CodeVerifier: "v"}); err == nil || !strings.Contains(err.Error(), "nonce") {
+		t.Errorf("nonce 不匹配时应当拒绝，实际: %v", err)
+	}
+
+	idp.nonce = "n-1"
+	if _, err := p.Exchange(context.Background(), "good-code", AuthRequest{Nonce: "n-1", CodeVerifier: "wrong"}); err == nil {
+		t.Errorf("PKCE 校验失败时应当返回错误")
+	}
+
+	idp.claims = jwt.MapClaims{"aud": "other-client"}
+	if _, err := p.Exchange(context.Background(), "good-code", AuthRequest{Nonce: "n-1", CodeVerifier: "v"}); err == nil || !strings.Contains(err.Error(), "受众") {
+		t.Errorf("受众不匹配时应当拒绝，实际: %v", err)
+	}
+
+	// 使用其他密钥签名的令牌
+	idp.claims = nil
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	if _, err := p.verifyIDToken(context.Background(), signWith(t, other, idp.server.URL), ""); err == nil {
+		t.Errorf("签名无效的令牌应当被拒绝")
+	}
+}
+
+func signWith(t *testing.T, key *rsa.PrivateKey, issuer string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss": issuer, "sub": "x", "aud": "client-1", "exp": time.Now().Add(time.Minute).Unix(),
+	})
+	token.Header["kid"] = "key-1"
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	return signed
+}
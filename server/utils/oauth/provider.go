@@ -0,0 +1,161 @@
+// Package oauth 第三方登录提供方的统一封装
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultHTTPClient 未注入HTTP客户端时使用的默认客户端
+var DefaultHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// ErrProviderDisabled 提供方未启用或未配置
+var ErrProviderDisabled = errors.New("该登录方式未启用")
+
+// AuthRequest 一次授权流程的参数，由调用方生成并在回调时原样传回
+type AuthRequest struct {
+	State        string // 防CSRF随机串
+	Nonce        string // OIDC 防重放随机串
+	CodeVerifier string // PKCE code_verifier，为空表示不使用 PKCE
+}
+
+// Identity 第三方账户信息
+type Identity struct {
+	Provider      string `json:"provider"`       // 提供方名称
+	Subject       string `json:"subject"`        // 提供方内的唯一标识（QQ 的 openid、OIDC 的 sub）
+	UnionID       string `json:"union_id"`       // 跨应用统一标识（如有）
+	Nickname      string `json:"nickname"`       // 昵称
+	Avatar        string `json:"avatar"`         // 头像地址
+	Email         string `json:"email"`          // 邮箱（如有）
+	EmailVerified bool   `json:"email_verified"` // 提供方是否确认过该邮箱
+}
+
+// Provider 第三方登录提供方
+type Provider interface {
+	// Name 提供方名称，同时作为 database.User 的 LoginMethod
+	Name() string
+	// AuthURL 生成跳转到提供方的授权地址
+	AuthURL(req AuthRequest) (string, error)
+	// Exchange 用回调得到的授权码换取第三方账户信息
+	Exchange(ctx context.Context, code string, req AuthRequest) (*Identity, error)
+}
+
+// getJSON 发送GET请求并把JSON响应解析到 out
+func getJSON(ctx context.Context, client *http.Client, rawURL string, out interface{}) error {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	return doJSON(client, httpReq, out)
+}
+
+// doJSON 执行请求并把JSON响应解析到 out
+func doJSON(client *http.Client, httpReq *http.Request, out interface{}) error {
+	if client == nil {
+		client = DefaultHTTPClient
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("请求 %s 失败: HTTP %d %s", httpReq.URL.Path, resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("解析 %s 响应失败: %w", httpReq.URL.Path, err)
+	}
+	return nil
+}
+
+// CodeChallengeS256 根据 PKCE code_verifier 计算 S256 code_challenge（RFC 7636）
+func CodeChallengeS256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// postForm 以表单方式发送POST请求并把JSON响应解析到 out
+func postForm(ctx context.Context, client *http.Client, rawURL string, form url.Values, out interface{}) error {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpReq.Header.Set("Accept", "application/json")
+	return doJSON(client, httpReq, out)
+}
+
+// getJSONWithToken 携带 Bearer 令牌发送GET请求
+func getJSONWithToken(ctx context.Context, client *http.Client, rawURL, accessToken string, out interface{}) error {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+accessToken)
+	return doJSON(client, httpReq, out)
+}
+
+// maxAvatarSize 导入头像的最大字节数
+const maxAvatarSize = 2 << 20
+
+// FetchAvatar 下载第三方头像，返回图片内容和扩展名
+func FetchAvatar(ctx context.Context, client *http.Client, avatarURL string) ([]byte, string, error) {
+	if client == nil {
+		client = DefaultHTTPClient
+	}
+	parsed, err := url.Parse(avatarURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, "", errors.New("头像地址无效")
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, avatarURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("下载头像失败: HTTP %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAvatarSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > maxAvatarSize {
+		return nil, "", errors.New("头像文件过大")
+	}
+
+	// 以实际内容判断类型，不信任响应头
+	switch http.DetectContentType(data) {
+	case "image/jpeg":
+		return data, ".jpg", nil
+	case "image/png":
+		return data, ".png", nil
+	case "image/gif":
+		return data, ".gif", nil
+	case "image/webp":
+		return data, ".webp", nil
+	default:
+		return nil, "", errors.New("头像不是支持的图片格式")
+	}
+}
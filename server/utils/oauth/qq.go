@@ -0,0 +1,139 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"server/config"
+	"server/model/appType"
+	"strings"
+)
+
+// QQBaseURL QQ互联接口地址
+const QQBaseURL = "https://graph.qq.com"
+
+// QQ QQ互联登录，详情请见 https://wiki.connect.qq.com/
+type QQ struct {
+	Config     config.QQ
+	HTTPClient *http.Client // 为空时使用 DefaultHTTPClient
+	BaseURL    string       // 为空时使用 QQBaseURL，测试时可指向本地桩服务
+}
+
+// NewQQ 创建QQ登录提供方
+func NewQQ(conf config.QQ, client *http.Client) *QQ {
+	return &QQ{Config: conf, HTTPClient: client}
+}
+
+func (q *QQ) Name() string {
+	return string(appType.LoginTypeQQ)
+}
+
+func (q *QQ) baseURL() string {
+	if q.BaseURL != "" {
+		return strings.TrimRight(q.BaseURL, "/")
+	}
+	return QQBaseURL
+}
+
+// AuthURL 生成QQ授权地址
+func (q *QQ) AuthURL(req AuthRequest) (string, error) {
+	if !q.Config.Enable || q.Config.AppID == "" {
+		return "", ErrProviderDisabled
+	}
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", q.Config.AppID)
+	query.Set("redirect_uri", q.Config.RedirectURI)
+	query.Set("state", req.State)
+	query.Set("scope", "get_user_info")
+	return q.baseURL() + "/oauth2.0/authorize?" + query.Encode(), nil
+}
+
+// qqError QQ互联接口的错误字段
+type qqError struct {
+	Error            int    `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Exchange 用授权码换取 access_token，再查询 openid 和用户资料
+func (q *QQ) Exchange(ctx context.Context, code string, req AuthRequest) (*Identity, error) {
+	if !q.Config.Enable || q.Config.AppID == "" {
+		return nil, ErrProviderDisabled
+	}
+	if code == "" {
+		return nil, errors.New("缺少授权码")
+	}
+
+	// 1. 授权码换取 access_token
+	var token struct {
+		qqError
+		AccessToken string `json:"access_token"`
+	}
+	query := url.Values{}
+	query.Set("grant_type", "authorization_code")
+	query.Set("client_id", q.Config.AppID)
+	query.Set("client_secret", q.Config.AppKey)
+	query.Set("code", code)
+	query.Set("redirect_uri", q.Config.RedirectURI)
+	query.Set("fmt", "json")
+	if err := getJSON(ctx, q.HTTPClient, q.baseURL()+"/oauth2.0/token?"+query.Encode(), &token); err != nil {
+		return nil, err
+	}
+	if token.Error != 0 || token.AccessToken == "" {
+		return nil, fmt.Errorf("获取QQ access_token失败: %d %s", token.Error, token.ErrorDescription)
+	}
+
+	// 2. 查询 openid（同时申请 unionid）
+	var me struct {
+		qqError
+		ClientID string `json:"client_id"`
+		OpenID   string `json:"openid"`
+		UnionID  string `json:"unionid"`
+	}
+	query = url.Values{}
+	query.Set("access_token", token.AccessToken)
+	query.Set("unionid", "1")
+	query.Set("fmt", "json")
+	if err := getJSON(ctx, q.HTTPClient, q.baseURL()+"/oauth2.0/me?"+query.Encode(), &me); err != nil {
+		return nil, err
+	}
+	if me.Error != 0 || me.OpenID == "" {
+		return nil, fmt.Errorf("获取QQ openid失败: %d %s", me.Error, me.ErrorDescription)
+	}
+	if me.ClientID != "" && me.ClientID != q.Config.AppID {
+		return nil, errors.New("QQ access_token 不属于当前应用")
+	}
+
+	// 3. 查询用户资料
+	var info struct {
+		Ret          int    `json:"ret"`
+		Msg          string `json:"msg"`
+		Nickname     string `json:"nickname"`
+		FigureURLQQ2 string `json:"figureurl_qq_2"`
+		FigureURLQQ1 string `json:"figureurl_qq_1"`
+	}
+	query = url.Values{}
+	query.Set("access_token", token.AccessToken)
+	query.Set("oauth_consumer_key", q.Config.AppID)
+	query.Set("openid", me.OpenID)
+	if err := getJSON(ctx, q.HTTPClient, q.baseURL()+"/user/get_user_info?"+query.Encode(), &info); err != nil {
+		return nil, err
+	}
+	if info.Ret != 0 {
+		return nil, fmt.Errorf("获取QQ用户信息失败: %d %s", info.Ret, info.Msg)
+	}
+
+	avatar := info.FigureURLQQ2
+	if avatar == "" {
+		avatar = info.FigureURLQQ1
+	}
+	return &Identity{
+		Provider: q.Name(),
+		Subject:  me.OpenID,
+		UnionID:  me.UnionID,
+		Nickname: info.Nickname,
+		Avatar:   avatar,
+	}, nil
+}
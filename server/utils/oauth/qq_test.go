@@ -0,0 +1,103 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"server/config"
+	"strings"
+	"testing"
+)
+
+// newQQStub 启动模拟QQ互联接口的本地服务
+func newQQStub(t *testing.T, tokenResp, meResp, infoResp map[string]interface{}) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	writeJSON := func(w http.ResponseWriter, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	}
+	mux.HandleFunc("/oauth2.0/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("code") != "good-code" {
+			writeJSON(w, map[string]interface{}{"error": 100019, "error_description": "code to access token error"})
+			return
+		}
+		writeJSON(w, tokenResp)
+	})
+	mux.HandleFunc("/oauth2.0/me", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("access_token") != "token-123" {
+			t.Errorf("me 接口收到错误的 access_token: %s", r.URL.Query().Get("access_token"))
+		}
+		writeJSON(w, meResp)
+	})
+	mux.HandleFunc("/user/get_user_info", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("openid") != "openid-abc" || r.URL.Query().Get("oauth_consumer_key") != "app-1" {
+			t.Errorf("get_user_info 接口参数错误: %s", r.URL.RawQuery)
+		}
+		writeJSON(w, infoResp)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestQQ(server *httptest.Server) *QQ {
+	q := NewQQ(config.QQ{Enable: true, AppID: "app-1", AppKey: "secret", RedirectURI: "http://localhost/callback"}, server.Client())
+	q.BaseURL = server.URL
+	return q
+}
+
+func TestQQExchange(t *testing.T) {
+	server := newQQStub(t,
+		map[string]interface{}{"access_token": "token-123", "expires_in": 7776000},
+		map[string]interface{}{"client_id": "app-1", "openid": "openid-abc", "unionid": "union-xyz"},
+		map[string]interface{}{"ret": 0, "nickname": "小明", "figureurl_qq_2": "http://q.qlogo.cn/100", "figureurl_qq_1": "http://q.qlogo.cn/40"},
+	)
+
+	identity, err := newTestQQ(server).Exchange(context.Background(), "good-code", AuthRequest{State: "s"})
+	if err != nil {
+		t.Fatalf("换取用户信息失败: %v", err)
+	}
+	if identity.Provider != "qq" || identity.Subject != "openid-abc" || identity.UnionID != "union-xyz" {
+		t.Errorf("账户标识错误: %+v", identity)
+	}
+	if identity.Nickname != "小明" || identity.Avatar != "http://q.qlogo.cn/100" {
+		t.Errorf("用户资料错误: %+v", identity)
+	}
+}
+
+func TestQQExchangeErrors(t *testing.T) {
+	server := newQQStub(t,
+		map[string]interface{}{"access_token": "token-123"},
+		map[string]interface{}{"client_id": "other-app", "openid": "openid-abc"},
+		map[string]interface{}{"ret": 0},
+	)
+	q := newTestQQ(server)
+
+	if _, err := q.Exchange(context.Background(), "bad-code", AuthRequest{}); err == nil {
+		t.Errorf("错误的授权码应当返回错误")
+	}
+	if _, err := q.Exchange(context.Background(), "good-code", AuthRequest{}); err == nil || !strings.Contains(err.Error(), "不属于当前应用") {
+		t.Errorf("其他应用的 access_token 应当被拒绝，实际: %v", err)
+	}
+
+	q.Config.Enable = false
+	if _, err := q.Exchange(context.Background(), "good-code", AuthRequest{}); err != ErrProviderDisabled {
+		t.Errorf("未启用时应当返回 ErrProviderDisabled，实际: %v", err)
+	}
+}
+
+func TestQQAuthURL(t *testing.T) {
+	q := NewQQ(config.QQ{Enable: true, AppID: "app-1", RedirectURI: "http://localhost/callback"}, nil)
+	raw, err := q.AuthURL(AuthRequest{State: "state-1"})
+	if err != nil {
+		t.Fatalf("生成授权地址失败: %v", err)
+	}
+	parsed, _ := url.Parse(raw)
+	query := parsed.Query()
+	if parsed.Host != "graph.qq.com" || query.Get("client_id") != "app-1" || query.Get("state") != "state-1" || query.Get("redirect_uri") != "http://localhost/callback" {
+		t.Errorf("授权地址错误: %s", raw)
+	}
+}
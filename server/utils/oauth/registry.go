@@ -0,0 +1,81 @@
+package oauth
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"server/config"
+	"server/model/appType"
+)
+
+// oidcNamePattern 通用提供方名称：用于接口路径，并写入 users.login_method（最长20个字符）
+var oidcNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,20}$`)
+
+// Registry 已启用的提供方集合，按注册顺序保存
+type Registry struct {
+	providers map[string]Provider
+	names     []string
+}
+
+// NewRegistry 创建空的提供方集合
+func NewRegistry() *Registry {
+	return &Registry{providers: make(map[string]Provider)}
+}
+
+// Register 注册提供方，名称重复时返回错误
+func (r *Registry) Register(p Provider) error {
+	name := p.Name()
+	if name == "" {
+		return fmt.Errorf("提供方名称不能为空")
+	}
+	if _, ok := r.providers[name]; ok {
+		return fmt.Errorf("提供方名称重复: %s", name)
+	}
+	r.providers[name] = p
+	r.names = append(r.names, name)
+	return nil
+}
+
+// Get 按名称获取提供方
+func (r *Registry) Get(name string) (Provider, bool) {
+	p, ok := r.providers[name]
+	return p, ok
+}
+
+// Names 已注册的提供方名称
+func (r *Registry) Names() []string {
+	return append([]string(nil), r.names...)
+}
+
+// BuildRegistry 根据配置注册所有已启用的提供方，client 为空时使用 DefaultHTTPClient
+func BuildRegistry(qq config.QQ, conf config.OAuth, client *http.Client) (*Registry, error) {
+	registry := NewRegistry()
+	if qq.Enable {
+		if err := registry.Register(NewQQ(qq, client)); err != nil {
+			return nil, err
+		}
+	}
+	if conf.GitHub.Enable {
+		if err := registry.Register(NewGitHub(conf.GitHub, client)); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range conf.OIDC {
+		if !p.Enable {
+			continue
+		}
+		if !oidcNamePattern.MatchString(p.Name) {
+			return nil, fmt.Errorf("提供方名称只能包含小写字母、数字、下划线和连字符，且不超过20个字符: %s", p.Name)
+		}
+		// 通用提供方不能占用内置登录方式的名称，否则 LoginMethod 会产生歧义
+		for _, builtin := range appType.SupportedLoginTypes {
+			if appType.LoginType(p.Name) == builtin {
+				return nil, fmt.Errorf("提供方名称不可用: %s", p.Name)
+			}
+		}
+		if err := registry.Register(NewOIDC(p, client)); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
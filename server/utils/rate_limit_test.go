@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"server/config"
+	"server/global"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"go.uber.org/zap"
+)
+
+// newTestRedis 启动内存 Redis（miniredis，执行真实的 Lua 脚本），时间通过 FastForward 推进
+func newTestRedis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	global.Redis = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	global.ZapLog = zap.NewNop()
+	global.Config = &config.Config{}
+	t.Cleanup(func() { global.Redis.Close() })
+	return mr
+}
+
+func TestIncrWindowCounterRollover(t *testing.T) {
+	mr := newTestRedis(t)
+	steps := []struct {
+		advance   time.Duration
+		wantCount int64
+		wantReset time.Duration
+	}{
+		{0, 1, time.Minute},
+		{20 * time.Second, 2, 40 * time.Second},
+		{39 * time.Second, 3, time.Second},
+		{time.Second, 1, time.Minute}, // 窗口过期后重新计数
+		{30 * time.Second, 2, 30 * time.Second},
+	}
+	for i, step := range steps {
+		mr.FastForward(step.advance)
+		count, reset, err := IncrWindowCounter("rate_limit:test", time.Minute)
+		if err != nil {
+			t.Fatalf("第 %d 步计数失败: %v", i+1, err)
+		}
+		if count != step.wantCount || reset != step.wantReset {
+			t.Errorf("第 %d 步计数为 %d、剩余 %v，期望 %d、%v", i+1, count, reset, step.wantCount, step.wantReset)
+		}
+	}
+}
+
+func TestRecordLoginFailureEscalation(t *testing.T) {
+	newTestRedis(t)
+	global.Config.RateLimit.Lockout = config.LoginLockout{
+		MaxFailures:   3,
+		FailureWindow: 15 * time.Minute,
+		BaseDuration:  time.Minute,
+		MaxDuration:   10 * time.Minute,
+	}
+	// 前两次只计数，第三次开始锁定，此后每次翻倍直到上限
+	want := []time.Duration{0, 0, time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute, 10 * time.Minute}
+	for i, duration := range want {
+		if got := RecordLoginFailure("Alice", "10.0.0.1"); got != duration {
+			t.Errorf("第 %d 次失败锁定 %v，期望 %v", i+1, got, duration)
+		}
+		ttl, locked := GetLoginLockout("alice", "10.0.0.1")
+		if locked != (duration > 0) || ttl != duration {
+			t.Errorf("第 %d 次失败后锁定状态为 %v（剩余 %v），期望锁定 %v", i+1, locked, ttl, duration)
+		}
+	}
+
+	// 锁定只针对失败来源的IP，其他IP仍可正常登录该账户
+	if _, locked := GetLoginLockout("alice", "10.0.0.2"); locked {
+		t.Errorf("其他IP不应被锁定")
+	}
+	if got := RecordLoginFailure("alice", "10.0.0.2"); got != 0 {
+		t.Errorf("其他IP的失败次数应单独计算，实际锁定 %v", got)
+	}
+}
+
+func TestResetLoginFailures(t *testing.T) {
+	mr := newTestRedis(t)
+	global.Config.RateLimit.Lockout = config.LoginLockout{MaxFailures: 2, FailureWindow: 15 * time.Minute, BaseDuration: time.Minute}
+
+	RecordLoginFailure("bob", "10.0.0.1")
+	if got := RecordLoginFailure("bob", "10.0.0.1"); got != time.Minute {
+		t.Fatalf("达到阈值后应锁定1分钟，实际为 %v", got)
+	}
+	ResetLoginFailures("BOB", "10.0.0.1")
+	if _, locked := GetLoginLockout("bob", "10.0.0.1"); locked {
+		t.Errorf("登录成功后应解除锁定")
+	}
+	if got := RecordLoginFailure("bob", "10.0.0.1"); got != 0 {
+		t.Errorf("登录成功后失败次数应重新计算，实际锁定 %v", got)
+	}
+
+	// 锁定期间失败计数不会先于锁定过期
+	RecordLoginFailure("bob", "10.0.0.1")
+	mr.FastForward(time.Minute)
+	if got := RecordLoginFailure("bob", "10.0.0.1"); got != 2*time.Minute {
+		t.Errorf("锁定过期后再次失败应继续退避，实际锁定 %v", got)
+	}
+}
+
+func TestAcquireEmailCooldown(t *testing.T) {
+	mr := newTestRedis(t)
+	global.Config.RateLimit.EmailCooldown = time.Minute
+
+	steps := []struct {
+		email    string
+		advance  time.Duration
+		wantOK   bool
+		wantWait time.Duration
+	}{
+		{"user@example.com", 0, true, 0},
+		{"User@Example.com", 10 * time.Second, false, 50 * time.Second}, // 邮箱不区分大小写
+		{"other@example.com", 0, true, 0},
+		{"user@example.com", 50 * time.Second, true, 0},
+	}
+	for i, step := range steps {
+		mr.FastForward(step.advance)
+		wait, ok := AcquireEmailCooldown(step.email)
+		if ok != step.wantOK || wait != step.wantWait {
+			t.Errorf("第 %d 步结果为 %v（等待 %v），期望 %v（等待 %v）", i+1, ok, wait, step.wantOK, step.wantWait)
+		}
+	}
+
+	global.Config.RateLimit.EmailCooldown = 0
+	if _, ok := AcquireEmailCooldown("user@example.com"); !ok {
+		t.Errorf("未配置冷却期时不应限制发送")
+	}
+}
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录B的 SHA1 测试向量（取后6位）
+func TestTOTPCode(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range cases {
+		got, err := TOTPCode(secret, TOTPCounter(time.Unix(unix, 0)))
+		if err != nil {
+			t.Fatalf("计算验证码失败: %v", err)
+		}
+		if got != want {
+			t.Errorf("时间 %d 的验证码为 %s，期望 %s", unix, got, want)
+		}
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("生成密钥失败: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	code, _ := TOTPCode(secret, TOTPCounter(now.Add(-30*time.Second)))
+
+	if _, ok := ValidateTOTP(secret, code, now); !ok {
+		t.Errorf("上一个时间步的验证码应当通过校验")
+	}
+	if _, ok := ValidateTOTP(secret, code, now.Add(90*time.Second)); ok {
+		t.Errorf("超出允许偏差的验证码不应通过校验")
+	}
+}
+
+func TestHashRecoveryCodeNormalizes(t *testing.T) {
+	if HashRecoveryCode("AbCde-12345") != HashRecoveryCode(" abcde12345 ") {
+		t.Errorf("恢复码哈希应忽略大小写、空白和连字符")
+	}
+}
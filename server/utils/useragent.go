@@ -0,0 +1,24 @@
+package utils
+
+import "strings"
+
+// botUserAgentKeywords 爬虫、监控和命令行工具 User-Agent 中常见的关键字（小写）
+var botUserAgentKeywords = []string{
+	"bot", "crawl", "spider", "slurp", "archiver", "facebookexternalhit", "embedly", "preview",
+	"monitor", "pingdom", "uptime", "lighthouse", "headless", "phantomjs", "selenium", "puppeteer",
+	"curl", "wget", "python-", "go-http-client", "java/", "okhttp", "axios", "node-fetch", "httpclient", "libwww",
+}
+
+// IsBot 根据 User-Agent 判断请求是否来自爬虫或脚本，空 User-Agent 也视为爬虫
+func IsBot(userAgent string) bool {
+	ua := strings.ToLower(strings.TrimSpace(userAgent))
+	if ua == "" {
+		return true
+	}
+	for _, keyword := range botUserAgentKeywords {
+		if strings.Contains(ua, keyword) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package utils
+
+import "testing"
+
+func TestIsBot(t *testing.T) {
+	bots := []string{
+		"",
+		"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+		"Mozilla/5.0 (compatible; Baiduspider/2.0; +http://www.baidu.com/search/spider.html)",
+		"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/120.0.0.0 Safari/537.36",
+		"curl/8.4.0",
+		"python-requests/2.31.0",
+		"Go-http-client/1.1",
+	}
+	for _, ua := range bots {
+		if !IsBot(ua) {
+			t.Errorf("IsBot(%q) = false, want true", ua)
+		}
+	}
+
+	browsers := []string{
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1",
+		"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36 MicroMessenger/8.0.44",
+	}
+	for _, ua := range browsers {
+		if IsBot(ua) {
+			t.Errorf("IsBot(%q) = true, want false", ua)
+		}
+	}
+}